@@ -0,0 +1,181 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/service"
+)
+
+// benchCase is one encode of the matrix, repeated parallel times at once
+type benchCase struct {
+	codec      string // Spec codec name, e.g. h264
+	encoder    string // FFmpeg encoder, e.g. libx264
+	resolution string
+	preset     string // "default" (config.VideoCodecArgs), "fast" (degraded encode args) or "hw"
+	args       []string
+}
+
+type benchResult struct {
+	elapsed time.Duration
+	err     error
+}
+
+func main() {
+	var (
+		codecs      = flag.String("codecs", "h264,h265,vp9,av1", "Comma separated spec codecs")
+		resolutions = flag.String("resolutions", "480p,720p,1080p", "Comma separated resolutions")
+		presets     = flag.String("presets", "default,fast", "default uses server encode args, fast the degraded ones used under load")
+		duration    = flag.Int("duration", 10, "Seconds of test pattern encoded per run")
+		fps         = flag.Int("fps", 30, "Frame rate of test pattern")
+		parallel    = flag.Int("parallel", 1, "Identical encodes run at once, compare totals with TRANSCODE_WORKERS sizes")
+		hw          = flag.Bool("hw", false, "Also benchmark available hardware encoders")
+	)
+	flag.Parse()
+
+	ffmpeg := service.GetFFmpegInfo()
+	if ffmpeg.Error != "" {
+		log.Fatalf("FFmpeg not available: %s", ffmpeg.Error)
+	}
+
+	fmt.Printf("Lorem Video Encoder Benchmark\n")
+	fmt.Printf("FFmpeg: %s\n", ffmpeg.Version)
+	fmt.Printf("Input: testsrc2 %ds at %dfps, %d parallel\n\n", *duration, *fps, *parallel)
+
+	cases, skipped := buildMatrix(split(*codecs), split(*resolutions), split(*presets), *hw, ffmpeg.Encoders)
+	for _, reason := range skipped {
+		fmt.Printf("Skipping %s\n", reason)
+	}
+	if len(skipped) > 0 {
+		fmt.Println()
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(table, "codec\tencoder\tresolution\tpreset\twall\tfps/job\tfps total\tspeed/job\t")
+
+	frames := float64(*duration * *fps)
+	for _, benchCase := range cases {
+		results := run(benchCase, *duration, *fps, *parallel)
+
+		var slowest time.Duration
+		var failed error
+		for _, result := range results {
+			slowest = max(slowest, result.elapsed)
+			if result.err != nil {
+				failed = result.err
+			}
+		}
+		if failed != nil {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\tfailed: %v\t\t\t\t\n", benchCase.codec, benchCase.encoder, benchCase.resolution, benchCase.preset, failed)
+			table.Flush()
+			continue
+		}
+
+		jobFPS := frames / slowest.Seconds()
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s\t%s\t%.1f\t%.1f\t%.2fx\t\n",
+			benchCase.codec, benchCase.encoder, benchCase.resolution, benchCase.preset,
+			slowest.Round(10*time.Millisecond), jobFPS, jobFPS*float64(*parallel), float64(*duration)/slowest.Seconds())
+		table.Flush() // Show each row as soon as it's done, full matrix takes minutes
+	}
+}
+
+// buildMatrix expands flags to cases, encoders missing from FFmpeg build are skipped with reason
+func buildMatrix(codecs, resolutions, presets []string, hw bool, available []string) ([]benchCase, []string) {
+	var cases []benchCase
+	var skipped []string
+
+	for _, resolution := range resolutions {
+		if _, ok := config.Resolutions[resolution]; !ok {
+			skipped = append(skipped, fmt.Sprintf("unknown resolution %s", resolution))
+			continue
+		}
+
+		for _, codec := range codecs {
+			encoder, ok := config.VideoCodecNameMap[codec]
+			if !ok || encoder == "none" {
+				skipped = append(skipped, fmt.Sprintf("unknown codec %s", codec))
+				continue
+			}
+			if !slices.Contains(available, encoder) {
+				skipped = append(skipped, fmt.Sprintf("%s %s: encoder not in FFmpeg build", codec, resolution))
+				continue
+			}
+
+			for _, preset := range presets {
+				argsMap := config.VideoCodecArgs
+				if preset == "fast" {
+					argsMap = config.VideoCodecFastArgs
+				} else if preset != "default" {
+					skipped = append(skipped, fmt.Sprintf("unknown preset %s", preset))
+					continue
+				}
+				cases = append(cases, benchCase{codec: codec, encoder: encoder, resolution: resolution, preset: preset, args: argsMap[encoder]})
+			}
+		}
+
+		// Hardware encoders (h264_nvenc, hevc_qsv, ...) run with FFmpeg defaults since options differ per vendor
+		if hw {
+			for _, encoder := range available {
+				if codec, _, ok := strings.Cut(encoder, "_"); ok {
+					cases = append(cases, benchCase{codec: codec, encoder: encoder, resolution: resolution, preset: "hw"})
+				}
+			}
+		}
+	}
+
+	return cases, skipped
+}
+
+// run encodes test pattern parallel times at once into null muxer
+func run(benchCase benchCase, duration, fps, parallel int) []benchResult {
+	resolution := config.Resolutions[benchCase.resolution]
+	args := []string{
+		"-hide_banner", "-loglevel", "error",
+		"-f", "lavfi",
+		"-i", fmt.Sprintf("testsrc2=size=%dx%d:rate=%d:duration=%d", resolution.Width, resolution.Height, fps, duration),
+		"-pix_fmt", "yuv420p",
+		"-c:v", benchCase.encoder,
+	}
+	args = append(args, benchCase.args...)
+	if benchCase.preset != "hw" {
+		args = append(args, "-crf", "25") // Same as pregenerated specs
+	}
+	args = append(args, "-f", "null", "-")
+
+	results := make([]benchResult, parallel)
+	var wg sync.WaitGroup
+	for i := range parallel {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			output, err := exec.Command("ffmpeg", args...).CombinedOutput()
+			if err != nil {
+				firstLine, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+				err = fmt.Errorf("%w: %s", err, firstLine)
+			}
+			results[i] = benchResult{elapsed: time.Since(started), err: err}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+func split(value string) []string {
+	var parts []string
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lorem.video/internal/config"
+)
+
+// videoCheck is spec requested from server and what ffprobe must find in response
+type videoCheck struct {
+	spec       string
+	codec      string
+	width      int
+	height     int
+	duration   float64
+	audioCodec string // Empty when no audio stream expected
+}
+
+// videoChecks cover real and synthetic sources, both containers and main codecs, kept tiny so run takes seconds
+var videoChecks = []videoCheck{
+	{spec: "bunny_426x240_2s", codec: "h264", width: 426, height: 240, duration: 2, audioCodec: "aac"},
+	{spec: "bunny_426x240_2s_vp9_opus.webm", codec: "vp9", width: 426, height: 240, duration: 2, audioCodec: "opus"},
+	{spec: "bunny_426x240_2s_av1_noaudio", codec: "av1", width: 426, height: 240, duration: 2},
+	{spec: "avsync_426x240_2s", codec: "h264", width: 426, height: 240, duration: 2, audioCodec: "aac"},
+	{spec: "bars_640x360_2s_h265", codec: "hevc", width: 640, height: 360, duration: 2, audioCodec: "aac"},
+}
+
+type selfTest struct {
+	baseURL string
+	client  *http.Client
+	timeout time.Duration // Per check, covers transcoding
+	tmpDir  string
+	failed  int
+}
+
+func main() {
+	os.Exit(run())
+}
+
+// run returns exit code, so deferred server stop and temp dir removal happen on failure too
+func run() int {
+	var (
+		baseURL = flag.String("url", "", "Test already running server (e.g. http://localhost:3000) instead of starting one")
+		server  = flag.String("server", "", "Server binary to start, default builds ./cmd/server with go build")
+		timeout = flag.Duration("timeout", 2*time.Minute, "Per check timeout including transcoding")
+		keep    = flag.Bool("keep", false, "Keep temporary data dir of started server")
+	)
+	flag.Parse()
+
+	tmpDir, err := os.MkdirTemp("", "lorem-video-selftest-")
+	if err != nil {
+		log.Printf("Failed to create temp dir: %v", err)
+		return 1
+	}
+	if !*keep {
+		defer os.RemoveAll(tmpDir)
+	}
+
+	test := &selfTest{baseURL: strings.TrimSuffix(*baseURL, "/"), client: &http.Client{Timeout: time.Minute}, timeout: *timeout, tmpDir: tmpDir}
+
+	if test.baseURL == "" {
+		stop, err := test.startServer(*server)
+		if err != nil {
+			log.Printf("Failed to start server: %v", err)
+			return 1
+		}
+		defer stop()
+	}
+
+	fmt.Printf("Lorem Video Self-Test\n")
+	fmt.Printf("Server: %s\n\n", test.baseURL)
+
+	test.check("GET /version", test.checkVersion)
+	test.check("GET /api/stats/runtime", test.checkRuntimeStats)
+	for _, video := range videoChecks {
+		test.check("GET /"+video.spec, func() error { return test.checkVideo(video) })
+	}
+	test.check("GET /getInfo/"+videoChecks[0].spec, func() error { return test.checkInfo(videoChecks[0]) })
+	test.check("GET /hls/bunny/playlist.m3u8", test.checkHLS)
+
+	fmt.Println()
+	if test.failed > 0 {
+		fmt.Printf("FAILED: %d check(s)\n", test.failed)
+		return 1
+	}
+	fmt.Println("All checks passed")
+	return 0
+}
+
+func (t *selfTest) check(name string, run func() error) {
+	started := time.Now()
+	err := run()
+	elapsed := time.Since(started).Round(time.Millisecond)
+	if err != nil {
+		t.failed++
+		fmt.Printf("FAIL  %s (%s): %v\n", name, elapsed, err)
+		return
+	}
+	fmt.Printf("ok    %s (%s)\n", name, elapsed)
+}
+
+// startServer runs server on random free port with fresh data dir, bunny only and HLS as the only pregeneration
+func (t *selfTest) startServer(binary string) (func(), error) {
+	if binary == "" {
+		binary = filepath.Join(t.tmpDir, "server")
+		build := exec.Command("go", "build", "-o", binary, "./cmd/server")
+		build.Stdout, build.Stderr = os.Stdout, os.Stderr
+		if err := build.Run(); err != nil {
+			return nil, fmt.Errorf("go build: %w", err)
+		}
+	} else if absolute, err := filepath.Abs(binary); err == nil {
+		binary = absolute
+	}
+
+	port, err := freePort()
+	if err != nil {
+		return nil, err
+	}
+	t.baseURL = fmt.Sprintf("http://localhost:%d", port)
+
+	logFile, err := os.Create(filepath.Join(t.tmpDir, "server.log"))
+	if err != nil {
+		return nil, err
+	}
+
+	// DATA_DIR keeps server off /data of deployment when run inside Docker image
+	cmd := exec.Command(binary)
+	cmd.Dir = t.tmpDir
+	cmd.Env = append(os.Environ(),
+		"DATA_DIR="+filepath.Join(t.tmpDir, "data"),
+		fmt.Sprintf("PORT=%d", port),
+		"BASE_URL="+t.baseURL,
+		"DEFAULT_SOURCES=none",
+		"PREGEN_DEFAULT=hls",
+	)
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	stop := func() {
+		cmd.Process.Signal(os.Interrupt)
+		<-exited
+		logFile.Close()
+	}
+
+	// Startup generates bunny.mp4 first, server listens once it's done
+	deadline := time.Now().Add(t.timeout)
+	for time.Now().Before(deadline) {
+		if resp, err := t.client.Get(t.baseURL + "/version"); err == nil {
+			resp.Body.Close()
+			fmt.Printf("Started server (log %s)\n", logFile.Name())
+			return stop, nil
+		}
+		select {
+		case <-exited:
+			return nil, fmt.Errorf("server exited, see %s", logFile.Name())
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	stop()
+	return nil, fmt.Errorf("server not reachable at %s, see %s", t.baseURL, logFile.Name())
+}
+
+func (t *selfTest) checkVersion() error {
+	var version struct {
+		Version string `json:"version"`
+		FFmpeg  struct {
+			Version  string   `json:"version"`
+			Encoders []string `json:"encoders"`
+			Error    string   `json:"error"`
+		} `json:"ffmpeg"`
+	}
+	if err := t.getJSON("/version", &version); err != nil {
+		return err
+	}
+	if version.FFmpeg.Error != "" {
+		return fmt.Errorf("server can't run FFmpeg: %s", version.FFmpeg.Error)
+	}
+	fmt.Printf("      version %s, FFmpeg %s, encoders %s\n", version.Version, version.FFmpeg.Version, strings.Join(version.FFmpeg.Encoders, " "))
+	return nil
+}
+
+func (t *selfTest) checkRuntimeStats() error {
+	var runtimeStats map[string]any
+	return t.getJSON("/api/stats/runtime", &runtimeStats)
+}
+
+// checkVideo polls spec until transcode completes, downloads it and compares ffprobe output with expectation
+func (t *selfTest) checkVideo(video videoCheck) error {
+	path, err := t.waitForVideo(video.spec)
+	if err != nil {
+		return err
+	}
+
+	probe, err := ffprobe(path)
+	if err != nil {
+		return err
+	}
+
+	var videoCodec, audioCodec string
+	var width, height int
+	for _, stream := range probe.Streams {
+		switch stream.CodecType {
+		case "video":
+			videoCodec, width, height = stream.CodecName, stream.Width, stream.Height
+		case "audio":
+			audioCodec = stream.CodecName
+		}
+	}
+
+	var duration float64
+	fmt.Sscanf(probe.Format.Duration, "%g", &duration)
+
+	switch {
+	case videoCodec != video.codec:
+		return fmt.Errorf("video codec %q, expected %q", videoCodec, video.codec)
+	case width != video.width || height != video.height:
+		return fmt.Errorf("resolution %dx%d, expected %dx%d", width, height, video.width, video.height)
+	case audioCodec != video.audioCodec:
+		return fmt.Errorf("audio codec %q, expected %q", audioCodec, video.audioCodec)
+	case math.Abs(duration-video.duration) > 0.5:
+		return fmt.Errorf("duration %.2fs, expected %.0fs", duration, video.duration)
+	}
+	return nil
+}
+
+// waitForVideo retries while server answers 202 or serves still growing file (no-store), returns downloaded path
+func (t *selfTest) waitForVideo(spec string) (string, error) {
+	deadline := time.Now().Add(t.timeout)
+	for {
+		resp, err := t.client.Head(t.baseURL + "/" + spec)
+		if err != nil {
+			return "", err
+		}
+		resp.Body.Close()
+
+		transcoding := resp.StatusCode == http.StatusAccepted ||
+			(resp.StatusCode == http.StatusOK && strings.Contains(resp.Header.Get("Cache-Control"), "no-store"))
+		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+			return "", fmt.Errorf("status %d", resp.StatusCode)
+		}
+		if !transcoding {
+			break
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("still transcoding after %s", t.timeout)
+		}
+		time.Sleep(time.Second)
+	}
+
+	path := filepath.Join(t.tmpDir, "download-"+strings.ReplaceAll(spec, "/", "_"))
+	return path, t.download("/"+spec, path)
+}
+
+func (t *selfTest) checkInfo(video videoCheck) error {
+	var info config.FFProbeOutput
+	if err := t.getJSON("/getInfo/"+video.spec, &info); err != nil {
+		return err
+	}
+	if info.Summary == nil {
+		return fmt.Errorf("response has no summary")
+	}
+	if expected := fmt.Sprintf("%dx%d", video.width, video.height); info.Summary.Resolution != expected {
+		return fmt.Errorf("summary resolution %s, expected %s", info.Summary.Resolution, expected)
+	}
+	return nil
+}
+
+// checkHLS waits for pregenerated master playlist, then probes init segment plus first chunk of first variant
+func (t *selfTest) checkHLS() error {
+	deadline := time.Now().Add(t.timeout)
+	var master string
+	for {
+		body, status, err := t.get("/hls/bunny/playlist.m3u8")
+		if err != nil {
+			return err
+		}
+		if status == http.StatusOK {
+			master = body
+			break
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("master playlist status %d after %s, HLS is pregenerated at startup", status, t.timeout)
+		}
+		time.Sleep(2 * time.Second)
+	}
+
+	variant := firstURI(master)
+	if variant == "" {
+		return fmt.Errorf("master playlist has no variants")
+	}
+	variantPath := urlPath(variant, "/hls/bunny/")
+	media, status, err := t.get(variantPath)
+	if err != nil || status != http.StatusOK {
+		return fmt.Errorf("variant %s: status %d %v", variantPath, status, err)
+	}
+	if !strings.Contains(media, "#EXT-X-MAP") {
+		return fmt.Errorf("media playlist %s has no init segment", variantPath)
+	}
+
+	segment := firstURI(media)
+	if segment == "" {
+		return fmt.Errorf("media playlist %s has no segments", variantPath)
+	}
+
+	// fMP4 segment is only decodable after init segment
+	variantDir := variantPath[:strings.LastIndex(variantPath, "/")+1]
+	var data strings.Builder
+	for _, path := range []string{urlPath(config.HLSInit, variantDir), urlPath(segment, variantDir)} {
+		body, status, err := t.get(path)
+		if err != nil || status != http.StatusOK || len(body) == 0 {
+			return fmt.Errorf("%s: status %d, %d bytes %v", path, status, len(body), err)
+		}
+		data.WriteString(body)
+	}
+
+	path := filepath.Join(t.tmpDir, "hls-segment.mp4")
+	if err := os.WriteFile(path, []byte(data.String()), 0644); err != nil {
+		return err
+	}
+	probe, err := ffprobe(path)
+	if err != nil {
+		return err
+	}
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "video" && stream.Width > 0 {
+			return nil
+		}
+	}
+	return fmt.Errorf("segment of %s has no video stream", variantPath)
+}
+
+func (t *selfTest) get(path string) (string, int, error) {
+	resp, err := t.client.Get(t.baseURL + path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), resp.StatusCode, err
+}
+
+func (t *selfTest) getJSON(path string, target any) error {
+	body, status, err := t.get(path)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("status %d: %s", status, strings.TrimSpace(body))
+	}
+	return json.Unmarshal([]byte(body), target)
+}
+
+func (t *selfTest) download(path, destination string) error {
+	resp, err := t.client.Get(t.baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("download status %d", resp.StatusCode)
+	}
+
+	file, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, resp.Body)
+	return err
+}
+
+func ffprobe(path string) (*config.FFProbeOutput, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, "ffprobe", "-v", "error", "-print_format", "json", "-show_format", "-show_streams", path).Output()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe: %w", err)
+	}
+	var probe config.FFProbeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("ffprobe output: %w", err)
+	}
+	return &probe, nil
+}
+
+// firstURI returns first non-tag line of playlist
+func firstURI(playlist string) string {
+	scanner := bufio.NewScanner(strings.NewReader(playlist))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			return line
+		}
+	}
+	return ""
+}
+
+// urlPath resolves playlist URI (absolute URL, absolute path or relative to dir) to server path
+func urlPath(uri, dir string) string {
+	if parsed, err := url.Parse(uri); err == nil && parsed.IsAbs() {
+		return parsed.Path
+	}
+	if strings.HasPrefix(uri, "/") {
+		return uri
+	}
+	return dir + uri
+}
+
+func freePort() (int, error) {
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return 0, err
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port, nil
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"lorem.video/internal/abuse"
+	"lorem.video/internal/alert"
+	"lorem.video/internal/alias"
 	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
 	"lorem.video/internal/rest"
 	"lorem.video/internal/service"
 	"lorem.video/internal/stats"
+	"lorem.video/internal/tenant"
+	"lorem.video/internal/upload"
 )
 
 func main() {
@@ -20,9 +31,130 @@ func main() {
 		log.Fatalf("Failed to create default source video: %v", err)
 	}
 
-	service.StartupPregeneration()
+	indexRoots := []string{config.AppPaths.Video, config.AppPaths.Tmp, config.AppPaths.Stream, config.AppPaths.HLSCache, config.AppPaths.Tenants}
+	if config.AppPaths.TmpRAM != "" {
+		indexRoots = append(indexRoots, config.AppPaths.TmpRAM)
+	}
+	if err := fileindex.Build(indexRoots...); err != nil {
+		log.Fatalf("Failed to build file index: %v", err)
+	}
+	fileindex.StartRefresh(config.GetEnvDuration("FILE_INDEX_REFRESH", 10*time.Minute))
+
+	if ramConfig := service.RAMTmpConfigFromEnv(); ramConfig.Enabled() {
+		service.StartRAMTmp(ramConfig)
+	}
 
+	service.SetSchedulerConfig(service.SchedulerConfigFromEnv())
+	service.SetStreamConfig(service.StreamConfigFromEnv())
+	service.StartJobLogPruning(service.JobLogConfigFromEnv())
+	stats.SetTrustedProxies(stats.TrustedProxiesFromEnv())
+	if degradePolicy := service.DegradePolicyFromEnv(); degradePolicy.Enabled() {
+		service.SetDegradePolicy(degradePolicy)
+	}
+	service.RestoreDegradedFiles(config.AppPaths.Tmp, config.AppPaths.TmpRAM, config.AppPaths.Tenants)
+
+	service.StartupPregeneration()
 	rest := rest.New()
+	if warmConfig := service.WarmConfigFromEnv(); warmConfig.Enabled() {
+		warmConfig.Paused = rest.InMaintenance
+		service.StartCacheWarming(warmConfig)
+	}
+	var tenants *tenant.Registry
+	if tenantsFile := os.Getenv("TENANTS_FILE"); tenantsFile != "" {
+		registry, err := tenant.Load(tenantsFile)
+		if err != nil {
+			log.Fatalf("Failed to load tenants: %v", err)
+		}
+		registry.StartUsagePersistence(time.Minute)
+		rest.SetTenantRegistry(registry)
+		tenants = registry
+	}
+	if aliasStore, err := alias.Open(config.AppPaths.Aliases); err != nil {
+		log.Printf("Warning: Failed to load aliases: %v", err)
+	} else {
+		rest.SetAliasStore(aliasStore)
+	}
+	rest.SetUploadStore(upload.NewStore(config.AppPaths.Uploads, upload.ConfigFromEnv()), service.SourcePolicyFromEnv())
+	mux := routes(rest)
+
+	geoDB, err := stats.LoadGeoDB(config.AppPaths.GeoIP)
+	if err != nil {
+		log.Printf("Warning: Failed to load GeoIP data: %v", err)
+	}
+	rest.SetGeoDB(geoDB)
+
+	var observers []stats.Observer
+	if alertConfig := alert.ConfigFromEnv(); alertConfig.Enabled() {
+		observers = append(observers, alert.NewMonitor(alertConfig).Observe)
+	}
+
+	if tenants != nil {
+		observers = append(observers, func(request stats.RequestStats) {
+			if request.Tenant != "" {
+				tenants.RecordEgress(request.Tenant, request.ResponseSize*int64(request.Weight()))
+			}
+		})
+	}
+
+	var detector *abuse.Detector
+	if abuseConfig := abuse.ConfigFromEnv(); abuseConfig.Enabled() {
+		detector = abuse.NewDetector(abuseConfig)
+		rest.SetAbuseDetector(detector)
+		observers = append(observers, detector.Observe)
+	}
+
+	flushInterval := config.GetEnvDuration("STATS_FLUSH_INTERVAL", 0)
+	fileSink, err := stats.NewFileSink(config.AppPaths.LogsStats, flushInterval)
+	if err != nil {
+		log.Printf("Warning: Failed to create stats logger: %v", err)
+	}
+	sink := stats.MultiSink(fileSink)
+	if remoteURL := os.Getenv("STATS_REMOTE_URL"); remoteURL != "" {
+		sink = stats.MultiSink(fileSink, stats.NewRemoteSink(remoteURL, flushInterval))
+	}
+
+	logOptions := stats.LogOptions{SampleChunks: int(config.GetEnvInt64("STATS_SAMPLE_CHUNKS"))}
+	statsMiddleware := stats.StatsMiddleware(sink, geoDB, logOptions, observers...)
+	serverConfig := config.GetServerConfig()
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.Port),
+		Handler:           newHandler(rest, mux, statsMiddleware, detector, serverConfig.MaxBodyBytes),
+		ReadHeaderTimeout: serverConfig.ReadHeaderTimeout,
+		IdleTimeout:       serverConfig.IdleTimeout,
+		WriteTimeout:      serverConfig.WriteTimeout,
+		MaxHeaderBytes:    serverConfig.MaxHeaderBytes,
+	}
+
+	// Deploys send SIGTERM, buffered stats and tenant usage are written out before exit
+	stopped := make(chan struct{})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+		log.Printf("Shutting down, waiting up to %s for open requests...", serverConfig.ShutdownTimeout)
+		ctx, cancel := context.WithTimeout(context.Background(), serverConfig.ShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Warning: Shutdown didn't finish cleanly: %v", err)
+		}
+		close(stopped)
+	}()
+
+	log.Printf("Server starting on port %d...", config.Port)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+	<-stopped
+
+	if err := sink.Close(); err != nil {
+		log.Printf("Warning: Failed to close stats sink: %v", err)
+	}
+	if tenants != nil {
+		tenants.SaveUsage()
+	}
+}
+
+func routes(rest *rest.Rest) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("GET /", rest.ServeDocumentation)
@@ -31,14 +163,48 @@ func main() {
 	mux.HandleFunc("GET /web/{path...}", rest.ServeStaticFiles)
 	mux.HandleFunc("GET /getInfo/{name}", rest.GetVideoInfo)
 	mux.HandleFunc("GET /transcode/{params}", rest.Transcode)
+	mux.HandleFunc("POST /batch", rest.Batch)
+	mux.HandleFunc("GET /random", rest.Random)
+	mux.HandleFunc("GET /playlist.m3u", rest.ServePlaylist)
+	mux.HandleFunc("GET /simulate/{code}", rest.Simulate)
+	mux.HandleFunc("GET /api/stats/runtime", rest.GetRuntimeStats)
+	mux.HandleFunc("GET /version", rest.GetVersion)
+	mux.HandleFunc("GET /api/usage", rest.GetUsage)
+	mux.HandleFunc("GET /api/stats/countries", rest.AdminOnly(rest.GetCountryStats))
+	mux.HandleFunc("GET /api/abuse/bans", rest.AdminOnly(rest.GetBans))
+	mux.HandleFunc("DELETE /api/abuse/bans", rest.AdminOnly(rest.Audited("bans.clear", rest.ClearBans)))
+	mux.HandleFunc("DELETE /api/abuse/bans/{ip}", rest.AdminOnly(rest.Audited("bans.delete", rest.DeleteBan)))
+	mux.HandleFunc("GET /admin/errors", rest.AdminOnly(rest.GetErrors))
+	mux.HandleFunc("GET /admin/audit", rest.AdminOnly(rest.GetAudit))
+	mux.HandleFunc("GET /jobs/{id}/log", rest.AdminOnly(rest.GetJobLog))
+	mux.HandleFunc("GET /admin/storage", rest.AdminOnly(rest.GetStorage))
+	mux.HandleFunc("GET /admin/maintenance", rest.AdminOnly(rest.GetMaintenance))
+	mux.HandleFunc("PUT /admin/maintenance", rest.AdminOnly(rest.Audited("maintenance.enable", rest.EnableMaintenance)))
+	mux.HandleFunc("DELETE /admin/maintenance", rest.AdminOnly(rest.Audited("maintenance.disable", rest.DisableMaintenance)))
+	mux.HandleFunc("POST /admin/uploads", rest.AdminOnly(rest.Audited("upload.create", rest.CreateUpload)))
+	mux.HandleFunc("HEAD /admin/uploads/{id}", rest.AdminOnly(rest.GetUploadOffset))
+	mux.HandleFunc("GET /admin/uploads/{id}", rest.AdminOnly(rest.GetUpload))
+	mux.HandleFunc("PATCH /admin/uploads/{id}", rest.AdminOnly(rest.PatchUpload))
+	mux.HandleFunc("DELETE /admin/uploads/{id}", rest.AdminOnly(rest.Audited("upload.delete", rest.DeleteUpload)))
+	mux.HandleFunc("GET /admin/aliases", rest.AdminOnly(rest.GetAliases))
+	mux.HandleFunc("PUT /admin/aliases/{alias}", rest.AdminOnly(rest.Audited("alias.put", rest.PutAlias)))
+	mux.HandleFunc("DELETE /admin/aliases/{alias}", rest.AdminOnly(rest.Audited("alias.delete", rest.DeleteAlias)))
+	mux.HandleFunc("GET /s/{alias}", rest.ServeAlias)
+	mux.HandleFunc("GET /hls/{videoName}/download.tar.gz", rest.DownloadHLS)
 	mux.HandleFunc("GET /hls/{videoName}/{path...}", rest.ServeHLS)
 	mux.HandleFunc("GET /{params}", rest.ServeVideo)
 
-	statsMiddleware := stats.StatsMiddleware(config.AppPaths.LogsStats)
-	handler := rest.RecoveryMiddleware(rest.BotsMiddleware(statsMiddleware(rest.CORSMiddleware(mux))))
+	return mux
+}
 
-	log.Printf("Server starting on port %d...", config.Port)
-	if err := http.ListenAndServe(fmt.Sprintf(":%d", config.Port), handler); err != nil {
-		log.Fatal(err)
+// newHandler wraps routes in middleware chain, order matters for bots, bans, tenants and CORS preflight
+func newHandler(rest *rest.Rest, mux http.Handler, statsMiddleware func(http.Handler) http.Handler, detector *abuse.Detector, maxBodyBytes int64) http.Handler {
+	handler := statsMiddleware(rest.CORSMiddleware(rest.TTFBMiddleware(mux)))
+	if detector != nil {
+		// Banned IPs are rejected before stats logging, ban endpoints stay reachable for admin
+		handler = detector.Middleware("/api/abuse/")(handler)
 	}
+	// Tenant is resolved before stats middleware so requests are logged to tenant's bucket
+	handler = rest.RequestIDMiddleware(rest.RecoveryMiddleware(rest.BotsMiddleware(rest.TenantMiddleware(handler))))
+	return rest.BodyLimitMiddleware(maxBodyBytes, "/admin/uploads/")(handler)
 }
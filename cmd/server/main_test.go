@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lorem.video/internal/rest"
+)
+
+func TestAdminUploadPreflight(t *testing.T) {
+	t.Setenv("ADMIN_TOKEN", "secret")
+	api := rest.New()
+	noStats := func(next http.Handler) http.Handler { return next }
+	handler := newHandler(api, routes(api), noStats, nil, 1<<20)
+
+	for _, path := range []string{"/admin/uploads", "/admin/uploads/abc123"} {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", "https://example.com")
+		req.Header.Set("Access-Control-Request-Method", "PATCH")
+		req.Header.Set("Access-Control-Request-Headers", "authorization,tus-resumable,upload-offset")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") == "" {
+			t.Errorf("preflight %s = %d, CORS headers %v", path, rec.Code, rec.Header())
+		}
+	}
+
+	// Scanners without token still get 404
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/uploads/abc123", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET without token = %d, want 404", rec.Code)
+	}
+}
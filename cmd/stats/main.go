@@ -1,9 +1,11 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -25,17 +27,66 @@ func main() {
 		minDate        = flag.String("min-date", defaultMinDate, "Minimum date YYYY-MM-DD (default: 7 days ago)")
 		maxDate        = flag.String("max-date", "", "Maximum date YYYY-MM-DD (empty for all)")
 		topN           = flag.Int("top", 20, "Number of top results to show")
-		showFullUA     = flag.Bool("full-ua", false, "Show full user agent strings")
 		showBots       = flag.Bool("bots", false, "Show stats from bots folder")
+		jsonOutput     = flag.Bool("json", false, "Print full analysis result as JSON (same as --format=json)")
+		format         = flag.String("format", FormatTable, "Output format: table, json, csv, markdown, influx (InfluxDB line protocol)")
+		interval       = flag.Duration("interval", time.Hour, "Aggregation interval for influx format")
+		pathPrefix     = flag.String("path", "", "Only include paths starting with this prefix (e.g. /hls/)")
+		pathRegex      = flag.String("path-regex", "", "Only include paths matching this regular expression")
+		uaContains     = flag.String("ua-contains", "", "Only include user agents containing this string (case-insensitive)")
+		excludeBots    = flag.Bool("exclude-bots", false, "Exclude user agents detected as bots/crawlers")
+		workers        = flag.Int("workers", 0, "Log files processed in parallel (0 for number of CPUs)")
+		useSummaries   = flag.Bool("summaries", true, "Read/write precomputed daily summary files for past days")
+		compareToPrev  = flag.Bool("compare-to-previous", false, "Compare with previous range of equal length")
+		compareMinDate = flag.String("compare-min-date", "", "Compare with explicit range starting at YYYY-MM-DD")
+		compareMaxDate = flag.String("compare-max-date", "", "Compare with explicit range ending at YYYY-MM-DD")
+		geoIPDir       = flag.String("geoip", config.AppPaths.GeoIP, "Directory with GeoIP country.csv/asn.csv for records logged without country")
 	)
+	flag.Bool("full-ua", false, "Show full user agent strings")
 	flag.Parse()
 
+	outputFormat := *format
+	if outputFormat == "text" {
+		outputFormat = FormatTable
+	}
+	if *jsonOutput {
+		outputFormat = FormatJSON
+	}
+	switch outputFormat {
+	case FormatTable, FormatJSON, FormatCSV, FormatMarkdown, FormatInflux:
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *format)
+		os.Exit(1)
+	}
+
+	var pathRe *regexp.Regexp
+	if *pathRegex != "" {
+		re, err := regexp.Compile(*pathRegex)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid --path-regex: %v\n", err)
+			os.Exit(1)
+		}
+		pathRe = re
+	}
+
+	geoDB, err := stats.LoadGeoDB(*geoIPDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to load GeoIP data: %v\n", err)
+	}
+
 	analyzerConfig := stats.AnalyzerConfig{
 		ExcludeStaticPaths: *excludeStatic,
 		ExcludePartial:     *excludePartial,
 		ExcludeReferer:     *excludeReferer,
 		MinDate:            *minDate,
 		MaxDate:            *maxDate,
+		GeoDB:              geoDB,
+		UseSummaries:       *useSummaries,
+		Workers:            *workers,
+		PathPrefix:         *pathPrefix,
+		PathRegex:          pathRe,
+		UAContains:         *uaContains,
+		ExcludeBots:        *excludeBots,
 		LogDir: func() string {
 			if *showBots {
 				return config.AppPaths.LogsBots
@@ -45,147 +96,430 @@ func main() {
 		}(),
 	}
 
-	fmt.Printf("🔍 Analyzing stats...\n\n")
+	if outputFormat == FormatInflux {
+		if err := stats.ExportInflux(os.Stdout, analyzerConfig, *interval); err != nil {
+			fmt.Fprintf(os.Stderr, "Error exporting stats: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if outputFormat == FormatTable {
+		fmt.Printf("🔍 Analyzing stats...\n\n")
+	}
 
 	result, err := stats.AnalyzeStats(analyzerConfig)
 	if err != nil {
-		fmt.Printf("Error analyzing stats: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error analyzing stats: %v\n", err)
 		os.Exit(1)
 	}
 
-	printResults(result, *topN, *showFullUA)
+	var comparison *stats.Comparison
+	if *compareToPrev || *compareMinDate != "" {
+		previousConfig := analyzerConfig
+		previousConfig.MinDate, previousConfig.MaxDate = *compareMinDate, *compareMaxDate
+		if *compareToPrev {
+			previousConfig.MinDate, previousConfig.MaxDate, err = stats.PreviousRange(*minDate, *maxDate)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error computing previous range: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		previous, err := stats.AnalyzeStats(previousConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error analyzing comparison range: %v\n", err)
+			os.Exit(1)
+		}
+
+		c := stats.Compare(result, previous, *topN)
+		c.CurrentRange = dateRangeLabel(analyzerConfig.MinDate, analyzerConfig.MaxDate)
+		c.PreviousRange = dateRangeLabel(previousConfig.MinDate, previousConfig.MaxDate)
+		comparison = &c
+	}
+
+	if outputFormat == FormatJSON {
+		var output any = result
+		if comparison != nil {
+			output = map[string]any{"result": result, "comparison": comparison}
+		}
+
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	sections := resultSections(result, *topN)
+	if comparison != nil {
+		sections = append(sections, comparisonSections(comparison, *topN)...)
+	}
+
+	if outputFormat == FormatTable {
+		fmt.Printf("+++++++++++++++++++++++++++++++++++++++++++++++++++++++\n")
+		fmt.Printf("+++++++++++++++++++++++++++++++++++++++++++++++++++++++\n")
+	}
+	if err := renderSections(os.Stdout, outputFormat, sections); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func comparisonSections(comparison *stats.Comparison, topN int) []section {
+	sections := []section{{
+		icon:     "📈",
+		title:    "Comparison",
+		keyValue: true,
+		columns:  []column{{name: "Range", left: true}, {name: "Dates", left: true}},
+		rows: [][]cell{
+			{textCell("Current"), textCell(comparison.CurrentRange)},
+			{textCell("Previous"), textCell(comparison.PreviousRange)},
+		},
+	}}
+
+	metrics := section{
+		icon:  "📈",
+		title: "Metric Changes",
+		columns: []column{
+			{name: "Metric", width: 18, left: true},
+			{name: "Current", width: 12},
+			{name: "Previous", width: 12},
+			{name: "Change", width: 12},
+			{name: "%", width: 9},
+		},
+	}
+	for _, metric := range comparison.Metrics {
+		current, previous := groupedNumberCell(int(metric.Current)), groupedNumberCell(int(metric.Previous))
+		change := cell{text: formatSigned(metric.Change), raw: strconv.FormatInt(metric.Change, 10)}
+		if metric.Name == "Total Bytes" {
+			current, previous = bytesCell(metric.Current), bytesCell(metric.Previous)
+			change.text = "+" + formatBytes(metric.Change)
+			if metric.Change < 0 {
+				change.text = "-" + formatBytes(-metric.Change)
+			}
+		}
+		pct := cell{text: formatPct(metric)}
+		if metric.ChangePct != nil {
+			pct.raw = strconv.FormatFloat(*metric.ChangePct, 'f', 2, 64)
+		}
+		metrics.rows = append(metrics.rows, []cell{textCell(metric.Name), current, previous, change, pct})
+	}
+	sections = append(sections, metrics)
+
+	if len(comparison.TopEndpoints) > 0 {
+		endpoints := section{
+			icon:  "🎯",
+			title: fmt.Sprintf("Top Endpoints Change (Top %d)", topN),
+			columns: []column{
+				{name: "Path", width: 50, left: true, truncate: true},
+				{name: "Current", width: 10},
+				{name: "Previous", width: 10},
+				{name: "Change", width: 10},
+			},
+		}
+		for i, ep := range comparison.TopEndpoints {
+			if i >= topN {
+				break
+			}
+			change := cell{text: formatSigned(int64(ep.Change)), raw: strconv.Itoa(ep.Change)}
+			endpoints.rows = append(endpoints.rows, []cell{textCell(ep.Path), numberCell(ep.Current), numberCell(ep.Previous), change})
+		}
+		sections = append(sections, endpoints)
+	}
+
+	return sections
+}
+
+func dateRangeLabel(minDate, maxDate string) string {
+	if minDate == "" {
+		minDate = "start"
+	}
+	if maxDate == "" {
+		maxDate = "now"
+	}
+	return minDate + " to " + maxDate
+}
+
+func formatSigned(n int64) string {
+	if n >= 0 {
+		return "+" + formatNumber(int(n))
+	}
+	return "-" + formatNumber(int(-n))
+}
+
+func formatPct(metric stats.MetricDelta) string {
+	if metric.ChangePct == nil {
+		if metric.Current == 0 {
+			return "-"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", *metric.ChangePct)
 }
 
-func printResults(result *stats.AnalysisResult, topN int, showFullUA bool) {
-	fmt.Printf("+++++++++++++++++++++++++++++++++++++++++++++++++++++++\n")
-	fmt.Printf("+++++++++++++++++++++++++++++++++++++++++++++++++++++++\n")
-	fmt.Printf("📊 OVERVIEW\n")
-	fmt.Printf("═══════════════════════════════════════\n")
-	fmt.Printf("Date Range:         %s\n", result.DateRange)
-	fmt.Printf("Total Requests:     %s\n", formatNumber(result.TotalRequests))
-	fmt.Printf("Unique Visitors:    %s\n", formatNumber(result.UniqueVisitors))
-	fmt.Printf("Total Bytes:        %s\n", formatBytes(result.TotalBytes))
-	fmt.Printf("Video Requests:     %s\n", formatNumber(result.VideoRequests))
-	fmt.Printf("Static Requests:    %s\n", formatNumber(result.StaticRequests))
-	fmt.Printf("Partial Requests:   %s\n", formatNumber(result.PartialRequests))
-	fmt.Printf("Error Requests:     %s\n", formatNumber(result.ErrorRequests))
-	fmt.Printf("\n")
+func resultSections(result *stats.AnalysisResult, topN int) []section {
+	sections := []section{{
+		icon:     "📊",
+		title:    "Overview",
+		keyValue: true,
+		columns:  []column{{name: "Metric", left: true}, {name: "Value", left: true}},
+		rows: [][]cell{
+			{textCell("Date Range"), textCell(result.DateRange)},
+			{textCell("Total Requests"), groupedNumberCell(result.TotalRequests)},
+			{textCell("Unique Visitors"), groupedNumberCell(result.UniqueVisitors)},
+			{textCell("Total Bytes"), bytesCell(result.TotalBytes)},
+			{textCell("Video Requests"), groupedNumberCell(result.VideoRequests)},
+			{textCell("Static Requests"), groupedNumberCell(result.StaticRequests)},
+			{textCell("Partial Requests"), groupedNumberCell(result.PartialRequests)},
+			{textCell("Error Requests"), groupedNumberCell(result.ErrorRequests)},
+		},
+	}}
+
+	if len(result.CacheSources) > 0 {
+		cache := section{
+			icon:    "🗄️ ",
+			title:   "Video Cache",
+			columns: []column{{name: "Source", width: 12, left: true}, {name: "Requests", width: 10}, {name: "Share", width: 8}},
+		}
+		total := 0
+		for _, count := range result.CacheSources {
+			total += count
+		}
+		for _, source := range []string{stats.CachePregen, stats.CacheTmp, stats.CacheGenerate} {
+			count := result.CacheSources[source]
+			cache.rows = append(cache.rows, []cell{textCell(source), groupedNumberCell(count), textCell(fmt.Sprintf("%.1f%%", float64(count)*100/float64(total)))})
+		}
+		sections = append(sections, cache)
+	}
+
+	if len(result.Tenants) > 0 {
+		tenants := section{
+			icon:    "🏢",
+			title:   "Tenants",
+			columns: []column{{name: "Tenant", width: 20, left: true}, {name: "Requests", width: 10}, {name: "Bytes", width: 12}},
+		}
+		var names []string
+		for name := range result.Tenants {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			usage := result.Tenants[name]
+			tenants.rows = append(tenants.rows, []cell{textCell(name), groupedNumberCell(usage.Requests), textCell(formatBytes(usage.Bytes))})
+		}
+		sections = append(sections, tenants)
+	}
+
+	if len(result.DailyVisitors) > 0 {
+		daily := section{
+			icon:    "📅",
+			title:   "Daily Visitors",
+			columns: []column{{name: "Date", width: 12, left: true}, {name: "Visitors", width: 10}, {name: "Requests", width: 10}},
+		}
+		for _, day := range result.DailyVisitors {
+			daily.rows = append(daily.rows, []cell{textCell(day.Date), groupedNumberCell(day.UniqueVisitors), groupedNumberCell(day.Requests)})
+		}
+		sections = append(sections, daily)
+	}
 
 	if len(result.TopEndpoints) > 0 {
-		fmt.Printf("🎯 TOP ENDPOINTS (Top %d)\n", topN)
-		fmt.Printf("═══════════════════════════════════════\n")
-		fmt.Printf("%-50s %10s %12s\n", "Path", "Requests", "Bytes")
-		fmt.Printf("%-50s %10s %12s\n", strings.Repeat("-", 50), strings.Repeat("-", 10), strings.Repeat("-", 12))
+		endpoints := section{
+			icon:    "🎯",
+			title:   fmt.Sprintf("Top Endpoints (Top %d)", topN),
+			columns: []column{{name: "Path", width: 50, left: true, truncate: true}, {name: "Requests", width: 10}, {name: "Bytes", width: 12}},
+		}
 		for i, ep := range result.TopEndpoints {
 			if i >= topN {
 				break
 			}
-			path := ep.Path
-			if len(path) > 47 {
-				path = path[:44] + "..."
+			endpoints.rows = append(endpoints.rows, []cell{textCell(ep.Path), numberCell(ep.Count), bytesCell(ep.Bytes)})
+		}
+		sections = append(sections, endpoints)
+	}
+
+	if len(result.EndpointLatency) > 0 {
+		latency := section{
+			icon:  "⏱️ ",
+			title: "Latency By Endpoint",
+			columns: []column{
+				{name: "Category", width: 15, left: true},
+				{name: "Requests", width: 10},
+				{name: "Avg", width: 10},
+				{name: "P95", width: 10},
+				{name: "Max", width: 10},
+			},
+		}
+		for _, lat := range result.EndpointLatency {
+			latency.rows = append(latency.rows, []cell{textCell(lat.Category), numberCell(lat.Count), msCell(lat.AvgMs), msCell(float64(lat.P95Ms)), msCell(float64(lat.MaxMs))})
+		}
+		sections = append(sections, latency)
+	}
+
+	if sessions := result.HLSSessions; sessions.Sessions > 0 {
+		sections = append(sections, section{
+			icon:     "🎬",
+			title:    "HLS Sessions",
+			keyValue: true,
+			columns:  []column{{name: "Metric", left: true}, {name: "Value", left: true}},
+			rows: [][]cell{
+				{textCell("Sessions"), groupedNumberCell(sessions.Sessions)},
+				{textCell("Chunk Requests"), groupedNumberCell(sessions.ChunkRequests)},
+				{textCell("Avg Watch Time"), secondsCell(sessions.AvgWatchSeconds)},
+				{textCell("Rebuffer Gaps"), groupedNumberCell(sessions.RebufferGaps)},
+				{textCell("With Rebuffering"), groupedNumberCell(sessions.SessionsWithRebuffer)},
+			},
+		})
+
+		videos := section{
+			icon:    "🎬",
+			title:   fmt.Sprintf("HLS Sessions By Video (Top %d)", topN),
+			columns: []column{{name: "Video", width: 30, left: true, truncate: true}, {name: "Sessions", width: 10}, {name: "Avg Watch", width: 10}, {name: "Rebuffers", width: 10}},
+		}
+		for i, video := range sessions.Videos {
+			if i >= topN {
+				break
 			}
-			fmt.Printf("%-50s %10d %12s\n", path, ep.Count, formatBytes(ep.Bytes))
+			videos.rows = append(videos.rows, []cell{textCell(video.Video), numberCell(video.Sessions), secondsCell(video.AvgWatchSeconds), numberCell(video.RebufferGaps)})
 		}
-		fmt.Printf("\n")
+		sections = append(sections, videos)
 	}
 
 	if len(result.TopVisitors) > 0 {
-		fmt.Printf("👥 TOP VISITORS (Top %d)\n", topN)
-		fmt.Printf("═══════════════════════════════════════\n")
-		fmt.Printf("%-15s %-15s %10s %12s\n", "IP", "Browser", "Requests", "Bytes")
-		fmt.Printf("%-15s %-15s %10s %12s\n", strings.Repeat("-", 15), strings.Repeat("-", 15), strings.Repeat("-", 10), strings.Repeat("-", 12))
+		visitors := section{
+			icon:    "👥",
+			title:   fmt.Sprintf("Top Visitors (Top %d)", topN),
+			columns: []column{{name: "IP", width: 15, left: true}, {name: "Browser", width: 15, left: true, truncate: true}, {name: "Requests", width: 10}, {name: "Bytes", width: 12}},
+		}
 		for i, visitor := range result.TopVisitors {
 			if i >= topN {
 				break
 			}
-			browser := visitor.Browser
-			if len(browser) > 12 {
-				browser = browser[:9] + "..."
-			}
-			fmt.Printf("%-15s %-15s %10d %12s\n", visitor.IP, browser, visitor.Requests, formatBytes(visitor.Bytes))
+			visitors.rows = append(visitors.rows, []cell{textCell(visitor.IP), textCell(visitor.Browser), numberCell(visitor.Requests), bytesCell(visitor.Bytes)})
 		}
-		fmt.Printf("\n")
+		sections = append(sections, visitors)
 	}
 
 	if len(result.TopReferrers) > 0 {
-		fmt.Printf("🔗 TOP REFERRER DOMAINS (Top %d)\n", topN)
-		fmt.Printf("═══════════════════════════════════════\n")
-		fmt.Printf("%-40s %10s %20s\n", "Domain", "Count", "Last Seen")
-		fmt.Printf("%-40s %10s %20s\n", strings.Repeat("-", 40), strings.Repeat("-", 10), strings.Repeat("-", 20))
+		referrers := section{
+			icon:    "🔗",
+			title:   fmt.Sprintf("Top Referrer Domains (Top %d)", topN),
+			columns: []column{{name: "Domain", width: 40, left: true, truncate: true}, {name: "Count", width: 10}, {name: "Last Seen", width: 20}},
+		}
 		for i, ref := range result.TopReferrers {
 			if i >= topN {
 				break
 			}
-			domain := ref.Domain
-			if len(domain) > 37 {
-				domain = domain[:34] + "..."
-			}
-			fmt.Printf("%-40s %10d %20s\n", domain, ref.Count, ref.LastSeen.Format("2006-01-02 15:04"))
+			lastSeen := cell{text: ref.LastSeen.Format("2006-01-02 15:04"), raw: ref.LastSeen.Format(time.RFC3339)}
+			referrers.rows = append(referrers.rows, []cell{textCell(ref.Domain), numberCell(ref.Count), lastSeen})
 		}
-		fmt.Printf("\n")
+		sections = append(sections, referrers)
 	}
 
 	if len(result.FullReferrerURLs) > 0 {
-		fmt.Printf("📄 FULL REFERRER URLS (Top %d)\n", topN)
-		fmt.Printf("═══════════════════════════════════════\n")
-		fmt.Printf("%-80s %10s\n", "Full URL", "Count")
-		fmt.Printf("%-80s %10s\n", strings.Repeat("-", 80), strings.Repeat("-", 10))
+		urls := section{
+			icon:    "📄",
+			title:   fmt.Sprintf("Full Referrer URLs (Top %d)", topN),
+			columns: []column{{name: "Full URL", width: 80, left: true, truncate: true}, {name: "Count", width: 10}},
+		}
 		for i, ref := range result.FullReferrerURLs {
 			if i >= topN {
 				break
 			}
-			url := ref.FullURL
-			if len(url) > 77 {
-				url = url[:74] + "..."
+			urls.rows = append(urls.rows, []cell{textCell(ref.FullURL), numberCell(ref.Count)})
+		}
+		sections = append(sections, urls)
+	}
+
+	if len(result.Countries) > 0 {
+		countries := section{
+			icon:    "🌍",
+			title:   fmt.Sprintf("Countries (Top %d)", topN),
+			columns: []column{{name: "Country", width: 10, left: true}, {name: "Requests", width: 10}, {name: "Visitors", width: 10}, {name: "Bytes", width: 12}},
+		}
+		for i, country := range result.Countries {
+			if i >= topN {
+				break
+			}
+			countries.rows = append(countries.rows, []cell{textCell(country.Country), numberCell(country.Requests), numberCell(country.UniqueVisitors), bytesCell(country.Bytes)})
+		}
+		sections = append(sections, countries)
+	}
+
+	if len(result.ASNs) > 0 {
+		asns := section{
+			icon:  "🏢",
+			title: fmt.Sprintf("Networks / ASN (Top %d)", topN),
+			columns: []column{
+				{name: "ASN", width: 10, left: true},
+				{name: "Organization", width: 30, left: true, truncate: true},
+				{name: "Requests", width: 10},
+				{name: "Visitors", width: 10},
+				{name: "Bytes", width: 12},
+			},
+		}
+		for i, asn := range result.ASNs {
+			if i >= topN {
+				break
 			}
-			fmt.Printf("%-80s %10d\n", url, ref.Count)
+			asns.rows = append(asns.rows, []cell{textCell(asn.ASN), textCell(asn.Org), numberCell(asn.Requests), numberCell(asn.UniqueVisitors), bytesCell(asn.Bytes)})
 		}
-		fmt.Printf("\n")
+		sections = append(sections, asns)
 	}
 
 	if len(result.UserAgents) > 0 {
-		browsers := summarizeBrowsers(result.UserAgents)
-		fmt.Printf("🌐 BROWSER SUMMARY (Top %d)\n", topN)
-		fmt.Printf("═══════════════════════════════════════\n")
-		fmt.Printf("%-30s %10s\n", "Browser", "Count")
-		fmt.Printf("%-30s %10s\n", strings.Repeat("-", 30), strings.Repeat("-", 10))
-		for i, browser := range browsers {
+		browsers := section{
+			icon:    "🌐",
+			title:   fmt.Sprintf("Browser Summary (Top %d)", topN),
+			columns: []column{{name: "Browser", width: 30, left: true}, {name: "Count", width: 10}},
+		}
+		for i, browser := range summarizeBrowsers(result.UserAgents) {
 			if i >= topN {
 				break
 			}
-			fmt.Printf("%-30s %10d\n", browser.Name, browser.Count)
+			browsers.rows = append(browsers.rows, []cell{textCell(browser.Name), numberCell(browser.Count)})
 		}
-		fmt.Printf("\n")
+		sections = append(sections, browsers)
 	}
 
 	if len(result.Bots) > 0 {
-		fmt.Printf("🤖 BOTS & CRAWLERS (Top %d)\n", topN)
-		fmt.Printf("═══════════════════════════════════════\n")
-		fmt.Printf("%-60s %10s\n", "Bot/Crawler", "Count")
-		fmt.Printf("%-60s %10s\n", strings.Repeat("-", 60), strings.Repeat("-", 10))
+		bots := section{
+			icon:    "🤖",
+			title:   fmt.Sprintf("Bots & Crawlers (Top %d)", topN),
+			columns: []column{{name: "Bot/Crawler", width: 60, left: true, truncate: true}, {name: "Count", width: 10}},
+		}
 		for i, bot := range result.Bots {
 			if i >= topN {
 				break
 			}
-			name := stats.ExtractBotName(bot.UserAgent)
-			fmt.Printf("%-60s %10d\n", name, bot.Count)
+			bots.rows = append(bots.rows, []cell{textCell(bot.UserAgent), numberCell(bot.Count)})
 		}
-		fmt.Printf("\n")
+		sections = append(sections, bots)
 	}
 
-	fmt.Printf("🚦 RATE LIMITING INSIGHTS\n")
-	fmt.Printf("═══════════════════════════════════════\n")
 	heavyUsers := 0
 	for _, visitor := range result.TopVisitors {
 		if visitor.Requests > 100 {
 			heavyUsers++
 		}
 	}
-	fmt.Printf("Heavy users (>100 requests): %d\n", heavyUsers)
+	insights := section{
+		icon:     "🚦",
+		title:    "Rate Limiting Insights",
+		keyValue: true,
+		columns:  []column{{name: "Metric", left: true}, {name: "Value", left: true}},
+		rows:     [][]cell{{textCell("Heavy users (>100 requests)"), numberCell(heavyUsers)}},
+	}
 	if len(result.TopVisitors) > 0 {
-		fmt.Printf("Top user requests: %d\n", result.TopVisitors[0].Requests)
-		fmt.Printf("Top user bytes: %s\n", formatBytes(result.TopVisitors[0].Bytes))
+		insights.rows = append(insights.rows,
+			[]cell{textCell("Top user requests"), numberCell(result.TopVisitors[0].Requests)},
+			[]cell{textCell("Top user bytes"), bytesCell(result.TopVisitors[0].Bytes)},
+		)
 	}
+	sections = append(sections, insights)
+
+	return sections
 }
 
 type BrowserSummary struct {
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Output formats for report sections
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatCSV      = "csv"
+	FormatMarkdown = "markdown"
+	FormatInflux   = "influx"
+)
+
+type column struct {
+	name     string
+	width    int  // table format only
+	left     bool // left aligned in table format
+	truncate bool // shorten long values in table format
+}
+
+// cell keeps human readable text for table/markdown and raw value for csv
+type cell struct {
+	text string
+	raw  string
+}
+
+type section struct {
+	icon     string
+	title    string
+	columns  []column
+	rows     [][]cell
+	keyValue bool // two column label/value list (overview, insights)
+}
+
+func textCell(s string) cell {
+	return cell{text: s, raw: s}
+}
+
+func numberCell(n int) cell {
+	return cell{text: strconv.Itoa(n), raw: strconv.Itoa(n)}
+}
+
+func groupedNumberCell(n int) cell {
+	return cell{text: formatNumber(n), raw: strconv.Itoa(n)}
+}
+
+func bytesCell(n int64) cell {
+	return cell{text: formatBytes(n), raw: strconv.FormatInt(n, 10)}
+}
+
+func msCell(ms float64) cell {
+	return cell{text: fmt.Sprintf("%.0fms", ms), raw: strconv.FormatFloat(ms, 'f', 2, 64)}
+}
+
+func secondsCell(seconds float64) cell {
+	return cell{text: fmt.Sprintf("%.1fs", seconds), raw: strconv.FormatFloat(seconds, 'f', 2, 64)}
+}
+
+func renderSections(w io.Writer, format string, sections []section) error {
+	switch format {
+	case FormatTable:
+		renderTable(w, sections)
+		return nil
+	case FormatCSV:
+		return renderCSV(w, sections)
+	case FormatMarkdown:
+		renderMarkdown(w, sections)
+		return nil
+	default:
+		return fmt.Errorf("unknown format: %s", format)
+	}
+}
+
+func renderTable(w io.Writer, sections []section) {
+	for _, s := range sections {
+		fmt.Fprintf(w, "%s %s\n", s.icon, strings.ToUpper(s.title))
+		fmt.Fprintf(w, "═══════════════════════════════════════\n")
+
+		if s.keyValue {
+			for _, row := range s.rows {
+				fmt.Fprintf(w, "%-20s%s\n", row[0].text+":", row[1].text)
+			}
+			fmt.Fprintf(w, "\n")
+			continue
+		}
+
+		header := make([]string, len(s.columns))
+		divider := make([]string, len(s.columns))
+		for i, col := range s.columns {
+			header[i] = pad(col.name, col)
+			divider[i] = strings.Repeat("-", col.width)
+		}
+		fmt.Fprintln(w, strings.Join(header, " "))
+		fmt.Fprintln(w, strings.Join(divider, " "))
+
+		for _, row := range s.rows {
+			values := make([]string, len(row))
+			for i, c := range row {
+				text := c.text
+				if s.columns[i].truncate && len(text) > s.columns[i].width-3 {
+					text = text[:s.columns[i].width-6] + "..."
+				}
+				values[i] = pad(text, s.columns[i])
+			}
+			fmt.Fprintln(w, strings.Join(values, " "))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
+
+func pad(text string, col column) string {
+	if col.left {
+		return fmt.Sprintf("%-*s", col.width, text)
+	}
+	return fmt.Sprintf("%*s", col.width, text)
+}
+
+// renderCSV writes every section as its own table, separated by a blank line and prefixed with section title row
+func renderCSV(w io.Writer, sections []section) error {
+	writer := csv.NewWriter(w)
+	for i, s := range sections {
+		if i > 0 {
+			writer.Write(nil)
+		}
+		writer.Write([]string{"# " + s.title})
+
+		header := make([]string, len(s.columns))
+		for j, col := range s.columns {
+			header[j] = col.name
+		}
+		writer.Write(header)
+
+		for _, row := range s.rows {
+			values := make([]string, len(row))
+			for j, c := range row {
+				values[j] = c.raw
+			}
+			writer.Write(values)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func renderMarkdown(w io.Writer, sections []section) {
+	for _, s := range sections {
+		fmt.Fprintf(w, "### %s %s\n\n", s.icon, s.title)
+
+		header := make([]string, len(s.columns))
+		align := make([]string, len(s.columns))
+		for i, col := range s.columns {
+			header[i] = col.name
+			align[i] = "---:"
+			if col.left {
+				align[i] = ":---"
+			}
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(w, "| %s |\n", strings.Join(align, " | "))
+
+		for _, row := range s.rows {
+			values := make([]string, len(row))
+			for i, c := range row {
+				values[i] = strings.ReplaceAll(c.text, "|", `\|`)
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(values, " | "))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
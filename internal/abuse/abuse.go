@@ -0,0 +1,213 @@
+package abuse
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/stats"
+)
+
+// Config thresholds are per IP within fixed Window, zero value disables a threshold
+type Config struct {
+	Window      time.Duration
+	BanDuration time.Duration
+	MaxRequests int // Sustained request rate
+	MaxUncached int // Requests that started a new transcode (202 Accepted), expensive for server
+}
+
+// Ban is a temporary block of an IP address
+type Ban struct {
+	IP        string    `json:"ip"`
+	Reason    string    `json:"reason"`
+	BannedAt  time.Time `json:"bannedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type window struct {
+	start    time.Time
+	requests int
+	uncached int
+}
+
+// Detector counts requests per IP from StatsMiddleware and maintains ban list
+type Detector struct {
+	config Config
+	now    func() time.Time
+
+	mutex      sync.Mutex
+	windows    map[string]*window // key: IP
+	bans       map[string]Ban     // key: IP
+	lastPruned time.Time
+}
+
+// ConfigFromEnv reads ABUSE_* environment variables
+func ConfigFromEnv() Config {
+	return Config{
+		Window:      config.GetEnvDuration("ABUSE_WINDOW", time.Minute),
+		BanDuration: config.GetEnvDuration("ABUSE_BAN_DURATION", time.Hour),
+		MaxRequests: int(config.GetEnvInt64("ABUSE_MAX_REQUESTS")),
+		MaxUncached: int(config.GetEnvInt64("ABUSE_MAX_UNCACHED")),
+	}
+}
+
+// Enabled reports whether any threshold is configured
+func (c Config) Enabled() bool {
+	return c.MaxRequests > 0 || c.MaxUncached > 0
+}
+
+func NewDetector(config Config) *Detector {
+	if config.Window <= 0 {
+		config.Window = time.Minute
+	}
+	if config.BanDuration <= 0 {
+		config.BanDuration = time.Hour
+	}
+
+	return &Detector{
+		config:  config,
+		now:     time.Now,
+		windows: make(map[string]*window),
+		bans:    make(map[string]Ban),
+	}
+}
+
+// Observe is a stats.Observer, registered on StatsMiddleware
+func (d *Detector) Observe(request stats.RequestStats) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.now()
+	d.prune(now)
+
+	if _, banned := d.bans[request.IP]; banned {
+		return
+	}
+	// Player fetches chunk every few seconds, counting them would ban ordinary viewers
+	if stats.EndpointCategory(request.Path) == stats.CategoryHLSChunk {
+		return
+	}
+
+	w, exists := d.windows[request.IP]
+	if !exists || now.Sub(w.start) >= d.config.Window {
+		w = &window{start: now}
+		d.windows[request.IP] = w
+	}
+	w.requests++
+	if request.Status == http.StatusAccepted && stats.EndpointCategory(request.Path) == stats.CategoryVideo {
+		w.uncached++
+	}
+
+	var reason string
+	switch {
+	case d.config.MaxRequests > 0 && w.requests > d.config.MaxRequests:
+		reason = fmt.Sprintf("%d requests in %s", w.requests, d.config.Window)
+	case d.config.MaxUncached > 0 && w.uncached > d.config.MaxUncached:
+		reason = fmt.Sprintf("%d uncached transcodes in %s", w.uncached, d.config.Window)
+	default:
+		return
+	}
+
+	d.bans[request.IP] = Ban{
+		IP:        request.IP,
+		Reason:    reason,
+		BannedAt:  now,
+		ExpiresAt: now.Add(d.config.BanDuration),
+	}
+	delete(d.windows, request.IP)
+	log.Printf("Banned %s for %s: %s", request.IP, d.config.BanDuration, reason)
+}
+
+// Banned returns active ban for IP
+func (d *Detector) Banned(ip string) (Ban, bool) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	ban, exists := d.bans[ip]
+	if !exists || !d.now().Before(ban.ExpiresAt) {
+		return Ban{}, false
+	}
+	return ban, true
+}
+
+// Bans lists active bans, most recent first
+func (d *Detector) Bans() []Ban {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := d.now()
+	result := make([]Ban, 0, len(d.bans))
+	for _, ban := range d.bans {
+		if now.Before(ban.ExpiresAt) {
+			result = append(result, ban)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].BannedAt.After(result[j].BannedAt)
+	})
+	return result
+}
+
+// Unban removes ban for IP, reports whether it existed
+func (d *Detector) Unban(ip string) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	_, exists := d.bans[ip]
+	delete(d.bans, ip)
+	return exists
+}
+
+// Clear removes all bans and returns how many were removed
+func (d *Detector) Clear() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	cleared := len(d.bans)
+	d.bans = make(map[string]Ban)
+	return cleared
+}
+
+// Middleware rejects banned IPs with 429 before request reaches stats logging.
+// Requests to exemptPrefix (admin ban endpoints) are always let through
+func (d *Detector) Middleware(exemptPrefix string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasPrefix(r.URL.Path, exemptPrefix) {
+				if ban, banned := d.Banned(stats.GetRealIP(r)); banned {
+					retryAfter := int(time.Until(ban.ExpiresAt).Seconds()) + 1
+					w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+					http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+					return
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// prune drops expired bans and idle windows
+func (d *Detector) prune(now time.Time) {
+	if now.Sub(d.lastPruned) < d.config.Window {
+		return
+	}
+	d.lastPruned = now
+
+	for ip, w := range d.windows {
+		if now.Sub(w.start) >= d.config.Window {
+			delete(d.windows, ip)
+		}
+	}
+	for ip, ban := range d.bans {
+		if !now.Before(ban.ExpiresAt) {
+			delete(d.bans, ip)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package abuse
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lorem.video/internal/stats"
+)
+
+func newTestDetector(config Config, now *time.Time) *Detector {
+	d := NewDetector(config)
+	d.now = func() time.Time { return *now }
+	return d
+}
+
+func TestDetectorRequestRate(t *testing.T) {
+	now := time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestDetector(Config{Window: time.Minute, BanDuration: time.Hour, MaxRequests: 3}, &now)
+
+	for i := 0; i < 3; i++ {
+		d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/720p", Status: 200})
+	}
+	if _, banned := d.Banned("1.1.1.1"); banned {
+		t.Fatal("banned at threshold")
+	}
+
+	// New window resets counter
+	now = now.Add(time.Minute)
+	for i := 0; i < 3; i++ {
+		d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/720p", Status: 200})
+	}
+	if _, banned := d.Banned("1.1.1.1"); banned {
+		t.Fatal("banned after window reset")
+	}
+
+	d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/720p", Status: 200})
+	if _, banned := d.Banned("1.1.1.1"); !banned {
+		t.Fatal("expected ban above threshold")
+	}
+	if _, banned := d.Banned("2.2.2.2"); banned {
+		t.Fatal("other IP must not be banned")
+	}
+
+	// Ban expires
+	now = now.Add(time.Hour)
+	if _, banned := d.Banned("1.1.1.1"); banned {
+		t.Fatal("ban should have expired")
+	}
+	if bans := d.Bans(); len(bans) != 0 {
+		t.Fatalf("expected no active bans, got %+v", bans)
+	}
+}
+
+func TestDetectorUncached(t *testing.T) {
+	now := time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+	d := newTestDetector(Config{MaxUncached: 1}, &now)
+
+	// Cached videos and HLS 202s are not counted
+	d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/720p", Status: 200})
+	d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/hls/bunny/playlist.m3u8", Status: http.StatusAccepted})
+	d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/720p_av1_crf40", Status: http.StatusAccepted})
+	if _, banned := d.Banned("1.1.1.1"); banned {
+		t.Fatal("banned at threshold")
+	}
+
+	d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/480p_vp9_crf50", Status: http.StatusAccepted})
+	ban, banned := d.Banned("1.1.1.1")
+	if !banned || ban.Reason != "2 uncached transcodes in 1m0s" {
+		t.Fatalf("expected uncached ban, got %+v", ban)
+	}
+
+	if !d.Unban("1.1.1.1") {
+		t.Fatal("Unban reported missing ban")
+	}
+	if _, banned := d.Banned("1.1.1.1"); banned {
+		t.Fatal("still banned after Unban")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	d := NewDetector(Config{MaxRequests: 1})
+	d.Observe(stats.RequestStats{IP: "1.1.1.1"})
+	d.Observe(stats.RequestStats{IP: "1.1.1.1"})
+
+	handler := d.Middleware("/api/abuse/")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		ip        string
+		forwarded string
+		path      string
+		want      int
+	}{
+		{"1.1.1.1", "", "/720p", http.StatusTooManyRequests},
+		{"1.1.1.1", "9.9.9.9", "/720p", http.StatusTooManyRequests}, // Spoofed header from untrusted peer
+		{"1.1.1.1", "", "/api/abuse/bans", http.StatusOK},
+		{"2.2.2.2", "", "/720p", http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		req.RemoteAddr = tt.ip + ":1234"
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: status %d, want %d", tt.ip, tt.path, rec.Code, tt.want)
+		}
+	}
+}
+
+func TestObserveIgnoresHLSChunks(t *testing.T) {
+	d := NewDetector(Config{MaxRequests: 2})
+	for i := 0; i < 10; i++ {
+		d.Observe(stats.RequestStats{IP: "1.1.1.1", Path: "/hls/bunny/720p/media.1.mp4"})
+	}
+	if _, banned := d.Banned("1.1.1.1"); banned {
+		t.Error("HLS viewer banned for chunk requests")
+	}
+}
@@ -0,0 +1,183 @@
+package alert
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/stats"
+)
+
+// Config thresholds are evaluated over rolling Window, zero value disables a threshold
+type Config struct {
+	Window           time.Duration
+	Cooldown         time.Duration // Minimum time between alerts for the same key
+	IPMaxBytes       int64
+	IPMaxRequests    int
+	TotalMaxBytes    int64
+	TotalMaxRequests int
+
+	WebhookURL string // POST JSON {"text": ...}, Slack/Mattermost/Discord compatible
+	Email      EmailConfig
+}
+
+type EmailConfig struct {
+	SMTPAddr string // host:port
+	Username string
+	Password string
+	From     string
+	To       string
+}
+
+// Alert describes a threshold breach
+type Alert struct {
+	Key       string    `json:"key"` // IP address or "total"
+	Metric    string    `json:"metric"`
+	Value     int64     `json:"value"`
+	Threshold int64     `json:"threshold"`
+	Window    string    `json:"window"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (a Alert) String() string {
+	if a.Metric == "bytes" {
+		return fmt.Sprintf("lorem.video alert: %s transferred %dMB in last %s (threshold %dMB)",
+			a.Key, a.Value/(1024*1024), a.Window, a.Threshold/(1024*1024))
+	}
+	return fmt.Sprintf("lorem.video alert: %s made %d requests in last %s (threshold %d)",
+		a.Key, a.Value, a.Window, a.Threshold)
+}
+
+const totalKey = "total"
+
+// Monitor keeps rolling per-IP and total counters fed from StatsMiddleware
+type Monitor struct {
+	config   Config
+	notifier func(Alert)
+	now      func() time.Time
+
+	mutex      sync.Mutex
+	counters   map[string]*rollingCounter // key: IP or totalKey
+	lastAlert  map[string]time.Time       // key: counter key + metric
+	lastPruned time.Time
+}
+
+// ConfigFromEnv reads ALERT_* environment variables
+func ConfigFromEnv() Config {
+	return Config{
+		Window:           config.GetEnvDuration("ALERT_WINDOW", 5*time.Minute),
+		Cooldown:         config.GetEnvDuration("ALERT_COOLDOWN", 30*time.Minute),
+		IPMaxBytes:       config.GetEnvInt64("ALERT_IP_MAX_MB") * 1024 * 1024,
+		IPMaxRequests:    int(config.GetEnvInt64("ALERT_IP_MAX_REQUESTS")),
+		TotalMaxBytes:    config.GetEnvInt64("ALERT_TOTAL_MAX_MB") * 1024 * 1024,
+		TotalMaxRequests: int(config.GetEnvInt64("ALERT_TOTAL_MAX_REQUESTS")),
+		WebhookURL:       os.Getenv("ALERT_WEBHOOK_URL"),
+		Email: EmailConfig{
+			SMTPAddr: os.Getenv("ALERT_SMTP_ADDR"),
+			Username: os.Getenv("ALERT_SMTP_USER"),
+			Password: os.Getenv("ALERT_SMTP_PASSWORD"),
+			From:     os.Getenv("ALERT_EMAIL_FROM"),
+			To:       os.Getenv("ALERT_EMAIL_TO"),
+		},
+	}
+}
+
+// Enabled reports whether any threshold is configured
+func (c Config) Enabled() bool {
+	return c.IPMaxBytes > 0 || c.IPMaxRequests > 0 || c.TotalMaxBytes > 0 || c.TotalMaxRequests > 0
+}
+
+func NewMonitor(config Config) *Monitor {
+	if config.Window <= 0 {
+		config.Window = 5 * time.Minute
+	}
+
+	return &Monitor{
+		config:    config,
+		notifier:  newNotifier(config),
+		now:       time.Now,
+		counters:  make(map[string]*rollingCounter),
+		lastAlert: make(map[string]time.Time),
+	}
+}
+
+// Observe is a stats.Observer, registered on StatsMiddleware
+func (m *Monitor) Observe(request stats.RequestStats) {
+	m.mutex.Lock()
+	now := m.now()
+	m.pruneIdle(now)
+
+	var alerts []Alert
+	if m.config.IPMaxBytes > 0 || m.config.IPMaxRequests > 0 {
+		alerts = append(alerts, m.record(request.IP, request.ResponseSize, now, m.config.IPMaxBytes, m.config.IPMaxRequests)...)
+	}
+	if m.config.TotalMaxBytes > 0 || m.config.TotalMaxRequests > 0 {
+		alerts = append(alerts, m.record(totalKey, request.ResponseSize, now, m.config.TotalMaxBytes, m.config.TotalMaxRequests)...)
+	}
+	m.mutex.Unlock()
+
+	for _, alert := range alerts {
+		go m.notifier(alert)
+	}
+}
+
+func (m *Monitor) record(key string, bytes int64, now time.Time, maxBytes int64, maxRequests int) []Alert {
+	counter, exists := m.counters[key]
+	if !exists {
+		counter = newRollingCounter(m.config.Window)
+		m.counters[key] = counter
+	}
+
+	totalBytes, totalRequests := counter.add(now, bytes)
+
+	var alerts []Alert
+	if maxBytes > 0 && totalBytes > maxBytes {
+		if alert, ok := m.newAlert(key, "bytes", totalBytes, maxBytes, now); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	if maxRequests > 0 && totalRequests > int64(maxRequests) {
+		if alert, ok := m.newAlert(key, "requests", totalRequests, int64(maxRequests), now); ok {
+			alerts = append(alerts, alert)
+		}
+	}
+	return alerts
+}
+
+func (m *Monitor) newAlert(key, metric string, value, threshold int64, now time.Time) (Alert, bool) {
+	alertKey := key + "|" + metric
+	if last, exists := m.lastAlert[alertKey]; exists && now.Sub(last) < m.config.Cooldown {
+		return Alert{}, false
+	}
+	m.lastAlert[alertKey] = now
+
+	return Alert{
+		Key:       key,
+		Metric:    metric,
+		Value:     value,
+		Threshold: threshold,
+		Window:    m.config.Window.String(),
+		Timestamp: now,
+	}, true
+}
+
+// pruneIdle drops counters of IPs without traffic in the last window
+func (m *Monitor) pruneIdle(now time.Time) {
+	if now.Sub(m.lastPruned) < m.config.Window {
+		return
+	}
+	m.lastPruned = now
+
+	for key, counter := range m.counters {
+		if now.Sub(counter.lastSeen) > m.config.Window {
+			delete(m.counters, key)
+		}
+	}
+	for key, last := range m.lastAlert {
+		if now.Sub(last) > m.config.Cooldown {
+			delete(m.lastAlert, key)
+		}
+	}
+}
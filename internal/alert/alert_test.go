@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonitorPerIPRequests(t *testing.T) {
+	m := NewMonitor(Config{
+		Window:        time.Minute,
+		Cooldown:      time.Hour,
+		IPMaxRequests: 3,
+	})
+	now := time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 3; i++ {
+		if got := m.record("1.1.1.1", 0, now, 0, 3); len(got) != 0 {
+			t.Fatalf("unexpected alert after %d requests", i+1)
+		}
+	}
+
+	got := m.record("1.1.1.1", 0, now, 0, 3)
+	if len(got) != 1 || got[0].Metric != "requests" || got[0].Value != 4 {
+		t.Fatalf("expected requests alert with value 4, got %+v", got)
+	}
+
+	// Cooldown suppresses repeated alerts
+	if got := m.record("1.1.1.1", 0, now, 0, 3); len(got) != 0 {
+		t.Fatalf("expected no alert during cooldown, got %+v", got)
+	}
+
+	// Other IPs are tracked separately
+	if got := m.record("2.2.2.2", 0, now, 0, 3); len(got) != 0 {
+		t.Fatalf("unexpected alert for other IP: %+v", got)
+	}
+}
+
+func TestRollingCounterWindow(t *testing.T) {
+	counter := newRollingCounter(time.Minute)
+	start := time.Date(2025, 12, 1, 12, 0, 0, 0, time.UTC)
+
+	counter.add(start, 100)
+	counter.add(start.Add(30*time.Second), 100)
+
+	bytes, requests := counter.add(start.Add(50*time.Second), 100)
+	if bytes != 300 || requests != 3 {
+		t.Fatalf("got %d bytes %d requests, want 300/3", bytes, requests)
+	}
+
+	// First request falls out of the window
+	bytes, requests = counter.add(start.Add(65*time.Second), 100)
+	if bytes != 300 || requests != 3 {
+		t.Fatalf("got %d bytes %d requests, want 300/3", bytes, requests)
+	}
+}
+
+func TestConfigEnabled(t *testing.T) {
+	if (Config{}).Enabled() {
+		t.Error("empty config should be disabled")
+	}
+	if !(Config{TotalMaxBytes: 1}).Enabled() {
+		t.Error("config with threshold should be enabled")
+	}
+}
@@ -0,0 +1,47 @@
+package alert
+
+import "time"
+
+const bucketsPerWindow = 12
+
+// rollingCounter sums bytes/requests over a sliding window split into fixed buckets
+type rollingCounter struct {
+	bucketSize time.Duration
+	buckets    [bucketsPerWindow]bucket
+	lastSeen   time.Time
+}
+
+type bucket struct {
+	index    int64 // absolute bucket number, used to detect stale buckets
+	bytes    int64
+	requests int64
+}
+
+func newRollingCounter(window time.Duration) *rollingCounter {
+	bucketSize := window / bucketsPerWindow
+	if bucketSize <= 0 {
+		bucketSize = time.Second
+	}
+	return &rollingCounter{bucketSize: bucketSize}
+}
+
+// add records one request and returns window totals
+func (c *rollingCounter) add(now time.Time, bytes int64) (int64, int64) {
+	index := now.UnixNano() / int64(c.bucketSize)
+	b := &c.buckets[index%bucketsPerWindow]
+	if b.index != index {
+		*b = bucket{index: index}
+	}
+	b.bytes += bytes
+	b.requests++
+	c.lastSeen = now
+
+	var totalBytes, totalRequests int64
+	for _, b := range c.buckets {
+		if index-b.index < bucketsPerWindow {
+			totalBytes += b.bytes
+			totalRequests += b.requests
+		}
+	}
+	return totalBytes, totalRequests
+}
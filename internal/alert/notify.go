@@ -0,0 +1,85 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+func newNotifier(config Config) func(Alert) {
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	return func(alert Alert) {
+		log.Printf("⚠️  %s", alert)
+
+		if config.WebhookURL != "" {
+			if err := sendWebhook(client, config.WebhookURL, alert); err != nil {
+				log.Printf("Warning: Failed to send alert webhook: %v", err)
+			}
+		}
+
+		if config.Email.SMTPAddr != "" && config.Email.To != "" {
+			if err := sendEmail(config.Email, alert); err != nil {
+				log.Printf("Warning: Failed to send alert email: %v", err)
+			}
+		}
+	}
+}
+
+func sendWebhook(client *http.Client, url string, alert Alert) error {
+	payload := struct {
+		Text string `json:"text"`
+		Alert
+	}{
+		Text:  alert.String(),
+		Alert: alert,
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+func sendEmail(config EmailConfig, alert Alert) error {
+	var auth smtp.Auth
+	if config.Username != "" {
+		host, _, err := net.SplitHostPort(config.SMTPAddr)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address: %w", err)
+		}
+		auth = smtp.PlainAuth("", config.Username, config.Password, host)
+	}
+
+	from := config.From
+	if from == "" {
+		from = config.Username
+	}
+	recipients := strings.Split(config.To, ",")
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + config.To + "\r\n")
+	msg.WriteString(fmt.Sprintf("Subject: lorem.video %s alert for %s\r\n", alert.Metric, alert.Key))
+	msg.WriteString("\r\n")
+	msg.WriteString(alert.String() + "\r\n")
+
+	return smtp.SendMail(config.SMTPAddr, auth, from, recipients, []byte(msg.String()))
+}
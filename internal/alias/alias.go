@@ -0,0 +1,129 @@
+package alias
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// nameRegex keeps aliases URL safe, e.g. tv-smoke-1
+var nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+var ErrInvalidName = errors.New("alias must be 1-64 lowercase letters, digits or dashes")
+
+// Alias maps short name to full spec string, served at /s/{name}
+type Alias struct {
+	Name      string    `json:"name"`
+	Spec      string    `json:"spec"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store keeps aliases in memory and persists every change to JSON file
+type Store struct {
+	mutex   sync.RWMutex
+	path    string
+	aliases map[string]Alias
+}
+
+// Open loads aliases from path, missing file starts empty store
+func Open(path string) (*Store, error) {
+	store := &Store{path: path, aliases: make(map[string]Alias)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var aliases []Alias
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return nil, fmt.Errorf("invalid alias file %s: %w", path, err)
+	}
+	for _, alias := range aliases {
+		store.aliases[alias.Name] = alias
+	}
+	return store, nil
+}
+
+func (s *Store) Get(name string) (Alias, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	alias, ok := s.aliases[name]
+	return alias, ok
+}
+
+// List returns aliases sorted by name
+func (s *Store) List() []Alias {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.sorted()
+}
+
+// Set creates or replaces alias, spec must be validated by caller
+func (s *Store) Set(name, spec string) (Alias, error) {
+	if !nameRegex.MatchString(name) {
+		return Alias{}, ErrInvalidName
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	alias := Alias{Name: name, Spec: spec, CreatedAt: time.Now()}
+	previous, existed := s.aliases[name]
+	s.aliases[name] = alias
+	if err := s.save(); err != nil {
+		if existed {
+			s.aliases[name] = previous
+		} else {
+			delete(s.aliases, name)
+		}
+		return Alias{}, err
+	}
+	return alias, nil
+}
+
+// Delete removes alias, reports false when it didn't exist
+func (s *Store) Delete(name string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	previous, ok := s.aliases[name]
+	if !ok {
+		return false, nil
+	}
+	delete(s.aliases, name)
+	if err := s.save(); err != nil {
+		s.aliases[name] = previous
+		return false, err
+	}
+	return true, nil
+}
+
+// save writes all aliases via temp file and rename, must be called with mutex held
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, s.path)
+}
+
+func (s *Store) sorted() []Alias {
+	aliases := make([]Alias, 0, len(s.aliases))
+	for _, alias := range s.aliases {
+		aliases = append(aliases, alias)
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+	return aliases
+}
@@ -0,0 +1,36 @@
+package alias
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "aliases.json")
+	store, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Set("TV smoke", "bunny_720p"); err != ErrInvalidName {
+		t.Errorf("expected invalid name error, got %v", err)
+	}
+	if _, err := store.Set("tv-smoke-1", "bunny_h264_1920x1080_30fps_20s_25crf_aac_128kbps.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Set("tv-smoke-2", "bunny_480p"); err != nil {
+		t.Fatal(err)
+	}
+	if deleted, err := store.Delete("tv-smoke-2"); !deleted || err != nil {
+		t.Errorf("delete failed: %v %v", deleted, err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	aliases := reopened.List()
+	if len(aliases) != 1 || aliases[0].Name != "tv-smoke-1" || aliases[0].Spec != "bunny_h264_1920x1080_30fps_20s_25crf_aac_128kbps.mp4" {
+		t.Errorf("unexpected aliases after reopen: %+v", aliases)
+	}
+}
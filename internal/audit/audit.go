@@ -0,0 +1,48 @@
+package audit
+
+import (
+	"log"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/jsonl"
+)
+
+// Entry is one line of audit-YYYY-MM-DD.jsonl, files are only ever appended to
+type Entry struct {
+	Timestamp time.Time         `json:"timestamp"`
+	RequestID string            `json:"requestId,omitempty"`
+	Actor     string            `json:"actor"`            // X-Admin-Actor header if sent, otherwise "admin"
+	IP        string            `json:"ip"`               // Client IP
+	Action    string            `json:"action"`           // e.g. "maintenance.enable"
+	Params    map[string]string `json:"params,omitempty"` // Path values and query params
+	Body      string            `json:"body,omitempty"`   // Request body, truncated
+	Status    int               `json:"status"`           // Response status, failed attempts are recorded too
+}
+
+// Record appends entry to daily audit log
+func Record(entry Entry) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	log.Printf("AUDIT [%s] %s by %s (%s): %d", entry.RequestID, entry.Action, entry.Actor, entry.IP, entry.Status)
+
+	if err := appendEntry(config.AppPaths.LogsAudit, entry); err != nil {
+		log.Printf("Warning: Failed to write audit log: %v", err)
+	}
+}
+
+func appendEntry(dir string, entry Entry) error {
+	return jsonl.Append(dir, "audit", entry.Timestamp, entry)
+}
+
+// Query returns entries newer than since, optionally only of action, most recent first, at most limit entries
+func Query(since time.Time, action string, limit int) ([]Entry, error) {
+	return query(config.AppPaths.LogsAudit, since, action, limit)
+}
+
+func query(dir string, since time.Time, action string, limit int) ([]Entry, error) {
+	return jsonl.Query(dir, "audit", since, limit, func(entry Entry) bool {
+		return entry.Timestamp.After(since) && (action == "" || entry.Action == action)
+	})
+}
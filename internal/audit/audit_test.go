@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryFiltersAction(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+
+	for i, action := range []string{"maintenance.enable", "alias.put", "maintenance.disable", "alias.put"} {
+		entry := Entry{Timestamp: day.Add(time.Duration(i) * time.Hour), Action: action, Actor: "ops", Status: 200}
+		if err := appendEntry(dir, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := query(dir, day.Add(-time.Hour), "alias.put", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || !entries[0].Timestamp.Equal(day.Add(3*time.Hour)) {
+		t.Errorf("expected 2 alias.put entries newest first, got %+v", entries)
+	}
+
+	all, err := query(dir, day.Add(-time.Hour), "", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 3 || all[0].Action != "alias.put" || all[2].Action != "alias.put" {
+		t.Errorf("unexpected limited entries: %+v", all)
+	}
+}
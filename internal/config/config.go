@@ -20,19 +20,35 @@ type Paths struct {
 	Data        string
 	Video       string
 	Stream      string
+	HLSCache    string // Lazily encoded HLS segments of sources without pregenerated ladder
 	SourceVideo string
 	Logs        string
 	LogsStats   string
 	LogsBots    string
 	LogsErrors  string
+	LogsJobs    string // Full FFmpeg output of latest transcode per spec
+	LogsAudit   string // Append-only admin action log
 	Tmp         string
+	TmpRAM      string // Optional RAM-backed (tmpfs) dir for short encodes, from TMP_RAM_DIR
+	GeoIP       string // optional country.csv/asn.csv range files
+	Aliases     string // Spec shortlinks JSON file
+	Tenants     string // Per tenant sourceVideo and tmp dirs, see TENANTS_FILE
+	Uploads     string // Partial resumable source video uploads
 
 	DefaultSourceVideo string // bunny.mp4 path
 }
 
 var AppPaths = initPaths()
 
-const Port = 3000
+// Port is HTTP listen port, PORT env variable or 3000
+var Port = getPort()
+
+func getPort() int {
+	if port := GetEnvInt64("PORT"); port > 0 {
+		return int(port)
+	}
+	return 3000
+}
 
 func GetBaseURL() string {
 	baseURL := os.Getenv("BASE_URL")
@@ -42,6 +58,11 @@ func GetBaseURL() string {
 	return baseURL
 }
 
+// GetAdminToken returns bearer token for admin/stats endpoints, empty disables them
+func GetAdminToken() string {
+	return os.Getenv("ADMIN_TOKEN")
+}
+
 func initPaths() *Paths {
 	dataDir := getDataDir()
 	sourceVideoDir := filepath.Join(dataDir, "sourceVideo")
@@ -50,12 +71,20 @@ func initPaths() *Paths {
 		Data:        dataDir,
 		Video:       filepath.Join(dataDir, "video"),
 		Stream:      filepath.Join(dataDir, "stream"),
+		HLSCache:    filepath.Join(dataDir, "hlscache"),
 		SourceVideo: sourceVideoDir,
 		Logs:        filepath.Join(dataDir, "logs"),
 		LogsStats:   filepath.Join(dataDir, "logs", "stats"),
 		LogsBots:    filepath.Join(dataDir, "logs", "bots"),
 		LogsErrors:  filepath.Join(dataDir, "logs", "errors"),
+		LogsJobs:    filepath.Join(dataDir, "logs", "jobs"),
+		LogsAudit:   filepath.Join(dataDir, "logs", "audit"),
 		Tmp:         filepath.Join(dataDir, "tmp"),
+		TmpRAM:      os.Getenv("TMP_RAM_DIR"),
+		GeoIP:       filepath.Join(dataDir, "geoip"),
+		Aliases:     filepath.Join(dataDir, "aliases.json"),
+		Tenants:     filepath.Join(dataDir, "tenants"),
+		Uploads:     filepath.Join(dataDir, "uploads"),
 
 		// Default files
 		DefaultSourceVideo: filepath.Join(sourceVideoDir, "bunny.mp4"),
@@ -63,6 +92,11 @@ func initPaths() *Paths {
 }
 
 func getDataDir() string {
+	// Explicit location, e.g. isolated data dir of self-test server
+	if dataDir := os.Getenv("DATA_DIR"); dataDir != "" {
+		return dataDir
+	}
+
 	// Check if we're in a Docker container (common location)
 	if _, err := os.Stat("/data"); err == nil {
 		return "/data"
@@ -83,11 +117,19 @@ func EnsureDirectories() error {
 		AppPaths.SourceVideo,
 		AppPaths.Video,
 		AppPaths.Stream,
+		AppPaths.HLSCache,
 		AppPaths.Logs,
 		AppPaths.LogsStats,
 		AppPaths.LogsBots,
 		AppPaths.LogsErrors,
+		AppPaths.LogsJobs,
+		AppPaths.LogsAudit,
 		AppPaths.Tmp,
+		AppPaths.Tenants,
+		AppPaths.Uploads,
+	}
+	if AppPaths.TmpRAM != "" {
+		dirs = append(dirs, AppPaths.TmpRAM)
 	}
 
 	for _, dir := range dirs {
@@ -100,7 +142,12 @@ func EnsureDirectories() error {
 }
 
 func GetSourceVideoFiles() ([]string, error) {
-	entries, err := os.ReadDir(AppPaths.SourceVideo)
+	return GetSourceVideoFilesIn(AppPaths.SourceVideo)
+}
+
+// GetSourceVideoFilesIn lists video files of source dir, e.g. tenant's own sources
+func GetSourceVideoFilesIn(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read source video directory: %w", err)
 	}
@@ -118,10 +165,36 @@ func GetSourceVideoFiles() ([]string, error) {
 		}
 
 		if slices.Contains(ValidContainers, ext) {
-			fullPath := filepath.Join(AppPaths.SourceVideo, entry.Name())
+			fullPath := filepath.Join(dir, entry.Name())
 			videoFiles = append(videoFiles, fullPath)
 		}
 	}
 
 	return videoFiles, nil
 }
+
+// FindSourceVideo returns source video path for name (filename without extension), any valid container.
+// Synthetic sources resolve to SyntheticSourcePrefix + name
+func FindSourceVideo(name string) (string, error) {
+	if slices.Contains(SyntheticSources, name) {
+		return SyntheticSourcePrefix + name, nil
+	}
+
+	return FindSourceVideoIn(AppPaths.SourceVideo, name)
+}
+
+// FindSourceVideoIn looks up source video by name in dir, without synthetic sources
+func FindSourceVideoIn(dir, name string) (string, error) {
+	files, err := GetSourceVideoFilesIn(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		if strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)) == name {
+			return file, nil
+		}
+	}
+
+	return "", fmt.Errorf("source video not found: %s", name)
+}
@@ -0,0 +1,30 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// GetEnvDuration parses duration env variable (e.g. 5m), fallback when unset or invalid
+func GetEnvDuration(name string, fallback time.Duration) time.Duration {
+	if value := os.Getenv(name); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+		log.Printf("Warning: invalid %s=%q, using %s", name, value, fallback)
+	}
+	return fallback
+}
+
+// GetEnvInt64 parses integer env variable, 0 when unset or invalid (disables thresholds)
+func GetEnvInt64(name string) int64 {
+	if value := os.Getenv(name); value != "" {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+		log.Printf("Warning: invalid %s=%q, ignoring", name, value)
+	}
+	return 0
+}
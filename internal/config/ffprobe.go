@@ -3,6 +3,7 @@ package config
 type FFProbeOutput struct {
 	Streams []FFprobeStream `json:"streams"`
 	Format  FFprobeFormat   `json:"format"`
+	Summary *VideoSummary   `json:"summary,omitempty"` // Filled by GetInfo, not part of ffprobe output
 }
 
 type FFprobeStream struct {
@@ -30,3 +31,29 @@ type FFprobeFormat struct {
 	Size           string `json:"size"`
 	BitRate        string `json:"bit_rate"`
 }
+
+// VideoSummary is normalized view of FFProbeOutput, numbers instead of ffprobe strings like "30000/1001"
+type VideoSummary struct {
+	Container  string          `json:"container"` // e.g. "mp4" instead of "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration   float64         `json:"duration"`  // Seconds
+	Size       int64           `json:"size"`      // Bytes
+	Bitrate    int             `json:"bitrate"`   // Overall kbps
+	Width      int             `json:"width,omitempty"`
+	Height     int             `json:"height,omitempty"`
+	Resolution string          `json:"resolution,omitempty"` // e.g. "1280x720"
+	FPS        float64         `json:"fps,omitempty"`
+	Streams    []StreamSummary `json:"streams"`
+}
+
+type StreamSummary struct {
+	Index         int     `json:"index"`
+	Type          string  `json:"type"` // video, audio, ...
+	Codec         string  `json:"codec"`
+	Bitrate       int     `json:"bitrate,omitempty"` // kbps, WebM streams often don't report it
+	Width         int     `json:"width,omitempty"`
+	Height        int     `json:"height,omitempty"`
+	FPS           float64 `json:"fps,omitempty"`
+	SampleRate    int     `json:"sampleRate,omitempty"`
+	Channels      int     `json:"channels,omitempty"`
+	ChannelLayout string  `json:"channelLayout,omitempty"`
+}
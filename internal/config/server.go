@@ -0,0 +1,35 @@
+package config
+
+import (
+	"net/http"
+	"time"
+)
+
+// ServerConfig holds http.Server timeouts and request limits
+type ServerConfig struct {
+	ReadHeaderTimeout time.Duration
+	IdleTimeout       time.Duration
+	WriteTimeout      time.Duration // 0 disables, long video downloads and slow clients would be cut otherwise
+	MaxHeaderBytes    int
+	MaxBodyBytes      int64         // Per request body limit
+	ShutdownTimeout   time.Duration // Open requests get this long to finish on SIGTERM
+}
+
+// GetServerConfig reads SERVER_* environment variables
+func GetServerConfig() ServerConfig {
+	serverConfig := ServerConfig{
+		ReadHeaderTimeout: GetEnvDuration("SERVER_READ_HEADER_TIMEOUT", 10*time.Second),
+		IdleTimeout:       GetEnvDuration("SERVER_IDLE_TIMEOUT", 2*time.Minute),
+		WriteTimeout:      GetEnvDuration("SERVER_WRITE_TIMEOUT", 0),
+		MaxHeaderBytes:    int(GetEnvInt64("SERVER_MAX_HEADER_BYTES")),
+		MaxBodyBytes:      1 << 20, // 1MB, only small JSON bodies (admin, batch) are posted
+		ShutdownTimeout:   GetEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
+	}
+	if serverConfig.MaxHeaderBytes <= 0 {
+		serverConfig.MaxHeaderBytes = http.DefaultMaxHeaderBytes
+	}
+	if maxBody := GetEnvInt64("SERVER_MAX_BODY_BYTES"); maxBody > 0 {
+		serverConfig.MaxBodyBytes = maxBody
+	}
+	return serverConfig
+}
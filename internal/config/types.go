@@ -18,6 +18,14 @@ type VideoSpec struct {
 	Bitrate      string // "25crf", "3000cbr", or "3000vbr"
 	AudioCodec   string
 	AudioBitrate int    // kbps
+	Channels     string // ChannelLayouts key, empty means stereo
+	VR360        bool   // Tag MP4 as equirectangular 360° video
+	Stereo3D     string // Frame packing: "sbs3d" (side-by-side) or "tb3d" (top-bottom), empty for 2D
+	Grain        int    // Noise filter strength 1-100, 0 disables
+	Blur         int    // Gaussian blur sigma 1-50, 0 disables
+	Pixelate     int    // Block size in pixels 2-128, 0 disables
+	Slide        int    // Slideshow seconds per slide 1-60, 0 uses default
+	Crossfade    int    // Slideshow crossfade milliseconds, 0 for hard cuts
 	Container    string // file extension/container format
 }
 
@@ -67,10 +75,41 @@ var AudioCodecNameMap = map[string]string{
 	"noaudio": "none",
 }
 
+// SyntheticSources are rendered procedurally by FFmpeg filters instead of read from sourceVideo dir
+var SyntheticSources = []string{
+	"avsync",   // Full-screen flash with coincident beep every second, for A/V sync testing
+	"clock",    // Frame index, wall-clock time and spec printed on every frame, for latency and dropped-frame measurements
+	"channels", // Tone and name of each channel in sequence for selected layout, for downmix/channel mapping checks
+	"stress",   // High-motion noise, fine detail and scene cuts 4 times per second, hard to compress for rate control tests
+	"slides",   // Numbered solid color cards, slide=N seconds each with optional xfade=N ms crossfade
+	"bars",     // EBU 75% color bars with 1kHz reference tone at -18 dBFS, for broadcast level checks
+	"testsrc",  // FFmpeg testsrc2 pattern with 440Hz tone, like default source without needing a file
+	"noise",    // Full-frame random noise with pink noise audio, incompressible worst case
+	"black",    // Black frames with silence, minimal bitrate baseline
+}
+
+// SyntheticSourcePrefix marks input path of synthetic source, e.g. "synthetic:avsync"
+const SyntheticSourcePrefix = "synthetic:"
+
+// ChannelLayouts maps filename token to FFmpeg channel layout
+var ChannelLayouts = map[string]string{
+	"mono":    "mono",
+	"stereo":  "stereo",
+	"quad":    "quad",
+	"5point1": "5.1",
+	"7point1": "7.1",
+}
+
 var ValidVideoCodecs = slices.Collect(maps.Keys(VideoCodecNameMap))
 var ValidAudioCodecs = slices.Collect(maps.Keys(AudioCodecNameMap))
 var ValidContainers = []string{"mp4", "webm"}
 
+// ContainerCodecs lists video and audio codecs players commonly support in each container, first is preferred
+var ContainerCodecs = map[string]struct{ Video, Audio []string }{
+	"mp4":  {Video: []string{"h264", "h265", "av1"}, Audio: []string{"aac"}},
+	"webm": {Video: []string{"vp9", "av1"}, Audio: []string{"opus", "vorbis"}},
+}
+
 type Resolution struct {
 	Width  int `json:"width"`
 	Height int `json:"height"`
@@ -123,6 +162,31 @@ var VideoCodecArgs = map[string][]string{
 	},
 }
 
+// VideoCodecFastArgs replace VideoCodecArgs for degraded encodes when server is under load
+var VideoCodecFastArgs = map[string][]string{
+	"libaom-av1": {
+		"-usage", "realtime",
+		"-cpu-used", "10",
+		"-row-mt", "1",
+		"-tiles", "2x2",
+	},
+	"libx264": {
+		"-preset", "ultrafast",
+		"-threads", "0",
+	},
+	"libx265": {
+		"-preset", "ultrafast",
+		"-x265-params", "pools=+",
+	},
+	"libvpx-vp9": {
+		"-deadline", "realtime",
+		"-speed", "8",
+		"-tile-columns", "2",
+		"-tile-rows", "1",
+		"-threads", "8",
+	},
+}
+
 func ApplyDefaultVideoSpec(input *VideoSpec) VideoSpec {
 	result := DefaultVideoSpec
 	if input.Name != "" {
@@ -152,6 +216,30 @@ func ApplyDefaultVideoSpec(input *VideoSpec) VideoSpec {
 	if input.AudioBitrate != 0 {
 		result.AudioBitrate = input.AudioBitrate
 	}
+	if input.Grain != 0 {
+		result.Grain = input.Grain
+	}
+	if input.Blur != 0 {
+		result.Blur = input.Blur
+	}
+	if input.Pixelate != 0 {
+		result.Pixelate = input.Pixelate
+	}
+	if input.Slide != 0 {
+		result.Slide = input.Slide
+	}
+	if input.Crossfade != 0 {
+		result.Crossfade = input.Crossfade
+	}
+	if input.VR360 {
+		result.VR360 = true
+	}
+	if input.Stereo3D != "" {
+		result.Stereo3D = input.Stereo3D
+	}
+	if input.Channels != "" {
+		result.Channels = input.Channels
+	}
 	if input.Container != "" {
 		result.Container = input.Container
 	}
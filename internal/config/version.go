@@ -0,0 +1,45 @@
+package config
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time with -ldflags "-X lorem.video/internal/config.Version=v1.2.0 -X ...Commit=... -X ...BuildDate=...",
+// see Dockerfile. Commit and BuildDate fall back to VCS info Go embeds when built inside git checkout
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Build identifies running binary
+type Build struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildDate string `json:"buildDate,omitempty"`
+	Modified  bool   `json:"modified,omitempty"` // Built from checkout with uncommitted changes
+	GoVersion string `json:"goVersion"`
+}
+
+func GetBuild() Build {
+	build := Build{Version: Version, Commit: Commit, BuildDate: BuildDate, GoVersion: runtime.Version()}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if build.Commit == "" {
+					build.Commit = setting.Value
+				}
+			case "vcs.time":
+				if build.BuildDate == "" {
+					build.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				build.Modified = setting.Value == "true"
+			}
+		}
+	}
+	return build
+}
@@ -0,0 +1,85 @@
+package errlog
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/jsonl"
+)
+
+// stderrExcerptSize keeps tail of FFmpeg output, the actual error is usually in the last lines
+const stderrExcerptSize = 2048
+
+// Entry is one line of errors-YYYY-MM-DD.jsonl
+type Entry struct {
+	Timestamp    time.Time `json:"timestamp"`
+	RequestID    string    `json:"requestId,omitempty"`
+	Route        string    `json:"route,omitempty"` // e.g. "GET /720p_av1"
+	Spec         string    `json:"spec,omitempty"`  // Generated video filename or HLS resolution
+	Job          string    `json:"job,omitempty"`   // Full FFmpeg log at /jobs/{job}/log
+	Message      string    `json:"message"`
+	FFmpegStderr string    `json:"ffmpegStderr,omitempty"`
+	Stack        string    `json:"stack,omitempty"` // Recovered panics
+}
+
+// RequestInfo identifies request that caused an error, carried in context down to background transcodes
+type RequestInfo struct {
+	ID    string
+	Route string
+}
+
+type requestInfoKey struct{}
+
+func WithRequest(ctx context.Context, info RequestInfo) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, info)
+}
+
+func RequestFromContext(ctx context.Context) RequestInfo {
+	info, _ := ctx.Value(requestInfoKey{}).(RequestInfo)
+	return info
+}
+
+// Record fills request info from ctx, prints entry to stdout and appends it to daily error log
+func Record(ctx context.Context, entry Entry) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	info := RequestFromContext(ctx)
+	if entry.RequestID == "" {
+		entry.RequestID = info.ID
+	}
+	if entry.Route == "" {
+		entry.Route = info.Route
+	}
+
+	log.Printf("ERROR [%s] %s %s: %s", entry.RequestID, entry.Route, entry.Spec, entry.Message)
+
+	if err := appendEntry(config.AppPaths.LogsErrors, entry); err != nil {
+		log.Printf("Warning: Failed to write error log: %v", err)
+	}
+}
+
+func appendEntry(dir string, entry Entry) error {
+	return jsonl.Append(dir, "errors", entry.Timestamp, entry)
+}
+
+// Query returns entries newer than since, most recent first, at most limit entries
+func Query(since time.Time, limit int) ([]Entry, error) {
+	return query(config.AppPaths.LogsErrors, since, limit)
+}
+
+func query(dir string, since time.Time, limit int) ([]Entry, error) {
+	return jsonl.Query(dir, "errors", since, limit, func(entry Entry) bool {
+		return entry.Timestamp.After(since)
+	})
+}
+
+// StderrExcerpt trims FFmpeg output to its last stderrExcerptSize bytes
+func StderrExcerpt(stderr string) string {
+	if len(stderr) <= stderrExcerptSize {
+		return stderr
+	}
+	return "..." + stderr[len(stderr)-stderrExcerptSize:]
+}
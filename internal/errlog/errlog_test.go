@@ -0,0 +1,59 @@
+package errlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuery(t *testing.T) {
+	dir := t.TempDir()
+	day1 := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	for _, entry := range []Entry{
+		{Timestamp: day1, Message: "old"},
+		{Timestamp: day1.Add(time.Hour), Message: "first", RequestID: "a"},
+		{Timestamp: day2, Message: "second", RequestID: "b"},
+		{Timestamp: day2.Add(time.Hour), Message: "third", FFmpegStderr: "boom"},
+	} {
+		if err := appendEntry(dir, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := query(dir, day1.Add(time.Minute), 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var messages []string
+	for _, entry := range entries {
+		messages = append(messages, entry.Message)
+	}
+	if got := strings.Join(messages, ","); got != "third,second,first" {
+		t.Errorf("messages = %s, want third,second,first", got)
+	}
+	if entries[0].FFmpegStderr != "boom" || entries[1].RequestID != "b" {
+		t.Errorf("entry fields not preserved: %+v", entries[:2])
+	}
+
+	limited, err := query(dir, day1.Add(-time.Hour), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(limited) != 2 || limited[1].Message != "second" {
+		t.Errorf("limited query = %+v", limited)
+	}
+}
+
+func TestStderrExcerpt(t *testing.T) {
+	if got := StderrExcerpt("short"); got != "short" {
+		t.Errorf("StderrExcerpt(short) = %q", got)
+	}
+
+	long := strings.Repeat("x", stderrExcerptSize) + "error line"
+	got := StderrExcerpt(long)
+	if !strings.HasSuffix(got, "error line") || len(got) != stderrExcerptSize+3 {
+		t.Errorf("excerpt should keep tail, got len %d", len(got))
+	}
+}
@@ -0,0 +1,227 @@
+package fileindex
+
+import (
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Index of generated files (video, tmp, stream dirs) to avoid stat/glob on every request.
+// Until Build is called lookups fall back to the filesystem, so CLI tools work unchanged
+type Index struct {
+	ready atomic.Bool
+	roots []string
+
+	building sync.Mutex // One walk at a time
+
+	mutex   sync.RWMutex
+	files   map[string]struct{}
+	dirs    map[string]struct{}
+	chunks  map[string]int  // directory -> HLS chunk_*.mp4 count
+	changes map[string]bool // Add (true) and Remove (false) made during walk, replayed on rebuilt maps
+}
+
+var defaultIndex = &Index{}
+
+func New() *Index {
+	return &Index{}
+}
+
+// Build walks roots and replaces index contents, missing roots are skipped
+func (i *Index) Build(roots ...string) error {
+	i.building.Lock()
+	defer i.building.Unlock()
+
+	i.mutex.Lock()
+	i.changes = make(map[string]bool)
+	i.mutex.Unlock()
+
+	files := make(map[string]struct{})
+	dirs := make(map[string]struct{})
+	chunks := make(map[string]int)
+
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Missing root or file removed during walk
+			}
+			if d.IsDir() {
+				dirs[path] = struct{}{}
+				return nil
+			}
+			files[path] = struct{}{}
+			if isChunk(path) {
+				chunks[filepath.Dir(path)]++
+			}
+			return nil
+		})
+		if err != nil {
+			i.mutex.Lock()
+			i.changes = nil
+			i.mutex.Unlock()
+			return err
+		}
+	}
+
+	i.mutex.Lock()
+	i.files, i.dirs, i.chunks = files, dirs, chunks
+	i.roots = roots
+	// Walk may have missed file FFmpeg started writing meanwhile, index must keep it or another transcode overwrites it
+	for path, added := range i.changes {
+		if added {
+			i.add(path)
+		} else {
+			i.remove(path)
+		}
+	}
+	i.changes = nil
+	i.mutex.Unlock()
+	i.ready.Store(true)
+
+	return nil
+}
+
+// StartRefresh rebuilds index periodically to pick up changes made outside the server (cleanup CLI, manual deletes)
+func (i *Index) StartRefresh(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			i.mutex.RLock()
+			roots := i.roots
+			i.mutex.RUnlock()
+
+			if err := i.Build(roots...); err != nil {
+				log.Printf("Warning: Failed to refresh file index: %v", err)
+			}
+		}
+	}()
+}
+
+// Add records file and its parent directories
+func (i *Index) Add(path string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.changes != nil {
+		i.changes[path] = true
+	}
+	if i.ready.Load() {
+		i.add(path)
+	}
+}
+
+// add must be called with mutex held
+func (i *Index) add(path string) {
+	if _, exists := i.files[path]; exists {
+		return
+	}
+	i.files[path] = struct{}{}
+	if isChunk(path) {
+		i.chunks[filepath.Dir(path)]++
+	}
+	for dir := filepath.Dir(path); ; dir = filepath.Dir(dir) {
+		if _, exists := i.dirs[dir]; exists {
+			break
+		}
+		i.dirs[dir] = struct{}{}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+}
+
+// AddTree records all files under dir, used after FFmpeg writes many files (HLS)
+func (i *Index) AddTree(dir string) {
+	if !i.ready.Load() {
+		return
+	}
+
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			i.Add(path)
+		}
+		return nil
+	})
+}
+
+func (i *Index) Remove(path string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.changes != nil {
+		i.changes[path] = false
+	}
+	if i.ready.Load() {
+		i.remove(path)
+	}
+}
+
+// remove must be called with mutex held
+func (i *Index) remove(path string) {
+	if _, exists := i.files[path]; !exists {
+		return
+	}
+	delete(i.files, path)
+	if isChunk(path) {
+		i.chunks[filepath.Dir(path)]--
+	}
+}
+
+func (i *Index) Exists(path string) bool {
+	if !i.ready.Load() {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	_, exists := i.files[path]
+	return exists
+}
+
+func (i *Index) DirExists(path string) bool {
+	if !i.ready.Load() {
+		stat, err := os.Stat(path)
+		return err == nil && stat.IsDir()
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	_, exists := i.dirs[path]
+	return exists
+}
+
+// ChunkCount returns number of HLS chunk_*.mp4 files in dir
+func (i *Index) ChunkCount(dir string) int {
+	if !i.ready.Load() {
+		// TODO maybe better pattern match from config.HLSChunkFormat
+		matches, _ := filepath.Glob(filepath.Join(dir, "chunk_*.mp4"))
+		return len(matches)
+	}
+
+	i.mutex.RLock()
+	defer i.mutex.RUnlock()
+	return i.chunks[dir]
+}
+
+func isChunk(path string) bool {
+	name := filepath.Base(path)
+	return strings.HasPrefix(name, "chunk_") && strings.HasSuffix(name, ".mp4")
+}
+
+// Package level functions operate on the server wide index
+
+func Build(roots ...string) error         { return defaultIndex.Build(roots...) }
+func StartRefresh(interval time.Duration) { defaultIndex.StartRefresh(interval) }
+func Add(path string)                     { defaultIndex.Add(path) }
+func AddTree(dir string)                  { defaultIndex.AddTree(dir) }
+func Remove(path string)                  { defaultIndex.Remove(path) }
+func Exists(path string) bool             { return defaultIndex.Exists(path) }
+func DirExists(path string) bool          { return defaultIndex.DirExists(path) }
+func ChunkCount(dir string) int           { return defaultIndex.ChunkCount(dir) }
@@ -0,0 +1,47 @@
+package fileindex
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	resolutionDir := filepath.Join(root, "bunny", "720p")
+	if err := os.MkdirAll(resolutionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"chunk_000.mp4", "chunk_001.mp4", "init.mp4"} {
+		if err := os.WriteFile(filepath.Join(resolutionDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	index := New()
+	newFile := filepath.Join(root, "bunny", "1080p", "chunk_000.mp4")
+
+	// Filesystem fallback before Build
+	if !index.DirExists(resolutionDir) || index.ChunkCount(resolutionDir) != 2 {
+		t.Fatal("fallback lookups failed")
+	}
+	index.Add(newFile) // Ignored until built
+
+	if err := index.Build(root, filepath.Join(root, "missing")); err != nil {
+		t.Fatal(err)
+	}
+	if !index.Exists(filepath.Join(resolutionDir, "init.mp4")) || index.ChunkCount(resolutionDir) != 2 {
+		t.Fatal("index missing built files")
+	}
+
+	// Index answers without touching filesystem
+	index.Add(newFile)
+	if !index.Exists(newFile) || !index.DirExists(filepath.Dir(newFile)) || index.ChunkCount(filepath.Dir(newFile)) != 1 {
+		t.Error("added file not indexed")
+	}
+
+	index.Remove(filepath.Join(resolutionDir, "chunk_001.mp4"))
+	if index.ChunkCount(resolutionDir) != 1 || index.Exists(filepath.Join(resolutionDir, "chunk_001.mp4")) {
+		t.Error("removed file still indexed")
+	}
+}
@@ -0,0 +1,95 @@
+// Package jsonl stores append-only logs as one JSON entry per line, split into daily prefix-YYYY-MM-DD.jsonl files
+package jsonl
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var mutex sync.Mutex
+
+func dailyFile(prefix string, day time.Time) string {
+	return fmt.Sprintf("%s-%s.jsonl", prefix, day.Format("2006-01-02"))
+}
+
+// Append writes entry as one line to daily file of timestamp
+func Append(dir, prefix string, timestamp time.Time, entry any) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	path := filepath.Join(dir, dailyFile(prefix, timestamp))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+// Query returns entries accepted by match from daily files since given day, most recent first, at most limit entries
+func Query[T any](dir, prefix string, since time.Time, limit int, match func(T) bool) ([]T, error) {
+	files, err := filepath.Glob(filepath.Join(dir, prefix+"-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest files first, stop once enough entries are collected
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	sinceFile := dailyFile(prefix, since)
+
+	entries := []T{}
+	for _, file := range files {
+		if filepath.Base(file) < sinceFile {
+			break
+		}
+
+		fileEntries, err := readEntries(file, match)
+		if err != nil {
+			return nil, err
+		}
+		for i := len(fileEntries) - 1; i >= 0 && len(entries) < limit; i-- {
+			entries = append(entries, fileEntries[i])
+		}
+		if len(entries) >= limit {
+			break
+		}
+	}
+
+	return entries, nil
+}
+
+func readEntries[T any](filename string, match func(T) bool) ([]T, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []T
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // Panic stacks can be long
+	for scanner.Scan() {
+		var entry T
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // Skip malformed lines
+		}
+		if match(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, scanner.Err()
+}
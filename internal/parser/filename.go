@@ -2,7 +2,6 @@ package parser
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"regexp"
 	"slices"
@@ -10,14 +9,20 @@ import (
 	"strings"
 
 	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
 )
 
-var resolutionRegex = regexp.MustCompile(`^(\d+)x(\d+)$`) // 1280x720
-var durationRegex = regexp.MustCompile(`^(\d+)s$`)        // 60s
-var crfRegex = regexp.MustCompile(`^(\d+)crf$`)           // constant rate factor 23
-var cbrRegex = regexp.MustCompile(`^(\d+)cbr$`)           // constant bitrate 3000
-var vbrRegex = regexp.MustCompile(`^(\d+)vbr$`)           // variable bitrate 3000
-var audioBitrateRegex = regexp.MustCompile(`^(\d+)kbps$`) // 128kbps
+var resolutionRegex = regexp.MustCompile(`^(\d+)x(\d+)$`)  // 1280x720
+var durationRegex = regexp.MustCompile(`^(\d+)s$`)         // 60s
+var crfRegex = regexp.MustCompile(`^(\d+)crf$`)            // constant rate factor 23
+var cbrRegex = regexp.MustCompile(`^(\d+)cbr$`)            // constant bitrate 3000
+var vbrRegex = regexp.MustCompile(`^(\d+)vbr$`)            // variable bitrate 3000
+var audioBitrateRegex = regexp.MustCompile(`^(\d+)kbps$`)  // 128kbps
+var grainRegex = regexp.MustCompile(`^grain=(\d+)$`)       // noise strength grain=20
+var blurRegex = regexp.MustCompile(`^blur=(\d+)$`)         // gaussian blur sigma blur=5
+var pixelateRegex = regexp.MustCompile(`^pixelate=(\d+)$`) // block size pixelate=16
+var slideRegex = regexp.MustCompile(`^slide=(\d+)$`)       // seconds per slide slide=3
+var crossfadeRegex = regexp.MustCompile(`^xfade=(\d+)$`)   // slide crossfade ms xfade=500
 
 var mockSourceFiles []string
 
@@ -28,6 +33,11 @@ func ClearMockSourceFiles() {
 	mockSourceFiles = nil
 }
 
+// SourceNames returns public source video names (without extension) and synthetic sources
+func SourceNames() []string {
+	return getSourceFileNames()
+}
+
 func getSourceFileNames() []string {
 	// Use mock files if available (for testing)
 	if mockSourceFiles != nil {
@@ -42,11 +52,16 @@ func getSourceFileNames() []string {
 			sourceFiles = append(sourceFiles, baseName)
 		}
 	}
-	return sourceFiles
+	return append(sourceFiles, config.SyntheticSources...)
 }
 
-// Example: bunny_av1_1280x720_30fps_60s_23crf_aac_128kbps.mp4
+// Example: bunny_av1_1280x720_30fps_60s_23crf_aac_128kbps.mp4, optional channel layout e.g. _5point1
 func ParseFilename(filename string) (*config.VideoSpec, error) {
+	return ParseFilenameWithSources(filename, getSourceFileNames())
+}
+
+// ParseFilenameWithSources parses filename recognizing sourceFiles as video names, e.g. tenant's own sources
+func ParseFilenameWithSources(filename string, sourceFiles []string) (*config.VideoSpec, error) {
 	// Extract extension/container
 	ext := strings.ToLower(filepath.Ext(filename))
 	if ext != "" {
@@ -57,9 +72,6 @@ func ParseFilename(filename string) (*config.VideoSpec, error) {
 		return nil, fmt.Errorf("invalid container format: %s (valid formats: %v)", ext, config.ValidContainers)
 	}
 
-	// Get source file names (using mocks if available for testing)
-	sourceFiles := getSourceFileNames()
-
 	filename = strings.TrimSuffix(filename, filepath.Ext(filename))
 	parts := strings.Split(filename, "_")
 	params := &config.VideoSpec{}
@@ -109,8 +121,46 @@ func ParseFilename(filename string) (*config.VideoSpec, error) {
 				params.AudioBitrate = audioBitrate
 			}
 
+		case grainRegex.MatchString(part):
+			grainStr := strings.TrimPrefix(part, "grain=")
+			if grain, err := strconv.Atoi(grainStr); err == nil && grain >= 1 && grain <= 100 {
+				params.Grain = grain
+			}
+
+		case blurRegex.MatchString(part):
+			blurStr := strings.TrimPrefix(part, "blur=")
+			if blur, err := strconv.Atoi(blurStr); err == nil && blur >= 1 && blur <= 50 {
+				params.Blur = blur
+			}
+
+		case pixelateRegex.MatchString(part):
+			pixelateStr := strings.TrimPrefix(part, "pixelate=")
+			if pixelate, err := strconv.Atoi(pixelateStr); err == nil && pixelate >= 2 && pixelate <= 128 {
+				params.Pixelate = pixelate
+			}
+
+		case slideRegex.MatchString(part):
+			slideStr := strings.TrimPrefix(part, "slide=")
+			if slide, err := strconv.Atoi(slideStr); err == nil && slide >= 1 && slide <= 60 {
+				params.Slide = slide
+			}
+
+		case crossfadeRegex.MatchString(part):
+			crossfadeStr := strings.TrimPrefix(part, "xfade=")
+			if crossfade, err := strconv.Atoi(crossfadeStr); err == nil && crossfade >= 1 && crossfade <= 60000 {
+				params.Crossfade = crossfade
+			}
+
+		case part == "vr360":
+			params.VR360 = true
+
+		case part == "sbs3d", part == "tb3d":
+			params.Stereo3D = part
+
 		default:
-			if res, ok := config.Resolutions[part]; ok {
+			if _, ok := config.ChannelLayouts[part]; ok {
+				params.Channels = part
+			} else if res, ok := config.Resolutions[part]; ok {
 				params.Width = res.Width
 				params.Height = res.Height
 			} else if slices.Contains(config.ValidVideoCodecs, part) {
@@ -127,6 +177,13 @@ func ParseFilename(filename string) (*config.VideoSpec, error) {
 	return params, nil
 }
 
+// IsSpecToken reports whether part would be parsed as spec parameter (resolution, codec, duration, ...)
+// instead of source name, such names can't be used for sources
+func IsSpecToken(part string) bool {
+	spec, err := ParseFilenameWithSources(part, []string{part})
+	return err != nil || spec.Name != part
+}
+
 // GenerateFilename creates a filename string from VideoSpec
 // Example output: bunny_av1_1280x720_30fps_60s_23crf_aac_128kbps.mp4
 func GenerateFilename(spec *config.VideoSpec) string {
@@ -156,6 +213,35 @@ func GenerateFilename(spec *config.VideoSpec) string {
 		parts = append(parts, spec.Bitrate)
 	}
 
+	if spec.Grain > 0 && spec.Codec != "novideo" {
+		parts = append(parts, fmt.Sprintf("grain=%d", spec.Grain))
+	}
+
+	if spec.Blur > 0 && spec.Codec != "novideo" {
+		parts = append(parts, fmt.Sprintf("blur=%d", spec.Blur))
+	}
+
+	if spec.Pixelate > 0 && spec.Codec != "novideo" {
+		parts = append(parts, fmt.Sprintf("pixelate=%d", spec.Pixelate))
+	}
+
+	// Only slideshow generator reads slide timing, other sources would get duplicate cache file of same video
+	if spec.Slide > 0 && spec.Codec != "novideo" && spec.Name == "slides" {
+		parts = append(parts, fmt.Sprintf("slide=%d", spec.Slide))
+	}
+
+	if spec.Crossfade > 0 && spec.Codec != "novideo" && spec.Name == "slides" {
+		parts = append(parts, fmt.Sprintf("xfade=%d", spec.Crossfade))
+	}
+
+	if spec.VR360 && spec.Codec != "novideo" {
+		parts = append(parts, "vr360")
+	}
+
+	if spec.Stereo3D != "" && spec.Codec != "novideo" {
+		parts = append(parts, spec.Stereo3D)
+	}
+
 	if spec.AudioCodec != "" {
 		parts = append(parts, spec.AudioCodec)
 	}
@@ -164,6 +250,10 @@ func GenerateFilename(spec *config.VideoSpec) string {
 		parts = append(parts, fmt.Sprintf("%dkbps", spec.AudioBitrate))
 	}
 
+	if spec.Channels != "" && spec.AudioCodec != "noaudio" {
+		parts = append(parts, spec.Channels)
+	}
+
 	filename := strings.Join(parts, "_")
 
 	// Add container extension if specified
@@ -177,15 +267,23 @@ func GenerateFilename(spec *config.VideoSpec) string {
 func FindExistingVideo(filename string, spec *config.VideoSpec) string {
 	// Search in video/ pregen folder
 	pregenPath := filepath.Join(config.AppPaths.Video, spec.Name, filename)
-	if _, err := os.Stat(pregenPath); err == nil {
+	if fileindex.Exists(pregenPath) {
 		return pregenPath
 	}
 
 	// Search in tmp folder
 	tmpPath := filepath.Join(config.AppPaths.Tmp, filename)
-	if _, err := os.Stat(tmpPath); err == nil {
+	if fileindex.Exists(tmpPath) {
 		return tmpPath
 	}
 
+	// Search in RAM tmp folder, short encodes not yet persisted to disk
+	if config.AppPaths.TmpRAM != "" {
+		ramPath := filepath.Join(config.AppPaths.TmpRAM, filename)
+		if fileindex.Exists(ramPath) {
+			return ramPath
+		}
+	}
+
 	return ""
 }
@@ -31,6 +31,49 @@ func TestParseFilename(t *testing.T) {
 				AudioBitrate: 128,
 			},
 		},
+		{
+			name:     "with channel layout",
+			filename: "bunny_aac_5point1",
+			want: &config.VideoSpec{
+				Name:       "bunny",
+				AudioCodec: "aac",
+				Channels:   "5point1",
+			},
+		},
+		{
+			name:     "with grain, out of range strength ignored",
+			filename: "bunny_grain=20_grain=500",
+			want: &config.VideoSpec{
+				Name:  "bunny",
+				Grain: 20,
+			},
+		},
+		{
+			name:     "with blur and pixelate",
+			filename: "bunny_blur=5_pixelate=16",
+			want: &config.VideoSpec{
+				Name:     "bunny",
+				Blur:     5,
+				Pixelate: 16,
+			},
+		},
+		{
+			name:     "with vr360",
+			filename: "bunny_vr360.mp4",
+			want: &config.VideoSpec{
+				Name:      "bunny",
+				VR360:     true,
+				Container: "mp4",
+			},
+		},
+		{
+			name:     "with side-by-side 3D",
+			filename: "bunny_sbs3d",
+			want: &config.VideoSpec{
+				Name:     "bunny",
+				Stereo3D: "sbs3d",
+			},
+		},
 		{
 			name:     "with CBR bitrate and mp4 extension",
 			filename: "cat_h264_1920x1080_60fps_30s_5000cbr_opus_192kbps.mp4",
@@ -314,6 +357,16 @@ func TestGenerateFilename(t *testing.T) {
 			},
 			want: "bunny_av1_1280x720_30fps_60s_23crf_aac_128kbps.mp4",
 		},
+		{
+			name: "slide timing of slideshow",
+			spec: &config.VideoSpec{Name: "slides", Duration: 10, Slide: 3, Crossfade: 500, Container: "mp4"},
+			want: "slides_10s_slide=3_xfade=500.mp4",
+		},
+		{
+			name: "slide timing ignored for other sources",
+			spec: &config.VideoSpec{Name: "bunny", Duration: 10, Slide: 3, Crossfade: 500, Container: "mp4"},
+			want: "bunny_10s.mp4",
+		},
 		{
 			name: "webm container",
 			spec: &config.VideoSpec{
@@ -0,0 +1,46 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"lorem.video/internal/abuse"
+)
+
+// SetAbuseDetector enables ban list endpoints, they return 404 while detector is not set
+func (rest *Rest) SetAbuseDetector(detector *abuse.Detector) {
+	rest.abuseDetector = detector
+}
+
+// GetBans lists active auto-bans
+func (rest *Rest) GetBans(w http.ResponseWriter, r *http.Request) {
+	if rest.abuseDetector == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(rest.abuseDetector.Bans())
+}
+
+// ClearBans removes all bans
+func (rest *Rest) ClearBans(w http.ResponseWriter, r *http.Request) {
+	if rest.abuseDetector == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"cleared": rest.abuseDetector.Clear()})
+}
+
+// DeleteBan removes ban of single IP
+func (rest *Rest) DeleteBan(w http.ResponseWriter, r *http.Request) {
+	if rest.abuseDetector == nil || !rest.abuseDetector.Unban(r.PathValue("ip")) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,103 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"lorem.video/internal/alias"
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+)
+
+// SetAliasStore enables /s/{alias} shortlinks and alias admin endpoints, they return 404 while store is not set
+func (rest *Rest) SetAliasStore(store *alias.Store) {
+	rest.aliases = store
+}
+
+// ServeAlias redirects shortlink to its spec, temporary redirect since alias can be re-pointed
+func (rest *Rest) ServeAlias(w http.ResponseWriter, r *http.Request) {
+	if rest.aliases == nil {
+		http.NotFound(w, r)
+		return
+	}
+	target, ok := rest.aliases.Get(r.PathValue("alias"))
+	if !ok {
+		http.Error(w, "Alias not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	http.Redirect(w, r, "/"+target.Spec+keyQuery(r), http.StatusFound)
+}
+
+// GetAliases lists registered aliases
+func (rest *Rest) GetAliases(w http.ResponseWriter, r *http.Request) {
+	if rest.aliases == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(rest.aliases.List())
+}
+
+// PutAlias creates or re-points alias, JSON body {"spec": "bunny_1080p_vp9.webm"}
+func (rest *Rest) PutAlias(w http.ResponseWriter, r *http.Request) {
+	if rest.aliases == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var body struct {
+		Spec string `json:"spec"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	inputParams, err := parser.ParseFilename(body.Spec)
+	if err != nil || *inputParams == (config.VideoSpec{}) {
+		http.Error(w, fmt.Sprintf("invalid spec: %s", body.Spec), http.StatusBadRequest)
+		return
+	}
+	spec := config.ApplyDefaultVideoSpec(inputParams)
+	if _, err := config.FindSourceVideo(spec.Name); err != nil {
+		http.Error(w, fmt.Sprintf("failed to find source video: %s", spec.Name), http.StatusBadRequest)
+		return
+	}
+
+	saved, err := rest.aliases.Set(r.PathValue("alias"), body.Spec)
+	if errors.Is(err, alias.ErrInvalidName) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// DeleteAlias removes alias
+func (rest *Rest) DeleteAlias(w http.ResponseWriter, r *http.Request) {
+	if rest.aliases == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	deleted, err := rest.aliases.Delete(r.PathValue("alias"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !deleted {
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,114 @@
+package rest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lorem.video/internal/audit"
+	"lorem.video/internal/errlog"
+	"lorem.video/internal/stats"
+)
+
+// auditBodyMax keeps request bodies of admin actions small in audit log, they are short JSON anyway
+const auditBodyMax = 4096
+
+// Audited records admin action with actor, path values, query and body to audit log once handler responds
+func (rest *Rest) Audited(action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body []byte
+		if r.Body != nil {
+			body, _ = io.ReadAll(io.LimitReader(r.Body, auditBodyMax))
+			r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+		}
+
+		recorder := &auditRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(recorder, r)
+
+		rest.recordAudit(r, action, auditParams(r), string(body), recorder.status)
+	}
+}
+
+// recordAudit writes admin action of request, for handlers that audit only some outcomes (e.g. finished upload)
+func (rest *Rest) recordAudit(r *http.Request, action string, params map[string]string, body string, status int) {
+	actor := strings.TrimSpace(r.Header.Get("X-Admin-Actor"))
+	if actor == "" {
+		actor = "admin"
+	}
+
+	audit.Record(audit.Entry{
+		RequestID: errlog.RequestFromContext(r.Context()).ID,
+		Actor:     actor,
+		IP:        stats.GetRealIP(r),
+		Action:    action,
+		Params:    params,
+		Body:      body,
+		Status:    status,
+	})
+}
+
+// auditParams collects path values of known admin routes, tus upload headers and query params
+func auditParams(r *http.Request) map[string]string {
+	params := map[string]string{}
+	for _, name := range []string{"ip", "alias", "id"} {
+		if value := r.PathValue(name); value != "" {
+			params[name] = value
+		}
+	}
+	if length := r.Header.Get("Upload-Length"); length != "" {
+		params["length"] = length
+	}
+	if filename := tusMetadata(r.Header.Get("Upload-Metadata"))["filename"]; filename != "" {
+		params["filename"] = filename
+	}
+	for name, values := range r.URL.Query() {
+		params[name] = strings.Join(values, ",")
+	}
+	if len(params) == 0 {
+		return nil
+	}
+	return params
+}
+
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (a *auditRecorder) WriteHeader(status int) {
+	a.status = status
+	a.ResponseWriter.WriteHeader(status)
+}
+
+// GetAudit returns admin actions, newest first. Query params: since (as in /admin/errors, default 7 days), action, limit
+func (rest *Rest) GetAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	since, err := parseSince(query.Get("since"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Get("since") == "" {
+		since = since.Add(-6 * 24 * time.Hour)
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = min(l, 1000)
+	}
+
+	entries, err := audit.Query(since, query.Get("action"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(entries)
+}
@@ -0,0 +1,38 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"lorem.video/internal/config"
+)
+
+// AdminOnly guards handler with ADMIN_TOKEN bearer auth, admin endpoints are disabled when token is not set
+func (rest *Rest) AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.GetAdminToken() == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		if !isAdminRequest(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// isAdminRequest checks Authorization bearer against ADMIN_TOKEN, always false when token is not set
+func isAdminRequest(r *http.Request) bool {
+	token := config.GetAdminToken()
+	if token == "" {
+		return false
+	}
+
+	provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
@@ -0,0 +1,172 @@
+package rest
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+	"lorem.video/internal/service"
+	"lorem.video/internal/tenant"
+)
+
+// batchMaxSpecs limits specs per batch request, every missing one is an FFmpeg encode
+const batchMaxSpecs = 50
+
+// batchErrorsFile lists specs that failed after ZIP streaming started and status can't change anymore
+const batchErrorsFile = "errors.txt"
+
+type batchItem struct {
+	filename string
+	output   string        // Where transcode writes file
+	path     string        // Existing file, empty while generating
+	resultCh <-chan string // Set when transcode was started
+	errCh    <-chan error
+}
+
+// Batch accepts JSON {"specs": ["bunny_720p", "bunny_1080p_vp9.webm"]} and streams ZIP of generated files,
+// missing videos are generated first
+func (rest *Rest) Batch(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Specs []string `json:"specs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(body.Specs) == 0 {
+		http.Error(w, "no specs given", http.StatusBadRequest)
+		return
+	}
+	if len(body.Specs) > batchMaxSpecs {
+		http.Error(w, fmt.Sprintf("too many specs, max %d", batchMaxSpecs), http.StatusBadRequest)
+		return
+	}
+
+	// Validate everything before starting any transcode, same filename is included once
+	requestTenant := tenant.FromContext(r.Context())
+	var specs []config.VideoSpec
+	seen := make(map[string]bool)
+	for _, params := range body.Specs {
+		inputParams, err := requestTenant.ParseFilename(params)
+		if err != nil || *inputParams == (config.VideoSpec{}) {
+			http.Error(w, fmt.Sprintf("invalid spec: %s", params), http.StatusBadRequest)
+			return
+		}
+		spec := config.ApplyDefaultVideoSpec(inputParams)
+		if _, err := requestTenant.FindSourceVideo(spec.Name); err != nil {
+			http.Error(w, fmt.Sprintf("failed to find source video: %s", spec.Name), http.StatusNotFound)
+			return
+		}
+		filename := parser.GenerateFilename(&spec)
+		if !seen[filename] {
+			seen[filename] = true
+			specs = append(specs, spec)
+		}
+	}
+
+	items := make([]batchItem, len(specs))
+	missing := false
+	for i, spec := range specs {
+		items[i].filename = parser.GenerateFilename(&spec)
+		items[i].output = filepath.Join(outputDir(requestTenant, spec), items[i].filename)
+		items[i].path = findServableVideo(requestTenant, items[i].filename, &spec)
+		missing = missing || items[i].path == ""
+	}
+	if missing && (rest.rejectInMaintenance(w, r) || rejectOverEncodeQuota(w, requestTenant)) {
+		return
+	}
+
+	// All missing videos are queued at once, scheduler limits how many encode concurrently.
+	// Transcodes outlive request so files are cached for retry after client disconnect
+	backgroundCtx := context.WithoutCancel(r.Context())
+	for i, spec := range specs {
+		if items[i].path != "" {
+			continue
+		}
+		inputPath, _ := requestTenant.FindSourceVideo(spec.Name)
+		items[i].resultCh, items[i].errCh = rest.videoService.Transcode(backgroundCtx, spec, inputPath, outputDir(requestTenant, spec))
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="lorem-video-batch.zip"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	archive := zip.NewWriter(w)
+	var failed []string
+	for _, item := range items {
+		path := item.path
+		if path == "" {
+			select {
+			case result := <-item.resultCh:
+				path = result
+			case err := <-item.errCh:
+				if !errors.Is(err, service.ErrAlreadyQueued) {
+					failed = append(failed, fmt.Sprintf("%s: %v", item.filename, err))
+					continue
+				}
+				path = item.output // Queued by another request, waited for below
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		// Existing file may still be encoded by another request, partial file must not be zipped
+		if err := service.WaitTranscoded(r.Context(), path); err != nil {
+			if r.Context().Err() != nil {
+				return
+			}
+			failed = append(failed, fmt.Sprintf("%s: %v", item.filename, err))
+			continue
+		}
+
+		if err := addZipFile(archive, path); err != nil {
+			log.Printf("❌ Batch failed to add %s: %v", item.filename, err)
+			return
+		}
+	}
+
+	if len(failed) > 0 {
+		if errorsFile, err := archive.Create(batchErrorsFile); err == nil {
+			fmt.Fprintln(errorsFile, strings.Join(failed, "\n"))
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Printf("❌ Batch failed to finish archive: %v", err)
+	}
+}
+
+// addZipFile stores file without compression, video is already compressed
+func addZipFile(archive *zip.Writer, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := zip.FileInfoHeader(stat)
+	if err != nil {
+		return err
+	}
+	header.Name = filepath.Base(path)
+	header.Method = zip.Store
+
+	entry, err := archive.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	_, err = file.WriteTo(entry)
+	return err
+}
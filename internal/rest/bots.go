@@ -12,7 +12,7 @@ import (
 )
 
 func (rest *Rest) BotsMiddleware(next http.Handler) http.Handler {
-	botLogger, err := stats.NewStatsLogger(config.AppPaths.LogsBots)
+	botLogger, err := stats.NewFileSink(config.AppPaths.LogsBots, 0)
 	if err != nil {
 		log.Printf("Warning: Failed to create bot logger: %v", err)
 	}
@@ -41,6 +41,13 @@ func (rest *Rest) BotsMiddleware(next http.Handler) http.Handler {
 			"/cgi-bin",
 		}
 
+		// Real /admin/ endpoints are reachable only with valid admin token, scanners still get 404.
+		// Browser CORS preflight never carries Authorization, it's answered by CORSMiddleware without reaching handlers
+		if strings.HasPrefix(url, "/admin/") && (r.Method == http.MethodOptions || isAdminRequest(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
 		for _, pattern := range botPatterns {
 			if strings.Contains(url, pattern) {
 				if botLogger != nil {
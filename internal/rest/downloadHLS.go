@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
+)
+
+// DownloadHLS streams pregenerated stream directory (master and media playlists, init and chunks) as tar.gz,
+// media playlists on disk are VOD, so package can be hosted on any static server
+func (r *Rest) DownloadHLS(w http.ResponseWriter, req *http.Request) {
+	videoName := req.PathValue("videoName")
+	videoNameDir := filepath.Join(config.AppPaths.Stream, videoName)
+	if filepath.Dir(videoNameDir) != filepath.Clean(config.AppPaths.Stream) || !fileindex.DirExists(videoNameDir) {
+		http.Error(w, "Video not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gzip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-hls.tar.gz"`, videoName))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	gzipWriter := gzip.NewWriter(w)
+	tarWriter := tar.NewWriter(gzipWriter)
+	// Entries are prefixed with video name so archive extracts into its own directory
+	err := filepath.WalkDir(videoNameDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(config.AppPaths.Stream, path)
+		if err != nil {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(relPath)
+		if entry.IsDir() {
+			header.Name += "/"
+		}
+		if err := tarWriter.WriteHeader(header); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.CopyN(tarWriter, file, header.Size)
+		return err
+	})
+	if err != nil {
+		// Headers are already sent, truncated archive fails to extract on client
+		log.Printf("❌ Failed to archive %s: %v", videoName, err)
+		return
+	}
+
+	if err := tarWriter.Close(); err != nil {
+		log.Printf("❌ Failed to archive %s: %v", videoName, err)
+		return
+	}
+	gzipWriter.Close()
+}
@@ -1,31 +1,44 @@
 package rest
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
-	"path/filepath"
+	"regexp"
 	"runtime/debug"
+	"strconv"
 	"time"
 
-	"lorem.video/internal/config"
+	"lorem.video/internal/errlog"
+	"lorem.video/internal/service"
 )
 
-// logError writes error message to both stdout and error log file
-func logError(msg string) {
-	// Log to stdout as usual
-	log.Print(msg)
+// requestIDRegex limits inbound X-Request-ID, it's echoed in response and written to logs
+var requestIDRegex = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
 
-	// Also write to error log file
-	date := time.Now().Format("2006-01-02")
-	errorLogPath := filepath.Join(config.AppPaths.LogsErrors, fmt.Sprintf("error-%s.log", date))
+// RequestIDMiddleware assigns request ID (reusing X-Request-ID from proxy) so error log entries can be matched to requests
+func (rest *Rest) RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get("X-Request-ID")
+		if !requestIDRegex.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", requestID)
 
-	errorFile, err := os.OpenFile(errorLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
-	if err == nil {
-		defer errorFile.Close()
-		errorFile.WriteString(time.Now().Format("2006/01/02 15:04:05") + " " + msg + "\n")
-	}
+		ctx := errlog.WithRequest(r.Context(), errlog.RequestInfo{
+			ID:    requestID,
+			Route: r.Method + " " + r.URL.Path,
+		})
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 // RecoveryMiddleware catches panics and logs them with detailed information
@@ -33,12 +46,72 @@ func (rest *Rest) RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				errorMsg := fmt.Sprintf("PANIC RECOVERED: %v\nRequest: %s %s\nRemote: %s\nUser-Agent: %s\nStack Trace:\n%s",
-					err, r.Method, r.URL.Path, r.RemoteAddr, r.UserAgent(), debug.Stack())
-				logError(errorMsg)
+				if err == http.ErrAbortHandler {
+					panic(err) // Deliberate abort of streamed response, net/http closes connection quietly
+				}
+				errlog.Record(r.Context(), errlog.Entry{
+					Message: fmt.Sprintf("PANIC RECOVERED: %v (remote %s, user agent %s)", err, r.RemoteAddr, r.UserAgent()),
+					Stack:   string(debug.Stack()),
+				})
 				http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			}
 		}()
 		next.ServeHTTP(w, r)
 	})
 }
+
+// GetErrors returns recent error log entries, newest first. Query params: since (RFC3339, YYYY-MM-DD or duration like 6h, default 24h), limit
+func (rest *Rest) GetErrors(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	since, err := parseSince(query.Get("since"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	limit := 100
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 {
+		limit = min(l, 1000)
+	}
+
+	entries, err := errlog.Query(since, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(entries)
+}
+
+// GetJobLog returns full FFmpeg output (command, stderr, progress, exit status) of latest transcode of job,
+// job ID is output filename ({tenant}~{filename} for tenants), see "job" in transcoding response and error log entries
+func (rest *Rest) GetJobLog(w http.ResponseWriter, r *http.Request) {
+	path, err := service.JobLogPath(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	http.ServeFile(w, r, path)
+}
+
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now().Add(-24 * time.Hour), nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid since: %s (use RFC3339, YYYY-MM-DD or duration like 6h)", value)
+}
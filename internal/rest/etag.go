@@ -0,0 +1,17 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// setETag sets strong validator from modification time and size, so http.ServeFile answers If-None-Match and
+// resumes ranges on If-Range. File still being written changes size, so stale ranges get full body instead
+func setETag(w http.ResponseWriter, path string) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%x-%x"`, stat.ModTime().UnixNano(), stat.Size()))
+}
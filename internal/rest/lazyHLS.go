@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/service"
+	"lorem.video/internal/tenant"
+)
+
+// serveLazyHLS serves HLS of source without pregenerated ladder, segments are encoded on first request and cached
+func (r *Rest) serveLazyHLS(w http.ResponseWriter, req *http.Request, videoName, path string) {
+	requestTenant := tenant.FromContext(req.Context())
+	sourcePath, err := requestTenant.FindSourceVideo(videoName)
+	if err != nil {
+		http.Error(w, "Video not found", http.StatusNotFound)
+		return
+	}
+	stream, err := service.OpenLazyHLS(videoName, sourcePath)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// /bunny/playlist.m3u8
+	if path == config.HLSMasterPlaylist {
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.Header().Set("Cache-Control", "no-cache")
+		http.ServeFile(w, req, stream.MasterPlaylist())
+		return
+	}
+
+	resolutionKey, filename, _ := strings.Cut(path, "/")
+	if !stream.HasResolution(resolutionKey) {
+		http.Error(w, "Resolution not found", http.StatusNotFound)
+		return
+	}
+
+	// /bunny/720p/media.m3u8, all full seconds of source are looped
+	if filename == config.HLSMediaPlaylist {
+		time.Sleep(100 * time.Millisecond) // artificial delay to avoid Chrome hammering requests
+		w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Write([]byte(generateMediaPlaylist(stream.Segments)))
+		return
+	}
+
+	// /bunny/720p/init.mp4 or /bunny/720p/media.1679654321.mp4
+	segment := -1
+	if filename != config.HLSInit {
+		hlsSeqStr, ok := strings.CutPrefix(filename, "media.")
+		hlsSeq, err := strconv.ParseInt(strings.TrimSuffix(hlsSeqStr, ".mp4"), 10, 64)
+		if !ok || !strings.HasSuffix(filename, ".mp4") || err != nil || hlsSeq < 0 {
+			http.Error(w, "No hls found", http.StatusNotFound)
+			return
+		}
+		segment = int(hlsSeq % int64(stream.Segments))
+	}
+
+	if !stream.Cached(resolutionKey, segment) && (r.rejectInMaintenance(w, req) || rejectOverEncodeQuota(w, requestTenant)) {
+		return
+	}
+	segmentPath, err := stream.Segment(req.Context(), resolutionKey, segment)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "video/mp4")
+	w.Header().Set("Accept-Ranges", "bytes")
+	if requestTenant != nil {
+		w.Header().Set("Cache-Control", "private, max-age=3600") // Tenant source shadows public one of same name
+	} else {
+		w.Header().Set("Cache-Control", "public, max-age=3600") // Cached segments can be evicted and encoded again
+	}
+	setETag(w, segmentPath)
+	http.ServeFile(w, req, segmentPath)
+}
@@ -0,0 +1,27 @@
+package rest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// BodyLimitMiddleware caps request body size, reading past limit fails and the handler can respond 413.
+// Paths with unlimitedPrefixes (resumable uploads) enforce their own size
+func (rest *Rest) BodyLimitMiddleware(maxBytes int64, unlimitedPrefixes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, prefix := range unlimitedPrefixes {
+				if strings.HasPrefix(r.URL.Path, prefix) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+			if r.ContentLength > maxBytes {
+				http.Error(w, "Request Entity Too Large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultMaintenanceMessage = "Video generation is temporarily paused for maintenance. Cached videos are still available, please retry in a few minutes."
+
+// MaintenanceStatus is returned by admin endpoint, generation endpoints respond 503 while enabled
+type MaintenanceStatus struct {
+	Enabled bool      `json:"enabled"`
+	Message string    `json:"message,omitempty"`
+	Since   time.Time `json:"since,omitzero"`
+}
+
+// maintenanceStatus returns current state, disabled when never toggled
+func (rest *Rest) maintenanceStatus() MaintenanceStatus {
+	if status := rest.maintenance.Load(); status != nil {
+		return *status
+	}
+	return MaintenanceStatus{}
+}
+
+// InMaintenance reports whether generation is paused, for background encoders outside request handling
+func (rest *Rest) InMaintenance() bool {
+	return rest.maintenanceStatus().Enabled
+}
+
+// rejectInMaintenance writes 503 and reports true when new generation is paused
+func (rest *Rest) rejectInMaintenance(w http.ResponseWriter, r *http.Request) bool {
+	status := rest.maintenanceStatus()
+	if !status.Enabled {
+		return false
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Retry-After", "300")
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "<!DOCTYPE html><html><head><title>Maintenance - lorem.video</title></head><body><h1>Maintenance</h1><p>%s</p></body></html>",
+			html.EscapeString(status.Message))
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusServiceUnavailable)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":      "maintenance",
+		"message":     status.Message,
+		"retry_after": "300",
+	})
+	return true
+}
+
+// GetMaintenance returns maintenance mode state
+func (rest *Rest) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(rest.maintenanceStatus())
+}
+
+// EnableMaintenance pauses generation endpoints, optional JSON body {"message": "..."} overrides default message
+func (rest *Rest) EnableMaintenance(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Message string `json:"message"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, fmt.Sprintf("invalid body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if body.Message == "" {
+		body.Message = defaultMaintenanceMessage
+	}
+
+	status := MaintenanceStatus{Enabled: true, Message: body.Message, Since: time.Now()}
+	rest.maintenance.Store(&status)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// DisableMaintenance resumes generation endpoints
+func (rest *Rest) DisableMaintenance(w http.ResponseWriter, r *http.Request) {
+	rest.maintenance.Store(nil)
+	w.WriteHeader(http.StatusNoContent)
+}
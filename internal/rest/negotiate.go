@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mileusna/useragent"
+
+	"lorem.video/internal/config"
+)
+
+// negotiateContainer fills container of spec without file extension: webm when Accept prefers video/webm over
+// video/mp4 and requested codecs fit webm, otherwise mp4 which plays everywhere.
+// Safari stays on mp4 since its WebM support is incomplete
+func negotiateContainer(r *http.Request, params *config.VideoSpec) {
+	accept := r.Header.Get("Accept")
+	if acceptQuality(accept, "video/webm") <= acceptQuality(accept, "video/mp4") {
+		return
+	}
+	codecs := config.ContainerCodecs["webm"]
+	if params.Codec != "" && params.Codec != "novideo" && !slices.Contains(codecs.Video, params.Codec) {
+		return
+	}
+	if params.AudioCodec != "" && params.AudioCodec != "noaudio" && !slices.Contains(codecs.Audio, params.AudioCodec) {
+		return
+	}
+	if useragent.Parse(r.UserAgent()).IsSafari() {
+		return
+	}
+
+	params.Container = "webm"
+	if params.Codec == "" {
+		params.Codec = codecs.Video[0]
+	}
+	if params.AudioCodec == "" {
+		params.AudioCodec = codecs.Audio[0]
+	}
+}
+
+// acceptQuality returns q value of most specific Accept entry matching mediaType, 0 when not acceptable
+func acceptQuality(accept, mediaType string) float64 {
+	if accept == "" {
+		return 1
+	}
+
+	quality, specificity := 0.0, -1
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	for _, entry := range strings.Split(accept, ",") {
+		rangeType, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		rangeType = strings.ToLower(strings.TrimSpace(rangeType))
+
+		var matched int
+		switch rangeType {
+		case mediaType:
+			matched = 2
+		case mainType + "/*":
+			matched = 1
+		case "*/*":
+			matched = 0
+		default:
+			continue
+		}
+		if matched <= specificity {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		quality, specificity = q, matched
+	}
+	return quality
+}
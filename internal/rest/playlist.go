@@ -0,0 +1,59 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+	"lorem.video/internal/tenant"
+)
+
+// playlistMaxSpecs limits entries per playlist
+const playlistMaxSpecs = 100
+
+// ServePlaylist returns extended M3U referencing comma separated specs, e.g. /playlist.m3u?specs=bunny_480p,bunny_720p_vp9.webm.
+// Missing videos are generated by ServeVideo once player requests them
+func (rest *Rest) ServePlaylist(w http.ResponseWriter, r *http.Request) {
+	requestTenant := tenant.FromContext(r.Context())
+	var specs []config.VideoSpec
+	for _, params := range strings.Split(r.URL.Query().Get("specs"), ",") {
+		params = strings.TrimSpace(params)
+		if params == "" {
+			continue
+		}
+		inputParams, err := requestTenant.ParseFilename(params)
+		if err != nil || *inputParams == (config.VideoSpec{}) {
+			http.Error(w, fmt.Sprintf("invalid spec: %s", params), http.StatusBadRequest)
+			return
+		}
+		spec := config.ApplyDefaultVideoSpec(inputParams)
+		if _, err := requestTenant.FindSourceVideo(spec.Name); err != nil {
+			http.Error(w, fmt.Sprintf("failed to find source video: %s", spec.Name), http.StatusNotFound)
+			return
+		}
+		specs = append(specs, spec)
+	}
+	if len(specs) == 0 {
+		http.Error(w, "no specs given", http.StatusBadRequest)
+		return
+	}
+	if len(specs) > playlistMaxSpecs {
+		http.Error(w, fmt.Sprintf("too many specs, max %d", playlistMaxSpecs), http.StatusBadRequest)
+		return
+	}
+
+	baseURL := config.GetBaseURL()
+	var playlist strings.Builder
+	playlist.WriteString("#EXTM3U\n")
+	for _, spec := range specs {
+		filename := parser.GenerateFilename(&spec)
+		fmt.Fprintf(&playlist, "#EXTINF:%d,%s\n%s/%s%s\n", spec.Duration, filename, baseURL, filename, keyQuery(r))
+	}
+
+	w.Header().Set("Content-Type", "audio/x-mpegurl")
+	w.Header().Set("Content-Disposition", `inline; filename="playlist.m3u"`)
+	w.Header().Set("Cache-Control", "public, max-age=3600")
+	w.Write([]byte(playlist.String()))
+}
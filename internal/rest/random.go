@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+	"lorem.video/internal/service"
+	"lorem.video/internal/tenant"
+)
+
+// RandomSpecHeader carries filename of spec picked by /random
+const RandomSpecHeader = "X-Random-Spec"
+
+// Random redirects to random valid spec, query params container, codec, maxres (720p or WxH) and name narrow the pick.
+// Redirect keeps chosen video cacheable and retries after 202 hit same spec
+func (rest *Rest) Random(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	constraints := service.RandomConstraints{
+		Name:      query.Get("name"),
+		Container: query.Get("container"),
+		Codec:     query.Get("codec"),
+	}
+	if constraints.Container != "" && !slices.Contains(config.ValidContainers, constraints.Container) {
+		http.Error(w, fmt.Sprintf("invalid container: %s", constraints.Container), http.StatusBadRequest)
+		return
+	}
+	if maxRes := query.Get("maxres"); maxRes != "" {
+		res, err := config.ParseResolution(maxRes)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		constraints.MaxHeight = res.Height
+	}
+	if constraints.Name != "" {
+		if _, err := tenant.FromContext(r.Context()).FindSourceVideo(constraints.Name); err != nil {
+			http.Error(w, fmt.Sprintf("failed to find source video: %s", constraints.Name), http.StatusNotFound)
+			return
+		}
+	}
+
+	spec, err := service.RandomSpec(rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())), constraints)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filename := parser.GenerateFilename(&spec)
+
+	w.Header().Set(RandomSpecHeader, filename)
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, "/"+filename+keyQuery(r), http.StatusFound)
+}
@@ -3,22 +3,38 @@ package rest
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"lorem.video/internal/abuse"
+	"lorem.video/internal/alias"
 	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
 	"lorem.video/internal/parser"
 	"lorem.video/internal/service"
+	"lorem.video/internal/stats"
+	"lorem.video/internal/tenant"
+	"lorem.video/internal/upload"
 )
 
 type Rest struct {
-	videoService *service.VideoService
-	appVersion   string // Cache-busting version generated at startup
+	videoService  *service.VideoService
+	appVersion    string           // Cache-busting version generated at startup
+	abuseDetector *abuse.Detector  // Optional, see SetAbuseDetector
+	aliases       *alias.Store     // Optional, see SetAliasStore
+	tenants       *tenant.Registry // Optional, see SetTenantRegistry
+	uploads       *upload.Store    // Optional, see SetUploadStore
+	geoDB         *stats.GeoDB     // Optional, see SetGeoDB
+	sourcePolicy  service.SourcePolicy
+	maintenance   atomic.Pointer[MaintenanceStatus]
 }
 
 func New() *Rest {
@@ -71,6 +87,10 @@ func (rest *Rest) GetVideoInfo(w http.ResponseWriter, r *http.Request) {
 }
 
 func (rest *Rest) Transcode(w http.ResponseWriter, r *http.Request) {
+	if rest.rejectInMaintenance(w, r) {
+		return
+	}
+
 	params := r.PathValue("params")
 	resultCh, errCh := rest.videoService.TranscodeFromParams(r.Context(), params)
 
@@ -87,7 +107,8 @@ func (rest *Rest) Transcode(w http.ResponseWriter, r *http.Request) {
 
 func (rest *Rest) ServeVideo(w http.ResponseWriter, r *http.Request) {
 	params := r.PathValue("params")
-	inputParams, err := parser.ParseFilename(params)
+	requestTenant := tenant.FromContext(r.Context())
+	inputParams, err := requestTenant.ParseFilename(params)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to parse filename parameters: %v", err), http.StatusBadRequest)
 		return
@@ -98,47 +119,82 @@ func (rest *Rest) ServeVideo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Without extension same URL serves different files depending on request headers
+	if inputParams.Container == "" {
+		w.Header().Add("Vary", "Accept, User-Agent")
+		negotiateContainer(r, inputParams)
+	}
+
 	spec := config.ApplyDefaultVideoSpec(inputParams)
 	filename := parser.GenerateFilename(&spec)
 
 	// Check for existing video
-	existingPath := parser.FindExistingVideo(filename, &spec)
+	existingPath := findServableVideo(requestTenant, filename, &spec)
 	if existingPath != "" {
+		cacheSource := stats.CacheTmp
+		if strings.HasPrefix(existingPath, config.AppPaths.Video) {
+			cacheSource = stats.CachePregen
+		}
+		stats.RecordCache(cacheSource)
+		service.RecordTmpHit(existingPath)
+		w.Header().Set(stats.CacheHeader, cacheSource)
+
 		ext := strings.TrimPrefix(filepath.Ext(existingPath), ".")
 		w.Header().Set("Accept-Ranges", "bytes")
 		w.Header().Set("Content-Type", "video/"+ext)
 
-		// Check if video is less than 5 minutes old to avoid caching partial transcodes
-		if stat, err := os.Stat(existingPath); err == nil {
-			fileAge := time.Since(stat.ModTime())
-			if fileAge < 5*time.Minute {
-				// Video is recent, might be partial - don't cache
-				w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-				w.Header().Set("Pragma", "no-cache")
-				w.Header().Set("Expires", "0")
-			} else {
-				// Video is older, safe to cache
-				w.Header().Set("Cache-Control", "public, max-age=3600") // 1 hour cache
-			}
+		// Partial (still transcoding) and degraded files must not be cached. Pregenerated file is fully determined
+		// by its spec filename and never rewritten, tmp files can be evicted and regenerated
+		if degradation, ok := service.DegradedFile(existingPath); ok {
+			w.Header().Set(service.DegradedHeader, degradation.String())
+			w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+		} else if service.Transcoding(existingPath) {
+			w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+			w.Header().Set("Pragma", "no-cache")
+			w.Header().Set("Expires", "0")
+		} else if requestTenant != nil {
+			// Same URL serves tenant's private source when key is sent as header, shared caches must not keep it
+			w.Header().Set("Cache-Control", "private, max-age=3600")
+		} else if cacheSource == stats.CachePregen {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable") // 1 year
+		} else {
+			w.Header().Set("Cache-Control", "public, max-age=3600") // 1 hour cache
 		}
 
+		// Plain download of partial file follows it until FFmpeg is done, range and HEAD requests get current snapshot
+		if service.Transcoding(existingPath) && service.FollowWhileTranscoding(spec) &&
+			r.Method == http.MethodGet && r.Header.Get("Range") == "" {
+			streamGrowingFile(w, r, existingPath)
+			return
+		}
+
+		setETag(w, existingPath)
 		http.ServeFile(w, r, existingPath)
 		return
 	}
 
 	// Video not found, start transcoding and tell client to retry
+	if rest.rejectInMaintenance(w, r) || rejectOverEncodeQuota(w, requestTenant) {
+		return
+	}
 	log.Printf("Starting transcoding for: %s", filename)
 
-	// TODO hardcoded .mp4 extension for source video. should be improved later
-	inputPath := filepath.Join(config.AppPaths.SourceVideo, spec.Name+".mp4")
-	if _, err := os.Stat(inputPath); err != nil {
+	inputPath, err := requestTenant.FindSourceVideo(spec.Name)
+	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to find source video: %s", spec.Name), http.StatusNotFound)
 		return
 	}
 
-	// Start transcoding in background
-	backgroundCtx := context.Background()
-	_, _ = rest.videoService.Transcode(backgroundCtx, spec, inputPath, config.AppPaths.Tmp)
+	stats.RecordCache(stats.CacheGenerate)
+	w.Header().Set(stats.CacheHeader, stats.CacheGenerate)
+
+	// Start transcoding in background, detached from request cancellation but keeping request ID for error log
+	backgroundCtx := context.WithoutCancel(r.Context())
+	if degradation := service.DegradationFor(spec); degradation.Degraded() {
+		backgroundCtx = service.WithDegradation(backgroundCtx, degradation)
+		w.Header().Set(service.DegradedHeader, degradation.String())
+	}
+	_, _ = rest.videoService.Transcode(backgroundCtx, spec, inputPath, outputDir(requestTenant, spec))
 
 	// Return 202 Accepted with retry instructions
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -150,5 +206,22 @@ func (rest *Rest) ServeVideo(w http.ResponseWriter, r *http.Request) {
 		"status":      "transcoding",
 		"message":     "Video is being generated. Please retry this URL in a few moments.",
 		"retry_after": "5",
+		"job":         service.JobID(r.Context(), filename),
 	})
 }
+
+// findServableVideo drops index entries of files deleted outside server (cleanup CLI, manual deletes) since last
+// index refresh, so such video is generated again instead of 404
+func findServableVideo(requestTenant *tenant.Tenant, filename string, spec *config.VideoSpec) string {
+	for range 3 { // Tenant and public cache can both be stale
+		path := requestTenant.FindExistingVideo(filename, spec)
+		if path == "" {
+			return ""
+		}
+		if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			return path
+		}
+		fileindex.Remove(path)
+	}
+	return ""
+}
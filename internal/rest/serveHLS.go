@@ -3,13 +3,13 @@ package rest
 import (
 	"fmt"
 	"net/http"
-	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
 
 	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
 )
 
 func (r *Rest) ServeHLS(w http.ResponseWriter, req *http.Request) {
@@ -24,8 +24,8 @@ func (r *Rest) ServeHLS(w http.ResponseWriter, req *http.Request) {
 	}
 
 	videoNameDir := filepath.Join(config.AppPaths.Stream, videoName)
-	if _, err := os.Stat(videoNameDir); os.IsNotExist(err) {
-		http.Error(w, "Video not found", http.StatusNotFound)
+	if !fileindex.DirExists(videoNameDir) {
+		r.serveLazyHLS(w, req, videoName, path)
 		return
 	}
 
@@ -43,21 +43,13 @@ func (r *Rest) ServeHLS(w http.ResponseWriter, req *http.Request) {
 	if strings.HasSuffix(path, "/"+config.HLSMediaPlaylist) {
 		resolutionKey := strings.TrimSuffix(path, "/"+config.HLSMediaPlaylist)
 		resolutionDir := filepath.Join(videoNameDir, resolutionKey)
-		if stat, err := os.Stat(resolutionDir); err != nil || !stat.IsDir() {
+		if !fileindex.DirExists(resolutionDir) {
 			http.Error(w, "Resolution not found", http.StatusNotFound)
 			return
 		}
 
-		// TODO maybe better pattern match from config.HLSChunkFormat
-		chunkPattern := filepath.Join(resolutionDir, "chunk_*.mp4")
-		matches, err := filepath.Glob(chunkPattern)
-		if err != nil {
-			http.Error(w, "Error reading chunks", http.StatusInternalServerError)
-			return
-		}
-
 		// IMPORTANT: exclude last segment as it may not be full second and wouldn't loop infinitely
-		chunkCount := len(matches) - 1
+		chunkCount := fileindex.ChunkCount(resolutionDir) - 1
 
 		if chunkCount < 1 {
 			http.Error(w, "No chunks found", http.StatusNotFound)
@@ -77,7 +69,8 @@ func (r *Rest) ServeHLS(w http.ResponseWriter, req *http.Request) {
 	if strings.HasSuffix(path, "/"+config.HLSInit) {
 		w.Header().Set("Content-Type", "video/mp4")
 		w.Header().Set("Accept-Ranges", "bytes")
-		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable") // Pregenerated, never rewritten
+		setETag(w, fullPath)
 		http.ServeFile(w, req, fullPath)
 		return
 	}
@@ -87,21 +80,13 @@ func (r *Rest) ServeHLS(w http.ResponseWriter, req *http.Request) {
 	if strings.HasPrefix(filename, "media.") && strings.HasSuffix(filename, ".mp4") {
 		resolutionKey := strings.TrimSuffix(path, "/"+filename)
 		resolutionDir := filepath.Join(videoNameDir, resolutionKey)
-		if stat, err := os.Stat(resolutionDir); err != nil || !stat.IsDir() {
+		if !fileindex.DirExists(resolutionDir) {
 			http.Error(w, "Resolution not found", http.StatusNotFound)
 			return
 		}
 
-		// TODO maybe better pattern match from config.HLSChunkFormat
-		chunkPattern := filepath.Join(resolutionDir, "chunk_*.mp4")
-		matches, err := filepath.Glob(chunkPattern)
-		if err != nil {
-			http.Error(w, "Error reading chunks", http.StatusInternalServerError)
-			return
-		}
-
 		// IMPORTANT: exclude last segment as it may not be full second and wouldn't loop infinitely
-		chunkCount := len(matches) - 1
+		chunkCount := fileindex.ChunkCount(resolutionDir) - 1
 
 		if chunkCount < 1 {
 			http.Error(w, "No chunks found", http.StatusNotFound)
@@ -121,6 +106,8 @@ func (r *Rest) ServeHLS(w http.ResponseWriter, req *http.Request) {
 
 		w.Header().Set("Content-Type", "video/mp4")
 		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable") // Sequence maps to same chunk while stream exists
+		setETag(w, chunkFile)
 		http.ServeFile(w, req, chunkFile)
 		return
 	}
@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+	"lorem.video/internal/tenant"
+)
+
+// simulateMaxHeaders limits ?headers= entries per request
+const simulateMaxHeaders = 20
+
+var headerNameRegex = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+
+// simulateReservedHeaders are framing headers that would break the response if overridden
+var simulateReservedHeaders = map[string]bool{
+	"Content-Length":    true,
+	"Transfer-Encoding": true,
+	"Connection":        true,
+}
+
+// Simulate responds with status code from path for testing player/SDK error handling, e.g.
+// /simulate/503?headers=Retry-After:30&headers=X-Reason:overload. Optional ?video=spec sends cached video as body
+func (rest *Rest) Simulate(w http.ResponseWriter, r *http.Request) {
+	code, err := strconv.Atoi(r.PathValue("code"))
+	if err != nil || code < 200 || code > 599 {
+		http.Error(w, "status code must be 200-599", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	headers := query["headers"]
+	if len(headers) > simulateMaxHeaders {
+		http.Error(w, fmt.Sprintf("too many headers, max %d", simulateMaxHeaders), http.StatusBadRequest)
+		return
+	}
+	var names []string
+	for _, header := range headers {
+		name, value, ok := strings.Cut(header, ":")
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if !ok || !headerNameRegex.MatchString(name) || simulateReservedHeaders[name] || strings.ContainsAny(value, "\r\n") {
+			http.Error(w, fmt.Sprintf("invalid header: %s", header), http.StatusBadRequest)
+			return
+		}
+		w.Header().Add(name, strings.TrimSpace(value))
+		names = append(names, name)
+	}
+
+	var videoPath string
+	if videoParams := query.Get("video"); videoParams != "" {
+		requestTenant := tenant.FromContext(r.Context())
+		inputParams, err := requestTenant.ParseFilename(videoParams)
+		if err != nil || *inputParams == (config.VideoSpec{}) {
+			http.Error(w, fmt.Sprintf("invalid video spec: %s", videoParams), http.StatusBadRequest)
+			return
+		}
+		spec := config.ApplyDefaultVideoSpec(inputParams)
+		videoPath = findServableVideo(requestTenant, parser.GenerateFilename(&spec), &spec)
+		if videoPath == "" {
+			http.Error(w, "video body must be generated already, request it first", http.StatusNotFound)
+			return
+		}
+	}
+
+	// Browser SDKs can only read non-safelisted headers (e.g. Retry-After) when exposed
+	if len(names) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(names, ", "))
+	}
+	w.Header().Set("Cache-Control", "no-store")
+
+	switch {
+	case code == http.StatusNoContent || code == http.StatusNotModified:
+		w.WriteHeader(code)
+	case videoPath != "":
+		// Whole file with simulated status, ranges aren't supported
+		file, err := os.Open(videoPath)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer file.Close()
+		w.Header().Set("Content-Type", "video/"+strings.TrimPrefix(filepath.Ext(videoPath), "."))
+		w.WriteHeader(code)
+		io.Copy(w, file)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(map[string]any{"status": code, "message": http.StatusText(code)})
+	}
+}
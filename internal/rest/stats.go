@@ -0,0 +1,65 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/stats"
+)
+
+// GetRuntimeStats returns live gauges (open requests, running FFmpeg processes)
+func (rest *Rest) GetRuntimeStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := json.NewEncoder(w).Encode(stats.GetRuntimeStats()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// SetGeoDB enables ASN org names and lookup of records logged without GeoIP data in country stats
+func (rest *Rest) SetGeoDB(geoDB *stats.GeoDB) {
+	rest.geoDB = geoDB
+}
+
+// GetCountryStats returns country/ASN breakdown, query params: min-date, max-date (YYYY-MM-DD), top
+func (rest *Rest) GetCountryStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	minDate := query.Get("min-date")
+	if minDate == "" {
+		minDate = time.Now().AddDate(0, 0, -7).Format("2006-01-02")
+	}
+
+	topN := 50
+	if top, err := strconv.Atoi(query.Get("top")); err == nil && top > 0 {
+		topN = top
+	}
+
+	// Records are tagged with country/ASN at log time when GeoIP data is configured, org names come from GeoDB
+	result, err := stats.AnalyzeStats(stats.AnalyzerConfig{
+		GeoDB:              rest.geoDB,
+		LogDir:             config.AppPaths.LogsStats,
+		ExcludeStaticPaths: true,
+		MinDate:            minDate,
+		MaxDate:            query.Get("max-date"),
+		UseSummaries:       true,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	json.NewEncoder(w).Encode(map[string]any{
+		"dateRange": result.DateRange,
+		"countries": result.Countries[:min(topN, len(result.Countries))],
+		"asns":      result.ASNs[:min(topN, len(result.ASNs))],
+	})
+}
@@ -0,0 +1,21 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"lorem.video/internal/service"
+)
+
+// GetStorage returns per-directory disk usage, free space and cache hit ratio
+func (rest *Rest) GetStorage(w http.ResponseWriter, r *http.Request) {
+	report, err := service.GetStorageReport()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"lorem.video/internal/service"
+	"lorem.video/internal/stats"
+)
+
+const (
+	streamChunkSize    = 64 << 10
+	streamPollInterval = 200 * time.Millisecond
+)
+
+// streamGrowingFile sends file FFmpeg is still writing and follows it until transcode ends. Next chunk is read only
+// after client connection accepted previous one, so disk reads go at client speed instead of FFmpeg's output rate
+func streamGrowingFile(w http.ResponseWriter, r *http.Request, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		http.Error(w, "video not available", http.StatusNotFound)
+		return
+	}
+	defer file.Close()
+
+	// Final size unknown, response is chunked
+	w.Header().Del("Accept-Ranges")
+	w.WriteHeader(http.StatusOK)
+	controller := http.NewResponseController(w)
+
+	buf := make([]byte, streamChunkSize)
+	var sent int64
+	var blocked time.Duration // Time spent waiting for client, excludes waiting for FFmpeg
+	defer func() { stats.RecordThroughput(r.Context(), sent, blocked) }()
+	follower := service.FollowStream(path)
+	defer follower.Stop()
+
+	for {
+		// Checked before read, so EOF after transcode ended is end of complete file
+		finished := !service.Transcoding(path)
+
+		n, err := file.Read(buf)
+		if n > 0 {
+			writeStart := time.Now()
+			if _, err := w.Write(buf[:n]); err != nil {
+				return
+			}
+			if err := controller.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return
+			}
+			blocked += time.Since(writeStart)
+			sent += int64(n)
+
+			if info, err := file.Stat(); err == nil && !finished {
+				follower.ReportBacklog(info.Size() - sent)
+			}
+		}
+
+		if err == io.EOF {
+			if finished {
+				// Failed transcode removes its partial output, abort so client doesn't take truncated file as complete
+				if _, statErr := os.Stat(path); statErr != nil {
+					panic(http.ErrAbortHandler)
+				}
+				return
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(streamPollInterval):
+			}
+		} else if err != nil {
+			panic(http.ErrAbortHandler)
+		}
+	}
+}
@@ -54,6 +54,7 @@ func (rest *Rest) ServeDocumentation(w http.ResponseWriter, r *http.Request) {
 			sourceVideoNames = append(sourceVideoNames, name)
 		}
 	}
+	sourceVideoNames = append(sourceVideoNames, config.SyntheticSources...)
 
 	data := TemplateData{
 		Domain:       "lorem.video",
@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/service"
+	"lorem.video/internal/tenant"
+)
+
+// SetTenantRegistry enables API key namespaces, requests without key use public namespace
+func (rest *Rest) SetTenantRegistry(registry *tenant.Registry) {
+	rest.tenants = registry
+}
+
+// TenantMiddleware puts tenant of API key into request context, unknown key is rejected
+func (rest *Rest) TenantMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rest.tenants == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Response depends on API key header, also public one so shared cache doesn't give it to tenant
+		w.Header().Add("Vary", tenant.KeyHeader)
+
+		requestTenant, err := rest.tenants.FromRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if requestTenant != nil {
+			if err := requestTenant.CheckEgressQuota(); err != nil {
+				w.Header().Set("Retry-After", fmt.Sprintf("%.0f", time.Until(requestTenant.QuotaReset()).Seconds()))
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			r = r.WithContext(tenant.WithTenant(r.Context(), requestTenant))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rejectOverEncodeQuota writes 402 and reports true when tenant can't start new transcodes this month
+func rejectOverEncodeQuota(w http.ResponseWriter, requestTenant *tenant.Tenant) bool {
+	if err := requestTenant.CheckEncodeQuota(); err != nil {
+		http.Error(w, err.Error()+", cached videos are still served", http.StatusPaymentRequired)
+		return true
+	}
+	return false
+}
+
+// GetUsage returns current month usage and caps of request's tenant, admin gets all tenants
+func (rest *Rest) GetUsage(w http.ResponseWriter, r *http.Request) {
+	if rest.tenants == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	var tenants []*tenant.Tenant
+	if requestTenant := tenant.FromContext(r.Context()); requestTenant != nil {
+		tenants = []*tenant.Tenant{requestTenant}
+	} else if isAdminRequest(r) {
+		tenants = rest.tenants.Tenants()
+	} else {
+		http.Error(w, "API key required", http.StatusUnauthorized)
+		return
+	}
+
+	type usageReport struct {
+		Tenant               string    `json:"tenant"`
+		Month                string    `json:"month"`
+		EncodeSeconds        float64   `json:"encodeSeconds"`
+		EgressBytes          int64     `json:"egressBytes"`
+		MonthlyEncodeSeconds int64     `json:"monthlyEncodeSeconds,omitempty"`
+		MonthlyEgressBytes   int64     `json:"monthlyEgressBytes,omitempty"`
+		ResetsAt             time.Time `json:"resetsAt"`
+	}
+	reports := make([]usageReport, 0, len(tenants))
+	for _, t := range tenants {
+		usage := t.Usage()
+		reports = append(reports, usageReport{
+			Tenant:               t.Name,
+			Month:                usage.Month,
+			EncodeSeconds:        usage.EncodeSeconds,
+			EgressBytes:          usage.EgressBytes,
+			MonthlyEncodeSeconds: t.MonthlyEncodeSeconds,
+			MonthlyEgressBytes:   t.MonthlyEgressBytes,
+			ResetsAt:             t.QuotaReset(),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(reports)
+}
+
+// outputDir returns where new video of spec is generated, tenant cache is trimmed to quota first
+func outputDir(requestTenant *tenant.Tenant, spec config.VideoSpec) string {
+	if requestTenant == nil {
+		return service.TmpOutputDir(spec)
+	}
+	requestTenant.EnforceQuota(service.Transcoding)
+	return requestTenant.CacheDir()
+}
+
+// keyQuery carries ?key= over to generated URLs, header keys are sent again by client anyway
+func keyQuery(r *http.Request) string {
+	if key := r.URL.Query().Get(tenant.KeyQueryParam); key != "" {
+		return "?" + tenant.KeyQueryParam + "=" + url.QueryEscape(key)
+	}
+	return ""
+}
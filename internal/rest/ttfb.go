@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ttfbMax caps ?ttfb= delay, held requests only cost a goroutine but shouldn't hang forever
+const ttfbMax = 60 * time.Second
+
+// TTFBMiddleware delays response headers by ?ttfb= (duration like 500ms or 5s, plain number is seconds) to simulate slow server,
+// body is then sent at full speed
+func (rest *Rest) TTFBMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value := r.URL.Query().Get("ttfb")
+		if value == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		delay, err := parseTTFB(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			next.ServeHTTP(w, r)
+		case <-r.Context().Done():
+		}
+	})
+}
+
+func parseTTFB(value string) (time.Duration, error) {
+	delay, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, numErr := strconv.ParseFloat(value, 64)
+		if numErr != nil {
+			return 0, fmt.Errorf("invalid ttfb: %s", value)
+		}
+		delay = time.Duration(seconds * float64(time.Second))
+	}
+	if delay < 0 || delay > ttfbMax {
+		return 0, fmt.Errorf("ttfb must be between 0 and %s", ttfbMax)
+	}
+	return delay, nil
+}
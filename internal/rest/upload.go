@@ -0,0 +1,262 @@
+package rest
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/service"
+	"lorem.video/internal/upload"
+)
+
+const tusVersion = "1.0.0"
+
+// tusExposedHeaders are read by browser tus clients from cross-origin responses
+const tusExposedHeaders = "Location, Upload-Offset, Upload-Length, Upload-Expires, Tus-Resumable, Tus-Version, Tus-Extension, Tus-Max-Size"
+
+// SetUploadStore enables resumable source video uploads, endpoints return 404 while store is not set.
+// Complete uploads are validated against policy before they become sources
+func (rest *Rest) SetUploadStore(store *upload.Store, policy service.SourcePolicy) {
+	rest.uploads = store
+	rest.sourcePolicy = policy
+}
+
+// tusRequest sets tus response headers and rejects requests of other protocol versions with 412
+func (rest *Rest) tusRequest(w http.ResponseWriter, r *http.Request) bool {
+	if rest.uploads == nil {
+		http.NotFound(w, r)
+		return false
+	}
+
+	w.Header().Set("Tus-Resumable", tusVersion)
+	w.Header().Set("Tus-Version", tusVersion)
+	w.Header().Set("Tus-Extension", "creation,termination,expiration")
+	w.Header().Set("Tus-Max-Size", strconv.FormatInt(rest.uploads.MaxBytes(), 10))
+	w.Header().Set("Access-Control-Expose-Headers", tusExposedHeaders)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		http.Error(w, "Tus-Resumable: "+tusVersion+" required", http.StatusPreconditionFailed)
+		return false
+	}
+	return true
+}
+
+// CreateUpload starts resumable upload (tus creation), headers Upload-Length and Upload-Metadata with base64 "filename"
+func (rest *Rest) CreateUpload(w http.ResponseWriter, r *http.Request) {
+	if !rest.tusRequest(w, r) {
+		return
+	}
+
+	length, err := strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid Upload-Length", http.StatusBadRequest)
+		return
+	}
+	filename := tusMetadata(r.Header.Get("Upload-Metadata"))["filename"]
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if _, err := config.FindSourceVideo(name); err == nil {
+		http.Error(w, fmt.Sprintf("source video already exists: %s", name), http.StatusConflict)
+		return
+	}
+
+	created, err := rest.uploads.Create(filename, length)
+	switch {
+	case errors.Is(err, upload.ErrInvalidName):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, upload.ErrTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/admin/uploads/"+created.ID)
+	w.Header().Set("Upload-Expires", created.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GetUploadOffset reports received bytes (tus HEAD), client resumes PATCH from Upload-Offset
+func (rest *Rest) GetUploadOffset(w http.ResponseWriter, r *http.Request) {
+	if !rest.tusRequest(w, r) {
+		return
+	}
+
+	current, err := rest.uploads.Get(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(current.Offset, 10))
+	w.Header().Set("Upload-Length", strconv.FormatInt(current.Length, 10))
+	w.Header().Set("Upload-Expires", current.ExpiresAt.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+}
+
+// PatchUpload appends body at Upload-Offset, completed upload is moved to source video dir
+func (rest *Rest) PatchUpload(w http.ResponseWriter, r *http.Request) {
+	if !rest.tusRequest(w, r) {
+		return
+	}
+	if r.Header.Get("Content-Type") != "application/offset+octet-stream" {
+		http.Error(w, "Content-Type must be application/offset+octet-stream", http.StatusUnsupportedMediaType)
+		return
+	}
+	offset, err := strconv.ParseInt(r.Header.Get("Upload-Offset"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid Upload-Offset", http.StatusBadRequest)
+		return
+	}
+
+	id := r.PathValue("id")
+	current, err := rest.uploads.Append(id, offset, r.Body)
+	switch {
+	case errors.Is(err, upload.ErrNotFound):
+		http.NotFound(w, r)
+		return
+	case errors.Is(err, upload.ErrOffsetMismatch), errors.Is(err, upload.ErrBusy):
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	case err != nil:
+		// Connection dropped mid chunk, received part is kept and reported by HEAD
+		log.Printf("Upload %s interrupted at %d/%d bytes: %v", id, current.Offset, current.Length, err)
+		http.Error(w, "upload interrupted, resume from HEAD Upload-Offset", http.StatusInternalServerError)
+		return
+	}
+
+	if current.Complete() && current.Status == upload.StatusUploading {
+		if _, err := rest.uploads.SetStatus(id, upload.StatusValidating, nil, nil); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		// Decode check of multi-GB file outlasts request, result is at GET /admin/uploads/{id}
+		go rest.validateUpload(r.Clone(context.WithoutCancel(r.Context())), current)
+	}
+
+	w.Header().Set("Upload-Offset", strconv.FormatInt(current.Offset, 10))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetUpload returns upload state including validation status, problems and warnings
+func (rest *Rest) GetUpload(w http.ResponseWriter, r *http.Request) {
+	if rest.uploads == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	current, err := rest.uploads.Get(r.PathValue("id"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(current)
+}
+
+// validateUpload probes and decodes complete upload, moves it to source dir or marks it rejected
+func (rest *Rest) validateUpload(r *http.Request, current upload.Upload) {
+	report := service.ValidateSource(r.Context(), rest.uploads.DataPath(current.ID), rest.sourcePolicy)
+
+	name := strings.TrimSuffix(current.Filename, filepath.Ext(current.Filename))
+	if _, err := config.FindSourceVideo(name); err == nil {
+		report.Problems = append(report.Problems, "source video with same name was added meanwhile")
+	}
+
+	params := map[string]string{"id": current.ID, "filename": current.Filename, "bytes": strconv.FormatInt(current.Length, 10)}
+	if report.Rejected() {
+		rest.uploads.SetStatus(current.ID, upload.StatusRejected, report.Problems, report.Warnings)
+		params["problems"] = strings.Join(report.Problems, "; ")
+		rest.recordAudit(r, "source.reject", params, "", http.StatusUnprocessableEntity)
+		log.Printf("Source video upload %s rejected: %s", current.Filename, params["problems"])
+		return
+	}
+
+	if rest.sourcePolicy.Normalize {
+		rest.normalizeUpload(r, current, name, report, params)
+		return
+	}
+
+	path, err := rest.uploads.Finish(current.ID, config.AppPaths.SourceVideo)
+	if err != nil {
+		rest.uploads.SetStatus(current.ID, upload.StatusRejected, []string{err.Error()}, report.Warnings)
+		log.Printf("Failed to move upload %s to sources: %v", current.Filename, err)
+		return
+	}
+	if len(report.Warnings) > 0 {
+		params["warnings"] = strings.Join(report.Warnings, "; ")
+	}
+	rest.recordAudit(r, "source.upload", params, "", http.StatusCreated)
+	log.Printf("Source video uploaded: %s (%dx%d %s, %.1fs)", path, report.Width, report.Height, report.Video, report.Duration)
+}
+
+// normalizeUpload re-encodes validated upload to mezzanine name.mp4 in source dir, original upload is discarded
+func (rest *Rest) normalizeUpload(r *http.Request, current upload.Upload, name string, report service.SourceReport, params map[string]string) {
+	rest.uploads.SetStatus(current.ID, upload.StatusNormalizing, nil, report.Warnings)
+
+	// Encoded next to upload data and moved once complete, so half written mezzanine is never a source
+	normalized := rest.uploads.DataPath(current.ID) + ".mp4"
+	if err := service.NormalizeSource(r.Context(), name, rest.uploads.DataPath(current.ID), normalized, report.Audio != ""); err != nil {
+		os.Remove(normalized)
+		rest.uploads.SetStatus(current.ID, upload.StatusRejected, []string{err.Error()}, report.Warnings)
+		params["problems"] = err.Error()
+		rest.recordAudit(r, "source.reject", params, "", http.StatusUnprocessableEntity)
+		return
+	}
+
+	path := filepath.Join(config.AppPaths.SourceVideo, name+".mp4")
+	if err := os.Rename(normalized, path); err != nil {
+		os.Remove(normalized)
+		rest.uploads.SetStatus(current.ID, upload.StatusRejected, []string{err.Error()}, report.Warnings)
+		log.Printf("Failed to move normalized upload %s to sources: %v", current.Filename, err)
+		return
+	}
+	rest.uploads.Delete(current.ID)
+
+	params["normalized"] = name + ".mp4"
+	if len(report.Warnings) > 0 {
+		params["warnings"] = strings.Join(report.Warnings, "; ")
+	}
+	rest.recordAudit(r, "source.upload", params, "", http.StatusCreated)
+	log.Printf("Source video uploaded and normalized: %s", path)
+}
+
+// DeleteUpload cancels unfinished upload (tus termination)
+func (rest *Rest) DeleteUpload(w http.ResponseWriter, r *http.Request) {
+	if !rest.tusRequest(w, r) {
+		return
+	}
+
+	if err := rest.uploads.Delete(r.PathValue("id")); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tusMetadata decodes Upload-Metadata, comma separated "key base64value" pairs
+func tusMetadata(header string) map[string]string {
+	metadata := map[string]string{}
+	for _, pair := range strings.Split(header, ",") {
+		key, encoded, _ := strings.Cut(strings.TrimSpace(pair), " ")
+		value, err := base64.StdEncoding.DecodeString(encoded)
+		if key == "" || err != nil {
+			continue
+		}
+		metadata[key] = string(value)
+	}
+	return metadata
+}
@@ -0,0 +1,26 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/service"
+)
+
+// VersionInfo identifies running server and FFmpeg for bug reports and deployment audits
+type VersionInfo struct {
+	config.Build
+	FFmpeg service.FFmpegInfo `json:"ffmpeg"`
+}
+
+// GetVersion returns server version, commit, build date, Go version and FFmpeg version with available encoders
+func (rest *Rest) GetVersion(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	info := VersionInfo{Build: config.GetBuild(), FFmpeg: service.GetFFmpegInfo()}
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
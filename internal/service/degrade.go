@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
+	"lorem.video/internal/stats"
+)
+
+// DegradedHeader advertises reduced quality of on-the-fly encode, e.g. "preset=fast; max-height=720"
+const DegradedHeader = "X-Degraded"
+
+// DegradePolicy lowers encode cost while running plus queued transcodes are at or above MaxActive, disabled when MaxActive is 0
+type DegradePolicy struct {
+	MaxActive int64
+	MaxHeight int           // Resolution cap for degraded encodes, 0 keeps requested resolution
+	TTL       time.Duration // Degraded files are deleted after TTL so next request gets full quality
+}
+
+func DegradePolicyFromEnv() DegradePolicy {
+	policy := DegradePolicy{
+		MaxActive: config.GetEnvInt64("TRANSCODE_DEGRADE_AT"),
+		MaxHeight: int(config.GetEnvInt64("TRANSCODE_DEGRADE_MAX_HEIGHT")),
+		TTL:       config.GetEnvDuration("TRANSCODE_DEGRADE_TTL", 10*time.Minute),
+	}
+	if os.Getenv("TRANSCODE_DEGRADE_MAX_HEIGHT") == "" {
+		policy.MaxHeight = 720
+	}
+	return policy
+}
+
+func (p DegradePolicy) Enabled() bool {
+	return p.MaxActive > 0
+}
+
+// Degradation describes how encode differs from requested spec
+type Degradation struct {
+	FastPreset bool
+	MaxHeight  int
+}
+
+func (d Degradation) Degraded() bool {
+	return d.FastPreset || d.MaxHeight > 0
+}
+
+// String is DegradedHeader value
+func (d Degradation) String() string {
+	var parts []string
+	if d.FastPreset {
+		parts = append(parts, "preset=fast")
+	}
+	if d.MaxHeight > 0 {
+		parts = append(parts, fmt.Sprintf("max-height=%d", d.MaxHeight))
+	}
+	return strings.Join(parts, "; ")
+}
+
+var degrade struct {
+	mutex  sync.Mutex
+	policy DegradePolicy
+	files  map[string]Degradation // Degraded output path -> degradation, until TTL expires
+}
+
+func SetDegradePolicy(policy DegradePolicy) {
+	degrade.mutex.Lock()
+	defer degrade.mutex.Unlock()
+	degrade.policy = policy
+	degrade.files = make(map[string]Degradation)
+}
+
+// DegradationFor returns degradation for new on-the-fly encode of spec based on current load
+func DegradationFor(spec config.VideoSpec) Degradation {
+	degrade.mutex.Lock()
+	policy := degrade.policy
+	degrade.mutex.Unlock()
+
+	runtimeStats := stats.GetRuntimeStats()
+	if !policy.Enabled() || runtimeStats.ActiveTranscodes+runtimeStats.QueuedTranscodes < policy.MaxActive {
+		return Degradation{}
+	}
+
+	degradation := Degradation{FastPreset: true}
+	if policy.MaxHeight > 0 && spec.Height > policy.MaxHeight {
+		degradation.MaxHeight = policy.MaxHeight
+	}
+	return degradation
+}
+
+// DegradedFile reports whether existing file was generated degraded
+func DegradedFile(path string) (Degradation, bool) {
+	degrade.mutex.Lock()
+	defer degrade.mutex.Unlock()
+	degradation, ok := degrade.files[path]
+	return degradation, ok
+}
+
+// degradedMarkerExt is sidecar next to degraded output, so degradation and its TTL survive restarts
+const degradedMarkerExt = ".degraded"
+
+// trackDegradedFile writes marker and schedules removal of degraded output once TTL expires
+func trackDegradedFile(path string, degradation Degradation) {
+	data, _ := json.Marshal(degradation)
+	if err := os.WriteFile(path+degradedMarkerExt, data, 0644); err != nil {
+		log.Printf("Warning: Failed to write degraded marker of %s: %v", filepath.Base(path), err)
+	}
+	scheduleDegradedRemoval(path, degradation, degradeTTL())
+}
+
+// RestoreDegradedFiles picks up degraded outputs left by previous run under roots, markers without video are removed
+func RestoreDegradedFiles(roots ...string) {
+	ttl := degradeTTL()
+	for _, root := range roots {
+		filepath.WalkDir(root, func(markerPath string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(markerPath, degradedMarkerExt) {
+				return nil
+			}
+			path := strings.TrimSuffix(markerPath, degradedMarkerExt)
+			info, statErr := d.Info()
+			data, readErr := os.ReadFile(markerPath)
+			var degradation Degradation
+			if _, videoErr := os.Stat(path); videoErr != nil || statErr != nil || readErr != nil || json.Unmarshal(data, &degradation) != nil {
+				os.Remove(markerPath)
+				return nil
+			}
+			scheduleDegradedRemoval(path, degradation, max(ttl-time.Since(info.ModTime()), 0))
+			return nil
+		})
+	}
+}
+
+func degradeTTL() time.Duration {
+	degrade.mutex.Lock()
+	defer degrade.mutex.Unlock()
+	if degrade.policy.TTL <= 0 {
+		return 10 * time.Minute
+	}
+	return degrade.policy.TTL
+}
+
+func scheduleDegradedRemoval(path string, degradation Degradation, ttl time.Duration) {
+	degrade.mutex.Lock()
+	if degrade.files == nil {
+		degrade.files = make(map[string]Degradation)
+	}
+	degrade.files[path] = degradation
+	degrade.mutex.Unlock()
+
+	time.AfterFunc(ttl, func() {
+		degrade.mutex.Lock()
+		delete(degrade.files, path)
+		degrade.mutex.Unlock()
+
+		fileindex.Remove(path)
+		os.Remove(path)
+		os.Remove(path + degradedMarkerExt)
+	})
+}
+
+type degradationKey struct{}
+
+// WithDegradation makes Transcode apply degradation
+func WithDegradation(ctx context.Context, degradation Degradation) context.Context {
+	return context.WithValue(ctx, degradationKey{}, degradation)
+}
+
+func degradationFromContext(ctx context.Context) Degradation {
+	degradation, _ := ctx.Value(degradationKey{}).(Degradation)
+	return degradation
+}
+
+// degradedSize scales width proportionally to height cap, rounded to even for yuv420
+func degradedSize(width, height, maxHeight int) (int, int) {
+	if maxHeight <= 0 || height <= maxHeight {
+		return width, height
+	}
+	scaled := width * maxHeight / height
+	return scaled - scaled%2, maxHeight
+}
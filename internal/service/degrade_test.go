@@ -0,0 +1,26 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRestoreDegradedFiles(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "bunny_720p.mp4")
+	orphan := filepath.Join(dir, "evicted.mp4") + degradedMarkerExt
+	os.WriteFile(video, []byte("video"), 0644)
+	os.WriteFile(video+degradedMarkerExt, []byte(`{"FastPreset":true,"MaxHeight":720}`), 0644)
+	os.WriteFile(orphan, []byte(`{"FastPreset":true}`), 0644)
+
+	RestoreDegradedFiles(dir)
+
+	degradation, ok := DegradedFile(video)
+	if !ok || degradation.String() != "preset=fast; max-height=720" {
+		t.Errorf("DegradedFile = %+v, %v after restart", degradation, ok)
+	}
+	if _, err := os.Stat(orphan); !os.IsNotExist(err) {
+		t.Error("marker of deleted video kept")
+	}
+}
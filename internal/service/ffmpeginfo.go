@@ -0,0 +1,77 @@
+package service
+
+import (
+	"bufio"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// knownEncoders are software encoders used for specs and hardware encoders worth reporting, in display order
+var knownEncoders = []string{
+	"libx264", "libx265", "libvpx-vp9", "libsvtav1", "libaom-av1", "librav1e", "aac", "libopus", "libvorbis",
+	"h264_nvenc", "hevc_nvenc", "av1_nvenc",
+	"h264_qsv", "hevc_qsv", "av1_qsv", "vp9_qsv",
+	"h264_vaapi", "hevc_vaapi", "av1_vaapi", "vp9_vaapi",
+	"h264_videotoolbox", "hevc_videotoolbox",
+	"h264_amf", "hevc_amf", "av1_amf",
+}
+
+// FFmpegInfo is FFmpeg build found on host
+type FFmpegInfo struct {
+	Version  string   `json:"version,omitempty"`
+	Encoders []string `json:"encoders,omitempty"` // Available knownEncoders, hardware ones may still fail without device
+	Error    string   `json:"error,omitempty"`
+}
+
+var (
+	ffmpegInfo     FFmpegInfo
+	ffmpegInfoOnce sync.Once
+)
+
+// GetFFmpegInfo detects FFmpeg version and encoders once, binary doesn't change while server runs
+func GetFFmpegInfo() FFmpegInfo {
+	ffmpegInfoOnce.Do(func() {
+		versionOutput, err := exec.Command("ffmpeg", "-hide_banner", "-version").Output()
+		if err != nil {
+			ffmpegInfo.Error = err.Error()
+			return
+		}
+		ffmpegInfo.Version = parseFFmpegVersion(string(versionOutput))
+
+		encodersOutput, err := exec.Command("ffmpeg", "-hide_banner", "-encoders").Output()
+		if err != nil {
+			ffmpegInfo.Error = err.Error()
+			return
+		}
+		ffmpegInfo.Encoders = parseEncoders(string(encodersOutput))
+	})
+	return ffmpegInfo
+}
+
+// parseFFmpegVersion takes version from "ffmpeg version 6.1.1 Copyright (c) 2000-2023 ..."
+func parseFFmpegVersion(output string) string {
+	firstLine, _, _ := strings.Cut(output, "\n")
+	fields := strings.Fields(firstLine)
+	if len(fields) >= 3 && fields[0] == "ffmpeg" && fields[1] == "version" {
+		return fields[2]
+	}
+	return strings.TrimSpace(firstLine)
+}
+
+// parseEncoders returns knownEncoders listed by "ffmpeg -encoders", lines like " V....D libx264  libx264 H.264 ..."
+func parseEncoders(output string) []string {
+	available := map[string]bool{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && len(fields[0]) == 6 {
+			available[fields[1]] = true
+		}
+	}
+
+	return slices.DeleteFunc(slices.Clone(knownEncoders), func(name string) bool {
+		return !available[name]
+	})
+}
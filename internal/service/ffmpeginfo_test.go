@@ -0,0 +1,25 @@
+package service
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFFmpegOutput(t *testing.T) {
+	if got := parseFFmpegVersion("ffmpeg version 6.1.1 Copyright (c) 2000-2023 the FFmpeg developers\nbuilt with gcc 13.2.1\n"); got != "6.1.1" {
+		t.Errorf("unexpected version: %s", got)
+	}
+
+	encoders := `Encoders:
+ V..... = Video
+ ------
+ V....D libx264              libx264 H.264 / AVC / MPEG-4 AVC / MPEG-4 part 10 (codec h264)
+ V....D h264_nvenc           NVIDIA NVENC H.264 encoder (codec h264)
+ V....D libvpx-vp9           libvpx VP9 (codec vp9)
+ A....D aac                  AAC (Advanced Audio Coding)
+ V....D mpeg4                MPEG-4 part 2
+`
+	if got := parseEncoders(encoders); !slices.Equal(got, []string{"libx264", "libvpx-vp9", "aac", "h264_nvenc"}) {
+		t.Errorf("unexpected encoders: %v", got)
+	}
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+)
+
+// generator returns FFmpeg input args rendering synthetic source for spec, see config.SyntheticSources
+type generator func(spec config.VideoSpec) []string
+
+var generators = map[string]generator{
+	"avsync":   avSyncInput,
+	"clock":    clockInput,
+	"channels": channelsInput,
+	"stress":   stressInput,
+	"slides":   slidesInput,
+	"bars":     barsInput,
+	"testsrc":  testsrcInput,
+	"noise":    noiseInput,
+	"black":    blackInput,
+}
+
+// syntheticInputArgs returns generator input args when inputPath is synthetic source
+func syntheticInputArgs(inputPath string, spec config.VideoSpec) ([]string, bool, error) {
+	name, ok := strings.CutPrefix(inputPath, config.SyntheticSourcePrefix)
+	if !ok {
+		return nil, false, nil
+	}
+
+	generate, ok := generators[name]
+	if !ok {
+		return nil, true, fmt.Errorf("unknown synthetic source: %s", name)
+	}
+	return generate(spec), true, nil
+}
+
+// avSyncInput flashes white frame and beeps 1kHz at start of every second, both last same whole number of frames.
+// Frame and sample numbers are used instead of t to avoid float rounding at second boundaries
+func avSyncInput(spec config.VideoSpec) []string {
+	const sampleRate = 48000
+	fps := max(spec.FPS, 1)
+	flashFrames := int(math.Ceil(0.1 * float64(fps))) // ~100ms
+	beepSamples := flashFrames * sampleRate / fps
+
+	video := fmt.Sprintf("color=c=black:size=%dx%d:rate=%d,drawbox=x=0:y=0:w=iw:h=ih:color=white:t=fill:enable='lt(mod(n,%d),%d)'",
+		spec.Width, spec.Height, fps, fps, flashFrames)
+	audio := fmt.Sprintf("aevalsrc='if(lt(mod(n,%d),%d),sin(2*PI*1000*t),0)':s=%d:c=stereo",
+		sampleRate, beepSamples, sampleRate)
+
+	return []string{"-f", "lavfi", "-i", video, "-f", "lavfi", "-i", audio}
+}
+
+// clockInput prints spec, frame index and wall-clock time (generation start + frame pts, ms precision) on every frame
+func clockInput(spec config.VideoSpec) []string {
+	fontSize := max(spec.Height/12, 12)
+	startedAt := time.Now().Unix()
+
+	lines := []string{
+		parser.GenerateFilename(&spec),
+		"frame %{n}",
+		fmt.Sprintf("%%{pts\\:gmtime\\:%d} UTC", startedAt),
+		"+%{pts\\:hms}",
+	}
+
+	filters := []string{fmt.Sprintf("color=c=black:size=%dx%d:rate=%d", spec.Width, spec.Height, max(spec.FPS, 1))}
+	for i, line := range lines {
+		filters = append(filters, fmt.Sprintf("drawtext=font=monospace:fontcolor=white:fontsize=%d:x=(w-text_w)/2:y=h/2+(%d-2)*%d:text='%s'",
+			fontSize, i, fontSize*3/2, line))
+	}
+
+	return []string{"-f", "lavfi", "-i", strings.Join(filters, ","), "-f", "lavfi", "-i", "anullsrc=r=48000:cl=stereo"}
+}
+
+type channelInfo struct {
+	name      string
+	frequency int // Hz
+}
+
+// channelOrder lists channels of config.ChannelLayouts in FFmpeg order
+var channelOrder = map[string][]channelInfo{
+	"mono":   {{"center", 440}},
+	"stereo": {{"left", 440}, {"right", 550}},
+	"quad":   {{"left", 440}, {"right", 550}, {"back left", 660}, {"back right", 770}},
+	"5.1":    {{"left", 440}, {"right", 550}, {"center", 660}, {"LFE", 60}, {"back left", 770}, {"back right", 880}},
+	"7.1":    {{"left", 440}, {"right", 550}, {"center", 660}, {"LFE", 60}, {"back left", 770}, {"back right", 880}, {"side left", 990}, {"side right", 1100}},
+}
+
+// channelsInput plays tone on one channel per second in layout order while channel name is shown on screen
+func channelsInput(spec config.VideoSpec) []string {
+	layout, ok := config.ChannelLayouts[spec.Channels]
+	if !ok {
+		layout = "stereo"
+	}
+	channels := channelOrder[layout]
+	count := len(channels)
+
+	fontSize := max(spec.Height/8, 12)
+	filters := []string{fmt.Sprintf("color=c=black:size=%dx%d:rate=%d", spec.Width, spec.Height, max(spec.FPS, 1))}
+	var tones []string
+	for i, channel := range channels {
+		active := fmt.Sprintf("eq(mod(floor(t),%d),%d)", count, i)
+		filters = append(filters, fmt.Sprintf("drawtext=font=monospace:fontcolor=white:fontsize=%d:x=(w-text_w)/2:y=(h-text_h)/2:text='%s':enable='%s'",
+			fontSize, channel.name, active))
+		tones = append(tones, fmt.Sprintf("if(%s*lt(mod(t,1),0.8),sin(2*PI*%d*t),0)", active, channel.frequency))
+	}
+
+	audio := fmt.Sprintf("aevalsrc='%s':s=48000:c=%s", strings.Join(tones, "|"), layout)
+	return []string{"-f", "lavfi", "-i", strings.Join(filters, ","), "-f", "lavfi", "-i", audio}
+}
+
+// stressInput blends chaotic cellular automaton over testsrc2 with temporal noise, hue jumps and inversion
+// cut scene 4 times per second, so every frame carries lots of new information
+func stressInput(spec config.VideoSpec) []string {
+	size := fmt.Sprintf("%dx%d", spec.Width, spec.Height)
+	rate := max(spec.FPS, 1)
+
+	video := fmt.Sprintf("testsrc2=size=%s:rate=%d,format=yuv420p[base];"+
+		"cellauto=size=%s:rate=%d:rule=30:random_fill_ratio=0.5,format=yuv420p[detail];"+
+		"[base][detail]blend=all_mode=difference,"+
+		"noise=alls=40:allf=t+u,"+
+		"hue=H='2*PI*0.618*floor(t*4)':s='1+mod(floor(t*4),3)',"+
+		"negate=enable='eq(mod(floor(t*4),2),1)'",
+		size, rate, size, rate)
+
+	return []string{"-f", "lavfi", "-i", video, "-f", "lavfi", "-i", "anoisesrc=color=white:sample_rate=48000:amplitude=0.3"}
+}
+
+// slideDefault is seconds per slide when spec has no slide=N
+const slideDefault = 2
+
+// slidesMaxChained is most slides built as separate filtergraph nodes, longer specs use slidesLoopInput
+const slidesMaxChained = 60
+
+// slidesInput chains numbered color cards, crossfade (capped to slide length) overlaps consecutive slides
+func slidesInput(spec config.VideoSpec) []string {
+	slide := spec.Slide
+	if slide <= 0 {
+		slide = slideDefault
+	}
+	fade := min(float64(spec.Crossfade)/1000, float64(slide))
+	count := max(int(math.Ceil(float64(spec.Duration)/float64(slide))), 1)
+	fontSize := max(spec.Height/3, 12)
+	if count > slidesMaxChained {
+		return slidesLoopInput(spec, slide, fade, fontSize)
+	}
+
+	var graph []string
+	for i := range count {
+		// Each slide but last is extended by crossfade, it's shown under next slide while fading out
+		duration := float64(slide)
+		if i < count-1 {
+			duration += fade
+		}
+		graph = append(graph, fmt.Sprintf("color=c=%s:size=%dx%d:rate=%d:duration=%g,drawtext=font=monospace:fontcolor=white:borderw=4:fontsize=%d:x=(w-text_w)/2:y=(h-text_h)/2:text='%d'[s%d]",
+			slideColor(i), spec.Width, spec.Height, max(spec.FPS, 1), duration, fontSize, i+1, i))
+	}
+
+	last := "s0"
+	if fade > 0 {
+		for i := 1; i < count; i++ {
+			graph = append(graph, fmt.Sprintf("[%s][s%d]xfade=transition=fade:duration=%g:offset=%d[x%d]", last, i, fade, i*slide, i))
+			last = fmt.Sprintf("x%d", i)
+		}
+	} else if count > 1 {
+		var inputs strings.Builder
+		for i := range count {
+			fmt.Fprintf(&inputs, "[s%d]", i)
+		}
+		graph = append(graph, fmt.Sprintf("%sconcat=n=%d:v=1:a=0[cut]", inputs.String(), count))
+		last = "cut"
+	}
+	graph = append(graph, fmt.Sprintf("[%s]null", last))
+
+	return []string{"-f", "lavfi", "-i", strings.Join(graph, ";"), "-f", "lavfi", "-i", "anullsrc=r=48000:cl=stereo"}
+}
+
+// slidesLoopInput renders slides from single color source whose hue and number follow floor(t/slide), so filtergraph
+// size doesn't grow with duration. Crossfade blends in same source one slide behind
+func slidesLoopInput(spec config.VideoSpec, slide int, fade float64, fontSize int) []string {
+	card := func(offset int) string {
+		index := fmt.Sprintf("(floor(t/%d)%+d)", slide, offset)
+		return fmt.Sprintf("color=c=red:size=%dx%d:rate=%d,hue=H='2*PI*0.618033988749895*%s',drawtext=font=monospace:fontcolor=white:borderw=4:fontsize=%d:x=(w-text_w)/2:y=(h-text_h)/2:text='%%{eif\\:%s+1\\:d}'",
+			spec.Width, spec.Height, max(spec.FPS, 1), index, fontSize, index)
+	}
+
+	video := card(0)
+	if fade > 0 {
+		// First input is current slide, second previous one, mixed during first fade seconds of each slide but first
+		video = fmt.Sprintf("%s[current];%s[previous];[current][previous]blend=all_expr='if(lt(T,%d),A,B+(A-B)*min(mod(T,%d)/%g,1))'",
+			card(0), card(-1), slide, slide, fade)
+	}
+	return []string{"-f", "lavfi", "-i", video, "-f", "lavfi", "-i", "anullsrc=r=48000:cl=stereo"}
+}
+
+// referenceToneLevel is -18 dBFS (EBU R68 alignment level) as linear amplitude
+var referenceToneLevel = math.Pow(10, -18.0/20)
+
+// barsInput renders EBU 75% bars (100/0/75/0) in limited range BT.709 with continuous 1kHz tone on all channels
+func barsInput(spec config.VideoSpec) []string {
+	video := fmt.Sprintf("pal75bars=size=%dx%d:rate=%d,setparams=range=tv:color_primaries=bt709:color_trc=bt709:colorspace=bt709",
+		spec.Width, spec.Height, max(spec.FPS, 1))
+	audio := fmt.Sprintf("aevalsrc='%.6f*sin(2*PI*1000*t)':s=48000:c=stereo", referenceToneLevel)
+	return []string{"-f", "lavfi", "-i", video, "-f", "lavfi", "-i", audio}
+}
+
+// testsrcInput is FFmpeg's testsrc2 pattern (moving gradient, timecode) with 440Hz tone, same as default bunny source
+func testsrcInput(spec config.VideoSpec) []string {
+	video := fmt.Sprintf("testsrc2=size=%dx%d:rate=%d", spec.Width, spec.Height, max(spec.FPS, 1))
+	return []string{"-f", "lavfi", "-i", video, "-f", "lavfi", "-i", "sine=frequency=440:sample_rate=48000"}
+}
+
+// noiseInput is full-frame temporal luma and chroma noise with pink noise audio, worst case for any encoder
+func noiseInput(spec config.VideoSpec) []string {
+	video := fmt.Sprintf("color=c=gray:size=%dx%d:rate=%d,format=yuv420p,noise=alls=100:allf=t",
+		spec.Width, spec.Height, max(spec.FPS, 1))
+	return []string{"-f", "lavfi", "-i", video, "-f", "lavfi", "-i", "anoisesrc=color=pink:sample_rate=48000:amplitude=0.3"}
+}
+
+// blackInput is black frames with digital silence, smallest possible output for given spec
+func blackInput(spec config.VideoSpec) []string {
+	video := fmt.Sprintf("color=c=black:size=%dx%d:rate=%d", spec.Width, spec.Height, max(spec.FPS, 1))
+	return []string{"-f", "lavfi", "-i", video, "-f", "lavfi", "-i", "anullsrc=r=48000:cl=stereo"}
+}
+
+// slideColor spreads hues by golden ratio so consecutive slides clearly differ
+func slideColor(i int) string {
+	hue := math.Mod(float64(i)*0.618033988749895, 1) * 6
+	x := 1 - math.Abs(math.Mod(hue, 2)-1)
+	var r, g, b float64
+	switch int(hue) {
+	case 0:
+		r, g = 1, x
+	case 1:
+		r, g = x, 1
+	case 2:
+		g, b = 1, x
+	case 3:
+		g, b = x, 1
+	case 4:
+		r, b = x, 1
+	default:
+		r, b = 1, x
+	}
+	// 75% value keeps white numbers readable
+	return fmt.Sprintf("0x%02x%02x%02x", int(r*191), int(g*191), int(b*191))
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"lorem.video/internal/config"
+)
+
+func TestSyntheticInputArgs(t *testing.T) {
+	spec := config.VideoSpec{Name: "avsync", Width: 1280, Height: 720, FPS: 25}
+
+	if _, synthetic, err := syntheticInputArgs("/data/sourceVideo/bunny.mp4", spec); synthetic || err != nil {
+		t.Errorf("regular source treated as synthetic: %v %v", synthetic, err)
+	}
+	if _, _, err := syntheticInputArgs(config.SyntheticSourcePrefix+"missing", spec); err == nil {
+		t.Error("expected error for unknown generator")
+	}
+
+	args, synthetic, err := syntheticInputArgs(config.SyntheticSourcePrefix+"avsync", spec)
+	if !synthetic || err != nil {
+		t.Fatalf("avsync not resolved: %v", err)
+	}
+	// 25fps: 3 flash frames = 120ms = 5760 samples at 48kHz
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "lt(mod(n,25),3)") || !strings.Contains(joined, "lt(mod(n,48000),5760)") {
+		t.Errorf("flash and beep not aligned: %s", joined)
+	}
+}
+
+func TestClockInput(t *testing.T) {
+	spec := config.VideoSpec{Name: "clock", Width: 1280, Height: 720, FPS: 30, Duration: 10, Codec: "h264", Container: "mp4"}
+
+	args := clockInput(spec)
+	if len(args) != 8 {
+		t.Fatalf("expected video and audio lavfi inputs, got %v", args)
+	}
+	for _, want := range []string{"text='clock_h264_1280x720_30fps_10s.mp4'", "text='frame %{n}'", `text='+%{pts\:hms}'`} {
+		if !strings.Contains(args[3], want) {
+			t.Errorf("video filter missing %s: %s", want, args[3])
+		}
+	}
+}
+
+func TestChannelsInputMatchesLayout(t *testing.T) {
+	for token, layout := range config.ChannelLayouts {
+		if _, ok := channelOrder[layout]; !ok {
+			t.Errorf("no channel order for layout %s", token)
+		}
+	}
+
+	args := channelsInput(config.VideoSpec{Width: 640, Height: 360, FPS: 30, Channels: "5point1"})
+	audio := args[7]
+	if strings.Count(audio, "sin(") != 6 || !strings.HasSuffix(audio, ":c=5.1") {
+		t.Errorf("expected 6 channel tones in 5.1 layout: %s", audio)
+	}
+}
+
+func TestSlidesInput(t *testing.T) {
+	spec := config.VideoSpec{Width: 640, Height: 360, FPS: 30, Duration: 7, Slide: 3, Crossfade: 500}
+
+	graph := slidesInput(spec)[3]
+	// 7s at 3s per slide needs 3 slides, crossfades start at slide boundaries
+	for _, want := range []string{"duration=3.5,", "text='3'[s2]", "xfade=transition=fade:duration=0.5:offset=3[x1]", "offset=6[x2]", "[x2]null"} {
+		if !strings.Contains(graph, want) {
+			t.Errorf("graph missing %s: %s", want, graph)
+		}
+	}
+
+	spec.Crossfade = 0
+	if graph := slidesInput(spec)[3]; !strings.Contains(graph, "[s0][s1][s2]concat=n=3") {
+		t.Errorf("expected hard cuts: %s", graph)
+	}
+
+	// Long specs use one looping source, graph size doesn't depend on duration
+	long := config.VideoSpec{Width: 640, Height: 360, FPS: 30, Duration: 36000, Slide: 1, Crossfade: 500}
+	graph = slidesInput(long)[3]
+	if len(graph) > 1000 || !strings.Contains(graph, `text='%{eif\:(floor(t/1)-1)+1\:d}'`) || !strings.Contains(graph, "min(mod(T,1)/0.5,1)") {
+		t.Errorf("unexpected looping slides graph: %s", graph)
+	}
+}
+
+func TestBarsInputToneLevel(t *testing.T) {
+	args := barsInput(config.VideoSpec{Width: 1920, Height: 1080, FPS: 25})
+	if !strings.HasPrefix(args[3], "pal75bars=size=1920x1080:rate=25") {
+		t.Errorf("unexpected bars filter: %s", args[3])
+	}
+	if !strings.Contains(args[7], "0.125893*sin(2*PI*1000*t)") {
+		t.Errorf("tone not at -18 dBFS: %s", args[7])
+	}
+}
+
+func TestEverySyntheticSourceHasGenerator(t *testing.T) {
+	spec := config.VideoSpec{Width: 640, Height: 360, FPS: 30, Duration: 5}
+	for _, name := range config.SyntheticSources {
+		args, synthetic, err := syntheticInputArgs(config.SyntheticSourcePrefix+name, spec)
+		if !synthetic || err != nil || len(args) == 0 {
+			t.Errorf("synthetic source %s not rendered: %v", name, err)
+		}
+	}
+}
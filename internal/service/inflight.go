@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"syscall"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
+)
+
+const (
+	transcodeNice     = 10 // Background encodes leave CPU to serving requests
+	deprioritizedNice = 19 // Encode whose clients can't keep up with it
+)
+
+// StreamConfig controls partial file streaming, deprioritization is disabled when DeprioritizeBacklog is 0
+type StreamConfig struct {
+	DeprioritizeBacklog int64 // Bytes written by FFmpeg but not yet sent to client that make encode low priority
+}
+
+func StreamConfigFromEnv() StreamConfig {
+	return StreamConfig{DeprioritizeBacklog: config.GetEnvInt64("STREAM_DEPRIORITIZE_BACKLOG")}
+}
+
+var streamConfig StreamConfig
+
+func SetStreamConfig(cfg StreamConfig) {
+	streamConfig = cfg
+}
+
+// inflight is queued or running transcode, pid is set once FFmpeg has started
+type inflight struct {
+	pid  atomic.Int64
+	done chan struct{} // Closed when transcode ended, successfully or not
+
+	mutex         sync.Mutex
+	backlogs      map[*StreamFollower]int64 // Output not yet sent to each client streaming partial file
+	deprioritized bool
+}
+
+func (job *inflight) finish(path string) {
+	transcoding.Delete(path)
+	close(job.done)
+}
+
+// transcoding holds output paths FFmpeg is still writing, such files are streamed while growing
+var transcoding sync.Map
+
+// Transcoding reports whether path is output of running transcode
+func Transcoding(path string) bool {
+	_, ok := transcoding.Load(path)
+	return ok
+}
+
+// WaitTranscoded blocks until queued or running transcode of path ends, nil when output is complete
+func WaitTranscoded(ctx context.Context, path string) error {
+	if value, ok := transcoding.Load(path); ok {
+		select {
+		case <-value.(*inflight).done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if !fileindex.Exists(path) {
+		return fmt.Errorf("transcode of %s failed", filepath.Base(path))
+	}
+	return nil
+}
+
+// FollowWhileTranscoding reports whether partial output of spec can be streamed while FFmpeg writes it.
+// Metadata injection replaces the file when FFmpeg is done, so reader of partial file would miss injected boxes
+func FollowWhileTranscoding(spec config.VideoSpec) bool {
+	return !needsMetadataInjection(spec)
+}
+
+// StreamFollower is client streaming partial output of transcode
+type StreamFollower struct {
+	path string
+	job  *inflight
+}
+
+// FollowStream registers client streaming partial file of path, Stop must be called when it's done
+func FollowStream(path string) *StreamFollower {
+	follower := &StreamFollower{path: path}
+	if value, ok := transcoding.Load(path); ok {
+		follower.job = value.(*inflight)
+		follower.job.setBacklog(follower, 0)
+	}
+	return follower
+}
+
+// ReportBacklog is called with output not yet sent to client. Encode is deprioritized only when all its
+// clients are over backlog limit, one slow client doesn't slow down others
+func (f *StreamFollower) ReportBacklog(backlog int64) {
+	if f.job != nil {
+		f.job.setBacklog(f, backlog)
+	}
+}
+
+func (f *StreamFollower) Stop() {
+	if f.job != nil {
+		f.job.setBacklog(f, -1)
+	}
+}
+
+// setBacklog updates follower's backlog (-1 removes it) and adjusts priority to least behind follower
+func (job *inflight) setBacklog(follower *StreamFollower, backlog int64) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	if job.backlogs == nil {
+		job.backlogs = make(map[*StreamFollower]int64)
+	}
+	if backlog < 0 {
+		delete(job.backlogs, follower)
+	} else {
+		job.backlogs[follower] = backlog
+	}
+
+	limit := streamConfig.DeprioritizeBacklog
+	if limit <= 0 {
+		return
+	}
+	behind := len(job.backlogs) > 0
+	for _, followerBacklog := range job.backlogs {
+		if followerBacklog <= limit {
+			behind = false
+			break
+		}
+	}
+	if behind != job.deprioritized {
+		job.renice(follower.path, behind)
+	}
+}
+
+// renice moves whole FFmpeg process group between normal transcode and low priority, caller holds job.mutex
+func (job *inflight) renice(path string, deprioritize bool) {
+	pid := int(job.pid.Load())
+	if pid == 0 {
+		return
+	}
+
+	nice, reason := transcodeNice, "restored priority, a client caught up"
+	if deprioritize {
+		nice, reason = deprioritizedNice, "deprioritized, all clients consume slower than it encodes"
+	}
+	// FFmpeg runs in own process group (Setpgid), PRIO_PGRP covers all its threads
+	if err := syscall.Setpriority(syscall.PRIO_PGRP, pid, nice); err != nil {
+		log.Printf("Failed to renice transcode %s: %v", filepath.Base(path), err)
+		return
+	}
+	job.deprioritized = deprioritize
+	log.Printf("Transcode %s %s", filepath.Base(path), reason)
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestStreamFollowersDeprioritize(t *testing.T) {
+	cmd := exec.Command("sleep", "5")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	defer cmd.Wait()
+	defer cmd.Process.Kill()
+
+	job := &inflight{}
+	job.pid.Store(int64(cmd.Process.Pid))
+	transcoding.Store("/tmp/backlog.mp4", job)
+	defer transcoding.Delete("/tmp/backlog.mp4")
+	SetStreamConfig(StreamConfig{DeprioritizeBacklog: 1 << 20})
+	defer SetStreamConfig(StreamConfig{})
+
+	// Linux getpriority returns 20 - nice
+	nice := func() int {
+		priority, err := syscall.Getpriority(syscall.PRIO_PROCESS, cmd.Process.Pid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return 20 - priority
+	}
+
+	slow, fast := FollowStream("/tmp/backlog.mp4"), FollowStream("/tmp/backlog.mp4")
+	slow.ReportBacklog(2 << 20)
+	fast.ReportBacklog(1024)
+	if nice() == deprioritizedNice {
+		t.Fatal("deprioritized while one client keeps up")
+	}
+
+	fast.ReportBacklog(2 << 20)
+	if got := nice(); got != deprioritizedNice {
+		t.Errorf("nice = %d, want %d", got, deprioritizedNice)
+	}
+
+	// Lowering nice needs CAP_SYS_NICE
+	if os.Geteuid() != 0 {
+		return
+	}
+	fast.Stop()
+	slow.ReportBacklog(1024)
+	if got := nice(); got != transcodeNice {
+		t.Errorf("nice after catching up = %d, want %d", got, transcodeNice)
+	}
+}
+
+func TestWaitTranscoded(t *testing.T) {
+	dir := t.TempDir()
+	complete, failed := filepath.Join(dir, "complete.mp4"), filepath.Join(dir, "failed.mp4")
+	for _, path := range []string{complete, failed} {
+		job := &inflight{done: make(chan struct{})}
+		transcoding.Store(path, job)
+		go func() {
+			time.Sleep(20 * time.Millisecond)
+			if path == complete {
+				os.WriteFile(path, []byte("video"), 0644)
+			}
+			job.finish(path)
+		}()
+	}
+
+	if err := WaitTranscoded(context.Background(), complete); err != nil {
+		t.Errorf("complete transcode: %v", err)
+	}
+	if err := WaitTranscoded(context.Background(), failed); err == nil {
+		t.Error("failed transcode reported complete")
+	}
+}
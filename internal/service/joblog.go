@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/tenant"
+)
+
+// ErrJobNotFound is returned by JobLogPath for unknown job
+var ErrJobNotFound = errors.New("job log not found")
+
+// jobLog receives full FFmpeg stderr and -progress output of one transcode, job ID is output filename
+// (or hls_{name}_{resolution}) prefixed by tenant, so rerun of same spec replaces previous log
+type jobLog struct {
+	file *os.File
+}
+
+// JobLogConfig limits kept job logs, oldest are removed every PruneInterval
+type JobLogConfig struct {
+	MaxAge        time.Duration
+	MaxCount      int
+	PruneInterval time.Duration
+}
+
+func JobLogConfigFromEnv() JobLogConfig {
+	jobLogConfig := JobLogConfig{
+		MaxAge:        config.GetEnvDuration("JOB_LOG_MAX_AGE", 7*24*time.Hour),
+		MaxCount:      int(config.GetEnvInt64("JOB_LOG_MAX_COUNT")),
+		PruneInterval: 10 * time.Minute,
+	}
+	if jobLogConfig.MaxCount == 0 {
+		jobLogConfig.MaxCount = 1000
+	}
+	return jobLogConfig
+}
+
+// StartJobLogPruning removes old job logs now and then every PruneInterval, off the transcode path
+func StartJobLogPruning(jobLogConfig JobLogConfig) {
+	go func() {
+		pruneJobLogs(config.AppPaths.LogsJobs, jobLogConfig)
+		ticker := time.NewTicker(jobLogConfig.PruneInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			pruneJobLogs(config.AppPaths.LogsJobs, jobLogConfig)
+		}
+	}()
+}
+
+// JobID namespaces job by request tenant ("{tenant}~{name}"), so tenants with same spec don't share log
+func JobID(ctx context.Context, name string) string {
+	if tenantName := tenant.NameFromContext(ctx); tenantName != "" {
+		return tenantName + "~" + name
+	}
+	return name
+}
+
+// jobProgressArgs make FFmpeg write progress to fd 3, which is job log file
+var jobProgressArgs = []string{"-progress", "pipe:3", "-stats_period", "2"}
+
+// startJobLog creates log with command line header, nil (no-op) when log can't be written
+func startJobLog(id string) *jobLog {
+	path := filepath.Join(config.AppPaths.LogsJobs, id+".log")
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		log.Printf("Warning: Failed to create job log: %v", err)
+		return nil
+	}
+	fmt.Fprintf(file, "job: %s\nstarted: %s\n", id, time.Now().Format(time.RFC3339))
+	return &jobLog{file: file}
+}
+
+// attach routes stderr (also kept in stderr buffer) and progress to log, cmd's last arg must be output path
+func (j *jobLog) attach(cmd *exec.Cmd, stderr io.Writer) {
+	if j == nil {
+		cmd.Stderr = stderr
+		return
+	}
+	cmd.Args = slices.Insert(cmd.Args, len(cmd.Args)-1, jobProgressArgs...)
+	fmt.Fprintf(j.file, "command: %s\n\n", strings.Join(cmd.Args, " "))
+	cmd.Stderr = io.MultiWriter(stderr, j.file)
+	cmd.ExtraFiles = []*os.File{j.file}
+}
+
+func (j *jobLog) finish(err error) {
+	if j == nil {
+		return
+	}
+	status := "success"
+	if err != nil {
+		status = err.Error()
+	}
+	fmt.Fprintf(j.file, "\nfinished: %s\nstatus: %s\n", time.Now().Format(time.RFC3339), status)
+	j.file.Close()
+}
+
+// pruneJobLogs removes logs older than MaxAge and oldest above MaxCount
+func pruneJobLogs(dir string, cfg JobLogConfig) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+
+	type logFile struct {
+		path    string
+		modTime time.Time
+	}
+	var logs []logFile
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || !info.Mode().IsRegular() || filepath.Ext(entry.Name()) != ".log" {
+			continue
+		}
+		logs = append(logs, logFile{filepath.Join(dir, entry.Name()), info.ModTime()})
+	}
+
+	// Newest first, everything past MaxCount or MaxAge is removed
+	sort.Slice(logs, func(i, j int) bool { return logs[i].modTime.After(logs[j].modTime) })
+	for i, logFile := range logs {
+		expired := cfg.MaxAge > 0 && time.Since(logFile.modTime) > cfg.MaxAge
+		if expired || (cfg.MaxCount > 0 && i >= cfg.MaxCount) {
+			os.Remove(logFile.path)
+		}
+	}
+}
+
+// JobLogPath returns log file of job
+func JobLogPath(id string) (string, error) {
+	if id == "" || filepath.Base(id) != id || strings.HasPrefix(id, ".") {
+		return "", ErrJobNotFound
+	}
+	path := filepath.Join(config.AppPaths.LogsJobs, id+".log")
+	if _, err := os.Stat(path); err != nil {
+		return "", ErrJobNotFound
+	}
+	return path, nil
+}
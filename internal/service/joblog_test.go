@@ -0,0 +1,85 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/tenant"
+)
+
+func TestJobLog(t *testing.T) {
+	original := config.AppPaths.LogsJobs
+	config.AppPaths.LogsJobs = t.TempDir()
+	defer func() { config.AppPaths.LogsJobs = original }()
+
+	jobLog := startJobLog("bunny_720p.mp4")
+	cmd := exec.Command("ffmpeg", "-i", "in.mp4", "out.mp4")
+	var stderr strings.Builder
+	jobLog.attach(cmd, &stderr)
+	if got := strings.Join(cmd.Args, " "); got != "ffmpeg -i in.mp4 -progress pipe:3 -stats_period 2 out.mp4" {
+		t.Errorf("progress args not before output: %s", got)
+	}
+	cmd.Stderr.Write([]byte("boom\n"))
+	jobLog.finish(errors.New("exit status 1"))
+
+	if stderr.String() != "boom\n" {
+		t.Errorf("stderr buffer = %q", stderr.String())
+	}
+	path, err := JobLogPath("bunny_720p.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, want := range []string{"command: ffmpeg", "boom", "status: exit status 1"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("log missing %q: %s", want, data)
+		}
+	}
+
+	for _, id := range []string{"", "../errors", ".hidden", "missing.mp4"} {
+		if _, err := JobLogPath(id); err != ErrJobNotFound {
+			t.Errorf("JobLogPath(%q) = %v, want not found", id, err)
+		}
+	}
+}
+
+func TestPruneJobLogs(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	for i, name := range []string{"new.log", "older.log", "oldest.log", "expired.log"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-time.Duration(i) * time.Minute)
+		if name == "expired.log" {
+			modTime = now.Add(-48 * time.Hour)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+
+	pruneJobLogs(dir, JobLogConfig{MaxAge: 24 * time.Hour, MaxCount: 2})
+
+	for name, kept := range map[string]bool{"new.log": true, "older.log": true, "oldest.log": false, "expired.log": false} {
+		if _, err := os.Stat(filepath.Join(dir, name)); (err == nil) != kept {
+			t.Errorf("%s kept = %v, want %v", name, err == nil, kept)
+		}
+	}
+}
+
+func TestJobIDTenant(t *testing.T) {
+	if id := JobID(context.Background(), "bunny_720p.mp4"); id != "bunny_720p.mp4" {
+		t.Errorf("public job ID = %q", id)
+	}
+	ctx := tenant.WithTenant(context.Background(), &tenant.Tenant{Name: "acme"})
+	if id := JobID(ctx, "bunny_720p.mp4"); id != "acme~bunny_720p.mp4" {
+		t.Errorf("tenant job ID = %q", id)
+	}
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/errlog"
+	"lorem.video/internal/fileindex"
+	"lorem.video/internal/stats"
+	"lorem.video/internal/tenant"
+)
+
+// lazyHLSMaxDuration matches pregenerated ladder, which encodes first 60s of source
+const lazyHLSMaxDuration = 60
+
+// LazyHLS is HLS ladder of source without pregenerated streams. Each 1s segment is encoded when first
+// requested and cached, so playback starts after one segment instead of after whole ladder is encoded
+type LazyHLS struct {
+	name       string
+	sourcePath string
+	dir        string // Master playlist, {resolution}/init.mp4 and chunk_NNN.mp4 files
+	ladder     map[string]config.Resolution
+	Segments   int // Full 1s segments looped by media playlist
+}
+
+// lazyHLSStreams caches probed sources, key is source path and modification time
+var lazyHLSStreams sync.Map
+
+// OpenLazyHLS probes source once and writes master playlist referencing same ladder as pregenerated HLS
+func OpenLazyHLS(name, sourcePath string) (*LazyHLS, error) {
+	info, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s@%d", sourcePath, info.ModTime().UnixNano())
+	if value, ok := lazyHLSStreams.Load(key); ok {
+		return value.(*LazyHLS), nil
+	}
+
+	duration, err := probeDuration(sourcePath)
+	if err != nil {
+		return nil, err
+	}
+	stream := &LazyHLS{
+		name:       name,
+		sourcePath: sourcePath,
+		dir:        lazyHLSDir(sourcePath),
+		ladder:     hlsLadder(sourcePath),
+		Segments:   int(min(duration, lazyHLSMaxDuration)),
+	}
+	if stream.Segments < 1 {
+		return nil, fmt.Errorf("source %s is shorter than one segment", name)
+	}
+
+	if err := os.MkdirAll(stream.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := generateMasterPlaylist(stream.MasterPlaylist(), stream.ladder, name); err != nil {
+		return nil, fmt.Errorf("failed to generate master playlist: %w", err)
+	}
+
+	lazyHLSStreams.Store(key, stream)
+	return stream, nil
+}
+
+// lazyHLSDir mirrors source location under data dir, so tenant source doesn't share cache with public one of same name
+func lazyHLSDir(sourcePath string) string {
+	relative, err := filepath.Rel(config.AppPaths.Data, sourcePath)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		relative = filepath.Base(sourcePath)
+	}
+	return filepath.Join(config.AppPaths.HLSCache, strings.TrimSuffix(relative, filepath.Ext(relative)))
+}
+
+func (s *LazyHLS) MasterPlaylist() string {
+	return filepath.Join(s.dir, config.HLSMasterPlaylist)
+}
+
+func (s *LazyHLS) HasResolution(resKey string) bool {
+	_, ok := s.ladder[resKey]
+	return ok
+}
+
+// SegmentPath is chunk file of segment, init.mp4 for negative index
+func (s *LazyHLS) SegmentPath(resKey string, index int) string {
+	if index < 0 {
+		return filepath.Join(s.dir, resKey, config.HLSInit)
+	}
+	return filepath.Join(s.dir, resKey, fmt.Sprintf(config.HLSChunkFormat, index))
+}
+
+// Cached reports whether segment (or init for negative index) can be served without encoding
+func (s *LazyHLS) Cached(resKey string, index int) bool {
+	return fileindex.Exists(s.SegmentPath(resKey, index))
+}
+
+// Segment returns chunk file of segment (init.mp4 for negative index), encoding it first when not cached.
+// Concurrent requests for same segment wait for one encode
+func (s *LazyHLS) Segment(ctx context.Context, resKey string, index int) (string, error) {
+	path := s.SegmentPath(resKey, index)
+	if fileindex.Exists(path) {
+		return path, nil
+	}
+
+	job := &inflight{done: make(chan struct{})}
+	if _, running := transcoding.LoadOrStore(path, job); running {
+		return path, WaitTranscoded(ctx, path)
+	}
+	defer job.finish(path)
+
+	// Other requests wait for this encode, so it isn't cancelled with request
+	ctx = context.WithoutCancel(ctx)
+	resolution := s.ladder[resKey]
+	release, err := transcodeQueue.acquire(ctx, path, float64(resolution.Width*resolution.Height*25))
+	if err != nil {
+		return "", err
+	}
+	defer release()
+
+	// Every segment encode writes init segment too, init request encodes first segment
+	segment := max(index, 0)
+	if err := s.encodeSegment(ctx, resKey, segment); err != nil {
+		return "", err
+	}
+	if !fileindex.Exists(path) {
+		return "", fmt.Errorf("segment %s not written", filepath.Base(path))
+	}
+	return path, nil
+}
+
+// encodeSegment encodes second index of source with pregenerated HLS settings. Timestamps are shifted to
+// segment's position, so consecutive segments play continuously as if encoded in one pass
+func (s *LazyHLS) encodeSegment(ctx context.Context, resKey string, index int) error {
+	res := s.ladder[resKey]
+	outputDir := filepath.Join(s.dir, resKey)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return err
+	}
+	workDir, err := os.MkdirTemp(outputDir, ".segment-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(workDir)
+
+	start := strconv.Itoa(index)
+	args := []string{
+		"-n", strconv.Itoa(transcodeNice), "ffmpeg",
+		"-hide_banner",
+		"-loglevel", "error",
+		"-ss", start,
+		"-i", s.sourcePath,
+		"-t", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d",
+			res.Width, res.Height, res.Width, res.Height),
+		"-c:v", "libx264",
+		"-preset", "fast",
+		"-r", "25",
+		"-g", "25",
+		"-keyint_min", "25",
+		"-sc_threshold", "0",
+		"-crf", "23",
+		"-c:a", "aac",
+		"-b:a", "128k",
+		"-ac", "2",
+		"-output_ts_offset", start,
+		"-f", "hls",
+		"-hls_time", "1",
+		"-hls_list_size", "0",
+		"-hls_segment_type", "fmp4",
+		"-hls_flags", "independent_segments",
+		"-hls_fmp4_init_filename", config.HLSInit,
+		"-hls_segment_filename", filepath.Join(workDir, "segment_%d.mp4"),
+		filepath.Join(workDir, config.HLSMediaPlaylist),
+	}
+
+	done := stats.TranscodeStarted()
+	started := time.Now()
+	output, err := exec.CommandContext(ctx, "nice", args...).CombinedOutput()
+	done()
+	tenant.FromContext(ctx).RecordEncode(time.Since(started))
+
+	if err != nil {
+		errlog.Record(ctx, errlog.Entry{
+			Spec:         fmt.Sprintf("hls %s %s segment %d", s.name, resKey, index),
+			Message:      fmt.Sprintf("ffmpeg failed: %v", err),
+			FFmpegStderr: errlog.StderrExcerpt(string(output)),
+		})
+		return fmt.Errorf("ffmpeg failed: %w", err)
+	}
+
+	// Init first, segment is only served once its init exists
+	initPath := s.SegmentPath(resKey, -1)
+	if !fileindex.Exists(initPath) {
+		if err := os.Rename(filepath.Join(workDir, config.HLSInit), initPath); err != nil {
+			return err
+		}
+		fileindex.Add(initPath)
+	}
+	chunkPath := s.SegmentPath(resKey, index)
+	if err := os.Rename(filepath.Join(workDir, "segment_0.mp4"), chunkPath); err != nil {
+		return err
+	}
+	fileindex.Add(chunkPath)
+	return nil
+}
+
+func probeDuration(path string) (float64, error) {
+	output, err := exec.Command("ffprobe",
+		"-v", "error",
+		"-show_entries", "format=duration",
+		"-of", "default=noprint_wrappers=1:nokey=1",
+		path,
+	).Output()
+	if err != nil {
+		return 0, fmt.Errorf("ffprobe failed: %w", err)
+	}
+	duration, err := strconv.ParseFloat(strings.TrimSpace(string(output)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse duration: %w", err)
+	}
+	return duration, nil
+}
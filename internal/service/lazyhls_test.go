@@ -0,0 +1,23 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+
+	"lorem.video/internal/config"
+)
+
+func TestLazyHLSDir(t *testing.T) {
+	original := config.AppPaths
+	defer func() { config.AppPaths = original }()
+	config.AppPaths = &config.Paths{Data: "/data", HLSCache: "/data/hlscache"}
+
+	public := lazyHLSDir(filepath.Join("/data", "sourceVideo", "bunny.mp4"))
+	tenantOwn := lazyHLSDir(filepath.Join("/data", "tenants", "acme", "sourceVideo", "bunny.mp4"))
+	if public != "/data/hlscache/sourceVideo/bunny" || tenantOwn != "/data/hlscache/tenants/acme/sourceVideo/bunny" {
+		t.Errorf("unexpected cache dirs %s, %s", public, tenantOwn)
+	}
+	if outside := lazyHLSDir("/elsewhere/clip.mp4"); outside != "/data/hlscache/clip" {
+		t.Errorf("source outside data dir cached at %s", outside)
+	}
+}
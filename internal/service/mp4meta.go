@@ -0,0 +1,266 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"lorem.video/internal/config"
+)
+
+// MP4 outputs are fragmented (empty moov + moof/mdat pairs) with default_base_moof, so boxes can be
+// inserted into moov without fixing sample offsets
+
+// sphericalV1UUID identifies Spherical Video V1 XML box, see github.com/google/spatial-media
+var sphericalV1UUID = []byte{0xff, 0xcc, 0x82, 0x63, 0xf8, 0x55, 0x4a, 0x93, 0x88, 0x14, 0x58, 0x7a, 0x02, 0x52, 0x1f, 0xdd}
+
+const sphericalV1XML = `<?xml version="1.0"?><rdf:SphericalVideo xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns:GSpherical="http://ns.google.com/videos/1.0/spherical/">` +
+	`<GSpherical:Spherical>true</GSpherical:Spherical><GSpherical:Stitched>true</GSpherical:Stitched>` +
+	`<GSpherical:StitchingSoftware>lorem.video</GSpherical:StitchingSoftware>` +
+	`<GSpherical:ProjectionType>equirectangular</GSpherical:ProjectionType></rdf:SphericalVideo>`
+
+var errNoVideoTrack = errors.New("mp4 has no video track")
+
+// Stereo3D frame packing per container/codec
+var (
+	st3dStereoModes     = map[string]byte{"tb3d": 1, "sbs3d": 2}
+	matroskaStereoModes = map[string]string{"tb3d": "top_bottom", "sbs3d": "left_right"}
+	x264FramePacking    = map[string]string{"sbs3d": "3", "tb3d": "4"}
+)
+
+// needsMetadataInjection reports whether MP4 output gets boxes FFmpeg can't write
+func needsMetadataInjection(spec config.VideoSpec) bool {
+	return spec.Container == "mp4" && spec.Codec != "novideo" && (spec.VR360 || spec.Stereo3D != "")
+}
+
+func injectMetadata(spec config.VideoSpec, path string) error {
+	if spec.VR360 {
+		if err := injectSpherical(path); err != nil {
+			return err
+		}
+	}
+	if stereoMode, ok := st3dStereoModes[spec.Stereo3D]; ok {
+		return injectStereo(path, stereoMode)
+	}
+	return nil
+}
+
+// injectStereo adds st3d (Spherical Video V2 stereo mode) to video sample entry
+func injectStereo(path string, stereoMode byte) error {
+	return rewriteMoov(path, func(moov []byte) ([]byte, error) {
+		return appendToVideoSampleEntry(moov, makeFullBox("st3d", []byte{stereoMode}))
+	})
+}
+
+// injectSpherical tags MP4 as equirectangular 360 video, both V1 (uuid in trak) and V2 (sv3d in sample entry)
+func injectSpherical(path string) error {
+	return rewriteMoov(path, func(moov []byte) ([]byte, error) {
+		uuidBox := makeBox("uuid", append(append([]byte{}, sphericalV1UUID...), sphericalV1XML...))
+		moov, err := appendToVideoTrak(moov, uuidBox)
+		if err != nil {
+			return nil, err
+		}
+		return appendToVideoSampleEntry(moov, sphericalV2Box())
+	})
+}
+
+// sphericalV2Box builds sv3d with full-frame equirectangular projection
+func sphericalV2Box() []byte {
+	svhd := makeFullBox("svhd", []byte("lorem.video\x00"))
+	prhd := makeFullBox("prhd", make([]byte, 12)) // yaw, pitch, roll = 0
+	equi := makeFullBox("equi", make([]byte, 16)) // no cropping bounds
+	proj := makeBox("proj", append(prhd, equi...))
+	return makeBox("sv3d", append(svhd, proj...))
+}
+
+func makeBox(boxType string, payload []byte) []byte {
+	box := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(box, uint32(8+len(payload)))
+	copy(box[4:], boxType)
+	return append(box, payload...)
+}
+
+// makeFullBox prepends version 0 and zero flags
+func makeFullBox(boxType string, payload []byte) []byte {
+	return makeBox(boxType, append(make([]byte, 4), payload...))
+}
+
+// rewriteMoov replaces top-level moov via edit, written to temp file and renamed so readers never see half-written file
+func rewriteMoov(path string, edit func(moov []byte) ([]byte, error)) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpPath := path + ".meta"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath) // No-op after successful rename
+
+	found := false
+	header := make([]byte, 8)
+	for !found {
+		if _, err := io.ReadFull(in, header); err != nil {
+			out.Close()
+			return fmt.Errorf("moov not found: %w", err)
+		}
+		size := int64(binary.BigEndian.Uint32(header))
+		if size < 8 {
+			out.Close()
+			return errors.New("unsupported box size before moov")
+		}
+
+		if string(header[4:8]) != "moov" {
+			// Boxes before moov (ftyp) are copied unchanged
+			_, copyErr := out.Write(header)
+			if copyErr == nil {
+				_, copyErr = io.CopyN(out, in, size-8)
+			}
+			if copyErr != nil {
+				out.Close()
+				return copyErr
+			}
+			continue
+		}
+
+		moov := make([]byte, size)
+		copy(moov, header)
+		if _, err := io.ReadFull(in, moov[8:]); err != nil {
+			out.Close()
+			return err
+		}
+		if moov, err = edit(moov); err != nil {
+			out.Close()
+			return err
+		}
+		if _, err := out.Write(moov); err != nil {
+			out.Close()
+			return err
+		}
+		found = true
+	}
+
+	// Fragments after moov are copied as is
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// mp4Box is box position within buffer
+type mp4Box struct {
+	offset, size int
+	boxType      string
+}
+
+// childBoxes lists boxes in buf[start:end]
+func childBoxes(buf []byte, start, end int) []mp4Box {
+	var boxes []mp4Box
+	for offset := start; offset+8 <= end; {
+		size := int(binary.BigEndian.Uint32(buf[offset:]))
+		if size < 8 || offset+size > end {
+			break
+		}
+		boxes = append(boxes, mp4Box{offset: offset, size: size, boxType: string(buf[offset+4 : offset+8])})
+		offset += size
+	}
+	return boxes
+}
+
+func findChild(buf []byte, parent mp4Box, headerSize int, boxType string) (mp4Box, bool) {
+	for _, child := range childBoxes(buf, parent.offset+headerSize, parent.offset+parent.size) {
+		if child.boxType == boxType {
+			return child, true
+		}
+	}
+	return mp4Box{}, false
+}
+
+// videoTrakPath returns moov, trak, mdia, minf, stbl, stsd and first sample entry of video track
+func videoTrakPath(moov []byte) ([]mp4Box, error) {
+	root := mp4Box{offset: 0, size: len(moov), boxType: "moov"}
+	for _, trak := range childBoxes(moov, 8, len(moov)) {
+		if trak.boxType != "trak" {
+			continue
+		}
+		mdia, ok := findChild(moov, trak, 8, "mdia")
+		if !ok {
+			continue
+		}
+		hdlr, ok := findChild(moov, mdia, 8, "hdlr")
+		// hdlr: header 8, version/flags 4, pre_defined 4, handler_type 4
+		if !ok || hdlr.size < 20 || string(moov[hdlr.offset+16:hdlr.offset+20]) != "vide" {
+			continue
+		}
+
+		path := []mp4Box{root, trak, mdia}
+		parent := mdia
+		for _, boxType := range []string{"minf", "stbl", "stsd"} {
+			child, ok := findChild(moov, parent, 8, boxType)
+			if !ok {
+				return nil, fmt.Errorf("video track missing %s", boxType)
+			}
+			path = append(path, child)
+			parent = child
+		}
+		// stsd: header 8, version/flags 4, entry_count 4
+		entry, ok := firstChild(moov, parent, 16)
+		if !ok {
+			return nil, errors.New("video track has no sample entry")
+		}
+		return append(path, entry), nil
+	}
+	return nil, errNoVideoTrack
+}
+
+func firstChild(buf []byte, parent mp4Box, headerSize int) (mp4Box, bool) {
+	children := childBoxes(buf, parent.offset+headerSize, parent.offset+parent.size)
+	if len(children) == 0 {
+		return mp4Box{}, false
+	}
+	return children[0], true
+}
+
+func appendToVideoTrak(moov, box []byte) ([]byte, error) {
+	path, err := videoTrakPath(moov)
+	if err != nil {
+		return nil, err
+	}
+	return appendToBox(moov, path[:2], box), nil
+}
+
+func appendToVideoSampleEntry(moov, box []byte) ([]byte, error) {
+	path, err := videoTrakPath(moov)
+	if err != nil {
+		return nil, err
+	}
+	return appendToBox(moov, path, box), nil
+}
+
+// appendToBox inserts box at end of last box in path and grows every box in path
+func appendToBox(buf []byte, path []mp4Box, box []byte) []byte {
+	target := path[len(path)-1]
+	insertAt := target.offset + target.size
+
+	var result bytes.Buffer
+	result.Grow(len(buf) + len(box))
+	result.Write(buf[:insertAt])
+	result.Write(box)
+	result.Write(buf[insertAt:])
+
+	out := result.Bytes()
+	for _, ancestor := range path {
+		binary.BigEndian.PutUint32(out[ancestor.offset:], uint32(ancestor.size+len(box)))
+	}
+	return out
+}
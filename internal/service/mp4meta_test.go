@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lorem.video/internal/config"
+)
+
+// testMP4 builds minimal fragmented MP4 layout with one video track
+func testMP4() (ftyp, moov, fragments []byte) {
+	hdlr := makeFullBox("hdlr", append(make([]byte, 4), []byte("vide\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")...))
+	avc1 := makeBox("avc1", make([]byte, 78))
+	stsd := makeFullBox("stsd", append([]byte{0, 0, 0, 1}, avc1...))
+	minf := makeBox("minf", makeBox("stbl", stsd))
+	mdia := makeBox("mdia", append(hdlr, minf...))
+	trak := makeBox("trak", mdia)
+
+	ftyp = makeBox("ftyp", []byte("isom"))
+	moov = makeBox("moov", append(makeBox("mvhd", make([]byte, 8)), trak...))
+	fragments = append(makeBox("moof", make([]byte, 16)), makeBox("mdat", []byte("frames"))...)
+	return ftyp, moov, fragments
+}
+
+func TestInjectSpherical(t *testing.T) {
+	ftyp, moov, fragments := testMP4()
+	path := filepath.Join(t.TempDir(), "vr.mp4")
+	if err := os.WriteFile(path, bytes.Join([][]byte{ftyp, moov, fragments}, nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := injectSpherical(path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, ftyp) || !bytes.HasSuffix(data, fragments) {
+		t.Fatal("boxes outside moov changed")
+	}
+
+	newMoov := data[len(ftyp) : len(data)-len(fragments)]
+	boxes := childBoxes(newMoov, 0, len(newMoov))
+	if len(boxes) != 1 || boxes[0].size != len(newMoov) {
+		t.Fatalf("moov size not updated: %+v", boxes)
+	}
+
+	trakPath, err := videoTrakPath(newMoov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := findChild(newMoov, trakPath[1], 8, "uuid"); !ok {
+		t.Error("V1 uuid box missing in trak")
+	}
+	if _, ok := findChild(newMoov, trakPath[len(trakPath)-1], 86, "sv3d"); !ok {
+		t.Error("V2 sv3d box missing in sample entry")
+	}
+}
+
+func TestInjectStereo(t *testing.T) {
+	ftyp, moov, fragments := testMP4()
+	path := filepath.Join(t.TempDir(), "3d.mp4")
+	if err := os.WriteFile(path, bytes.Join([][]byte{ftyp, moov, fragments}, nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	spec := config.VideoSpec{Container: "mp4", Codec: "h264", Stereo3D: "sbs3d"}
+	if !needsMetadataInjection(spec) {
+		t.Fatal("expected injection for sbs3d mp4")
+	}
+	if err := injectMetadata(spec, path); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newMoov := data[len(ftyp) : len(data)-len(fragments)]
+	trakPath, err := videoTrakPath(newMoov)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st3d, ok := findChild(newMoov, trakPath[len(trakPath)-1], 86, "st3d")
+	if !ok || newMoov[st3d.offset+12] != 2 {
+		t.Error("st3d box missing or not left-right")
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+
+	"lorem.video/internal/errlog"
+	"lorem.video/internal/stats"
+)
+
+// Mezzanine is the uniform format uploads are re-encoded to when SOURCE_NORMALIZE is enabled:
+// visually lossless H.264 with closed 2s GOPs, so looped HLS and seeking cut cleanly, and EBU R128 loudness
+const (
+	mezzanineCRF         = "16"
+	mezzanineKeyframeSec = 2
+	mezzanineLoudness    = "loudnorm=I=-23:TP=-1:LRA=7"
+)
+
+// NormalizeSource re-encodes input to mezzanine mp4 at outputPath, FFmpeg output is at /jobs/normalize_{name}/log
+func NormalizeSource(ctx context.Context, name, inputPath, outputPath string, hasAudio bool) error {
+	args := []string{
+		"-n", "10", "ffmpeg",
+		"-y",
+		"-i", inputPath,
+		"-map", "0:v:0",
+		"-c:v", "libx264",
+		"-preset", "slow",
+		"-crf", mezzanineCRF,
+		"-profile:v", "high",
+		"-pix_fmt", "yuv420p",
+		"-vf", "scale=trunc(iw/2)*2:trunc(ih/2)*2", // Even dimensions for yuv420p
+		"-force_key_frames", fmt.Sprintf("expr:gte(t,n_forced*%d)", mezzanineKeyframeSec),
+		"-flags", "+cgop",
+		"-sc_threshold", "0",
+	}
+	if hasAudio {
+		args = append(args,
+			"-map", "0:a:0",
+			"-af", mezzanineLoudness,
+			"-c:a", "aac",
+			"-b:a", "256k",
+			"-ar", "48000",
+			"-ac", "2",
+		)
+	}
+	args = append(args, "-movflags", "+faststart", "-f", "mp4", outputPath)
+
+	cmd := exec.CommandContext(ctx, "nice", args...)
+	var stderr bytes.Buffer
+	jobID := "normalize_" + name
+	jobLog := startJobLog(jobID)
+	jobLog.attach(cmd, &stderr)
+
+	done := stats.TranscodeStarted()
+	err := cmd.Run()
+	done()
+	jobLog.finish(err)
+
+	if err != nil {
+		errlog.Record(ctx, errlog.Entry{
+			Job:          jobID,
+			Spec:         "mezzanine " + name,
+			Message:      fmt.Sprintf("ffmpeg failed: %v", err),
+			FFmpegStderr: errlog.StderrExcerpt(stderr.String()),
+		})
+		return fmt.Errorf("normalize failed: %w", err)
+	}
+	return nil
+}
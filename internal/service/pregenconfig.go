@@ -0,0 +1,77 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PregenConfig selects what is pregenerated for a source at startup
+type PregenConfig struct {
+	Videos bool `json:"videos"` // config.DefaultPregenSpecs progressive files
+	HLS    bool `json:"hls"`    // 480p/720p/1080p HLS ladder
+}
+
+// defaultPregenConfig applies to sources without {name}.pregen.json, PREGEN_DEFAULT is all (default), videos, hls or none
+func defaultPregenConfig() PregenConfig {
+	switch value := os.Getenv("PREGEN_DEFAULT"); value {
+	case "", "all":
+		return PregenConfig{Videos: true, HLS: true}
+	case "videos":
+		return PregenConfig{Videos: true}
+	case "hls":
+		return PregenConfig{HLS: true}
+	case "none":
+		return PregenConfig{}
+	default:
+		log.Printf("Warning: invalid PREGEN_DEFAULT=%q, pregenerating all", value)
+		return PregenConfig{Videos: true, HLS: true}
+	}
+}
+
+// PregenConfigPath is settings file next to source, e.g. bunny.pregen.json for bunny.mp4
+func PregenConfigPath(sourcePath string) string {
+	return strings.TrimSuffix(sourcePath, filepath.Ext(sourcePath)) + ".pregen.json"
+}
+
+// LoadPregenConfig reads source's pregen.json, fields missing from file keep PREGEN_DEFAULT value
+func LoadPregenConfig(sourcePath string) (PregenConfig, error) {
+	pregenConfig := defaultPregenConfig()
+
+	data, err := os.ReadFile(PregenConfigPath(sourcePath))
+	if os.IsNotExist(err) {
+		return pregenConfig, nil
+	}
+	if err != nil {
+		return pregenConfig, err
+	}
+	if err := json.Unmarshal(data, &pregenConfig); err != nil {
+		return defaultPregenConfig(), fmt.Errorf("invalid %s: %w", filepath.Base(PregenConfigPath(sourcePath)), err)
+	}
+	return pregenConfig, nil
+}
+
+// writePregenConfig creates source's pregen.json, existing file is kept as operator may have edited it
+func writePregenConfig(sourcePath string, pregenConfig PregenConfig) error {
+	path := PregenConfigPath(sourcePath)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	data, err := json.Marshal(pregenConfig)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// sourcePregenConfig logs invalid settings file and falls back to default
+func sourcePregenConfig(sourcePath string) PregenConfig {
+	pregenConfig, err := LoadPregenConfig(sourcePath)
+	if err != nil {
+		log.Printf("⚠️  %v, using PREGEN_DEFAULT", err)
+	}
+	return pregenConfig
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPregenConfig(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "mezzanine.mp4")
+
+	t.Setenv("PREGEN_DEFAULT", "")
+	if got, err := LoadPregenConfig(source); err != nil || got != (PregenConfig{Videos: true, HLS: true}) {
+		t.Errorf("expected everything pregenerated without settings file, got %+v (%v)", got, err)
+	}
+
+	// Missing field keeps default
+	os.WriteFile(filepath.Join(dir, "mezzanine.pregen.json"), []byte(`{"videos": false}`), 0644)
+	if got, err := LoadPregenConfig(source); err != nil || got != (PregenConfig{HLS: true}) {
+		t.Errorf("expected HLS only, got %+v (%v)", got, err)
+	}
+
+	t.Setenv("PREGEN_DEFAULT", "none")
+	os.WriteFile(filepath.Join(dir, "mezzanine.pregen.json"), []byte(`{"hls": true`), 0644)
+	if got, err := LoadPregenConfig(source); err == nil || got != (PregenConfig{}) {
+		t.Errorf("expected error and default for invalid file, got %+v (%v)", got, err)
+	}
+}
+
+func TestWritePregenConfig(t *testing.T) {
+	t.Setenv("PREGEN_DEFAULT", "")
+	source := filepath.Join(t.TempDir(), "long.mp4")
+
+	// Extras are written explicitly off, so PREGEN_DEFAULT=all doesn't apply to them
+	if err := writePregenConfig(source, PregenConfig{HLS: true}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := LoadPregenConfig(source); got != (PregenConfig{HLS: true}) {
+		t.Errorf("expected HLS only, got %+v", got)
+	}
+
+	// Operator's edit survives
+	writePregenConfig(source, PregenConfig{})
+	if got, _ := LoadPregenConfig(source); got != (PregenConfig{HLS: true}) {
+		t.Errorf("existing settings overwritten, got %+v", got)
+	}
+}
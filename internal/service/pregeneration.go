@@ -9,10 +9,12 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
 )
 
 // StartupPregeneration runs video pregeneration in the background on app startup
@@ -45,6 +47,9 @@ func PregenerateAllVideos(ctx context.Context) (map[string][]string, error) {
 	results := make(map[string][]string)
 
 	for _, sourceFile := range sourceFiles {
+		if !sourcePregenConfig(sourceFile).Videos {
+			continue
+		}
 		generatedFiles, err := PregenerateVideos(ctx, sourceFile)
 		if err != nil {
 			log.Printf("❌ Failed to pregenerate videos for %s: %v", filepath.Base(sourceFile), err)
@@ -92,44 +97,116 @@ func PregenerateVideos(ctx context.Context, inputPath string) ([]string, error)
 	return generatedFiles, nil
 }
 
-// GenerateDefaultSourceVideo creates a default test video using FFmpeg generators
-func GenerateDefaultSourceVideo(outputPath string) error {
+// DefaultSource is generated from FFmpeg lavfi sources into sourceVideo dir on first boot
+type DefaultSource struct {
+	Name     string
+	Video    string       // lavfi video source with size and rate
+	Audio    string       // lavfi audio source
+	Duration int          // Seconds
+	Pregen   PregenConfig // Written to {name}.pregen.json for extras, bunny follows PREGEN_DEFAULT
+}
+
+// DefaultSources give fresh deployment variety without uploads, bunny is the default spec name and always generated
+var DefaultSources = []DefaultSource{
+	{Name: "bunny", Video: "testsrc2=size=1920x1080:rate=30", Audio: "sine=frequency=440", Duration: 60},
+	{Name: "portrait", Video: "testsrc2=size=1080x1920:rate=30", Audio: "sine=frequency=523", Duration: 30},
+	{Name: "square", Video: "testsrc=size=1080x1080:rate=30", Audio: "sine=frequency=659", Duration: 30},
+	{Name: "long", Video: "testsrc2=size=1280x720:rate=30", Audio: "sine=frequency=440:beep_factor=4", Duration: 600, Pregen: PregenConfig{HLS: true}},
+	{Name: "motion", Video: "life=size=1920x1080:rate=60:mold=10:ratio=0.3:random_fill_ratio=0.5:death_color=#202060:life_color=#f0c040", Audio: "anoisesrc=color=pink:amplitude=0.2", Duration: 30},
+}
+
+// GenerateDefaultSourceVideo creates source video using FFmpeg generators
+func GenerateDefaultSourceVideo(source DefaultSource, outputPath string) error {
 	cmd := exec.Command("ffmpeg",
 		"-f", "lavfi",
-		"-i", "testsrc2=duration=60:size=1920x1080:rate=30", // Test pattern video
+		"-i", source.Video,
 		"-f", "lavfi",
-		"-i", "sine=frequency=440:duration=60", // 440Hz test tone
+		"-i", source.Audio,
+		"-t", fmt.Sprintf("%d", source.Duration),
 		"-c:v", "libx264",
 		"-preset", "fast",
 		"-crf", "25",
+		"-pix_fmt", "yuv420p", // life renders rgb
 		"-c:a", "aac",
 		"-b:a", "128k",
-		"-y", // Overwrite if exists
-		outputPath,
+		"-f", "mp4",
+		"-y",              // Overwrite if exists
+		outputPath+".tmp", // Not listed as source until complete
 	)
 
 	if err := cmd.Run(); err != nil {
+		os.Remove(outputPath + ".tmp")
 		return fmt.Errorf("ffmpeg failed to generate test video: %w", err)
 	}
+	if err := os.Rename(outputPath+".tmp", outputPath); err != nil {
+		return err
+	}
 
 	log.Printf("Generated default source video: %s", outputPath)
 	return nil
 }
 
-// EnsureDefaultSourceVideo checks if default source video exists and generates it if not
+// EnsureDefaultSourceVideo generates bunny source on first boot (bunny.mp4 missing), other DefaultSources follow in
+// background after server started. DEFAULT_SOURCES limits which extras are generated, e.g. "portrait,square", "none" for bunny only
 func EnsureDefaultSourceVideo() error {
 	defaultPath := config.AppPaths.DefaultSourceVideo
 
-	if _, err := os.Stat(defaultPath); os.IsNotExist(err) {
-		log.Printf("Default source video not found, generating: %s", defaultPath)
-		return GenerateDefaultSourceVideo(defaultPath)
-	} else if err != nil {
+	if _, err := os.Stat(defaultPath); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
 		return fmt.Errorf("failed to check default source video: %w", err)
 	}
 
+	log.Printf("Default source video not found, generating: %s", defaultPath)
+	if err := GenerateDefaultSourceVideo(DefaultSources[0], defaultPath); err != nil {
+		return err
+	}
+
+	var extras []DefaultSource
+	selected := os.Getenv("DEFAULT_SOURCES")
+	for _, source := range DefaultSources[1:] {
+		if selected == "" || slices.Contains(strings.Split(selected, ","), source.Name) {
+			extras = append(extras, source)
+		}
+	}
+	go generateExtraSources(extras)
+
 	return nil
 }
 
+// generateExtraSources writes pregen settings before source appears, so startup pregeneration doesn't pick up
+// full video set and HLS ladder for every extra, then pregenerates what settings enable
+func generateExtraSources(sources []DefaultSource) {
+	for _, source := range sources {
+		outputPath := filepath.Join(config.AppPaths.SourceVideo, source.Name+".mp4")
+		if _, err := os.Stat(outputPath); err == nil {
+			continue
+		}
+		if err := writePregenConfig(outputPath, source.Pregen); err != nil {
+			log.Printf("Warning: Failed to write pregen settings of %s: %v", source.Name, err)
+			continue
+		}
+		// Extra sources are optional, failure leaves deployment with bunny only
+		if err := GenerateDefaultSourceVideo(source, outputPath); err != nil {
+			log.Printf("Warning: Failed to generate default source %s: %v", source.Name, err)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Minute)
+		if source.Pregen.Videos {
+			if _, err := PregenerateVideos(ctx, outputPath); err != nil {
+				log.Printf("❌ Failed to pregenerate videos for %s: %v", source.Name, err)
+			}
+		}
+		if source.Pregen.HLS {
+			if _, err := PregenerateHLS(ctx, outputPath); err != nil {
+				log.Printf("❌ Failed to pregenerate HLS streams for %s: %v", source.Name, err)
+			}
+		}
+		cancel()
+	}
+}
+
 // PregenerateAllHLS generates HLS streams for all source video files
 func PregenerateAllHLS(ctx context.Context) (map[string][]string, error) {
 	sourceFiles, err := config.GetSourceVideoFiles()
@@ -140,6 +217,9 @@ func PregenerateAllHLS(ctx context.Context) (map[string][]string, error) {
 	results := make(map[string][]string)
 
 	for _, sourceFile := range sourceFiles {
+		if !sourcePregenConfig(sourceFile).HLS {
+			continue
+		}
 		generatedStreams, err := PregenerateHLS(ctx, sourceFile)
 		if err != nil {
 			log.Printf("❌ Failed to pregenerate HLS streams for %s: %v", filepath.Base(sourceFile), err)
@@ -161,29 +241,7 @@ func PregenerateHLS(ctx context.Context, inputPath string) ([]string, error) {
 		return nil, fmt.Errorf("failed to create output directory: %w", err)
 	}
 
-	// Check if input video is vertical (portrait orientation)
-	isVertical, err := isVideoVertical(inputPath)
-	if err != nil {
-		log.Printf("⚠️  Failed to detect video orientation for %s, using default resolutions: %v", filenameNoExt, err)
-		isVertical = false
-	}
-
-	hlsResolutions := map[string]config.Resolution{
-		"480p":  config.Resolutions["480p"],
-		"720p":  config.Resolutions["720p"],
-		"1080p": config.Resolutions["1080p"],
-	}
-
-	// If video is vertical, swap width/height for HLS transcoding
-	if isVertical {
-		for key, res := range hlsResolutions {
-			hlsResolutions[key] = config.Resolution{
-				Width:  res.Height,
-				Height: res.Width,
-			}
-		}
-		// log.Printf("Detected vertical video %s, using portrait resolutions for HLS", filenameNoExt)
-	}
+	hlsResolutions := hlsLadder(inputPath)
 
 	var generatedStreams []string
 	videoService := NewVideoService()
@@ -227,6 +285,7 @@ func PregenerateHLS(ctx context.Context, inputPath string) ([]string, error) {
 		if err := generateMasterPlaylist(masterPlaylistPath, hlsResolutions, filenameNoExt); err != nil {
 			return nil, fmt.Errorf("failed to generate master playlist: %w", err)
 		}
+		fileindex.Add(masterPlaylistPath)
 
 		generatedStreams = append(generatedStreams, "master: "+filepath.Base(masterPlaylistPath))
 		log.Printf("✅ Generated master playlist for %s: %s", filenameNoExt, filepath.Base(masterPlaylistPath))
@@ -235,6 +294,33 @@ func PregenerateHLS(ctx context.Context, inputPath string) ([]string, error) {
 	return generatedStreams, nil
 }
 
+// hlsLadder returns 480p/720p/1080p resolutions of HLS streams, portrait for vertical video
+func hlsLadder(inputPath string) map[string]config.Resolution {
+	// Check if input video is vertical (portrait orientation)
+	isVertical, err := isVideoVertical(inputPath)
+	if err != nil {
+		log.Printf("⚠️  Failed to detect video orientation for %s, using default resolutions: %v", filepath.Base(inputPath), err)
+		isVertical = false
+	}
+
+	hlsResolutions := map[string]config.Resolution{
+		"480p":  config.Resolutions["480p"],
+		"720p":  config.Resolutions["720p"],
+		"1080p": config.Resolutions["1080p"],
+	}
+
+	// If video is vertical, swap width/height for HLS transcoding
+	if isVertical {
+		for key, res := range hlsResolutions {
+			hlsResolutions[key] = config.Resolution{
+				Width:  res.Height,
+				Height: res.Width,
+			}
+		}
+	}
+	return hlsResolutions
+}
+
 func generateMasterPlaylist(masterPlaylistPath string, hlsResolutions map[string]config.Resolution, videoName string) error {
 	// Define approximate bandwidth for each resolution (these are rough estimates)
 	bandwidths := map[string]int{
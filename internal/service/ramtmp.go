@@ -0,0 +1,152 @@
+package service
+
+import (
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
+)
+
+// RAMTmpConfig routes short encodes to config.AppPaths.TmpRAM, disabled when TMP_RAM_DIR is unset
+type RAMTmpConfig struct {
+	MaxDuration int           // Seconds, longer videos go to disk tmp
+	TTL         time.Duration // Age after which file is persisted or deleted
+	PersistHits int           // Cache hits within TTL needed to move file to disk tmp
+}
+
+func RAMTmpConfigFromEnv() RAMTmpConfig {
+	ramConfig := RAMTmpConfig{
+		MaxDuration: int(config.GetEnvInt64("TMP_RAM_MAX_DURATION")),
+		TTL:         config.GetEnvDuration("TMP_RAM_TTL", 10*time.Minute),
+		PersistHits: int(config.GetEnvInt64("TMP_RAM_PERSIST_HITS")),
+	}
+	if ramConfig.MaxDuration <= 0 {
+		ramConfig.MaxDuration = 10
+	}
+	if ramConfig.PersistHits <= 0 {
+		ramConfig.PersistHits = 2
+	}
+	return ramConfig
+}
+
+func (c RAMTmpConfig) Enabled() bool {
+	return config.AppPaths.TmpRAM != "" && c.TTL > 0
+}
+
+var ramTmp struct {
+	mutex   sync.Mutex
+	enabled bool
+	config  RAMTmpConfig
+	hits    map[string]int // path -> cache hits
+}
+
+// StartRAMTmp enables RAM output dir and starts sweeping expired files
+func StartRAMTmp(ramConfig RAMTmpConfig) {
+	ramTmp.mutex.Lock()
+	ramTmp.enabled, ramTmp.config = true, ramConfig
+	ramTmp.hits = make(map[string]int)
+	ramTmp.mutex.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(ramConfig.TTL / 2)
+		defer ticker.Stop()
+		for range ticker.C {
+			sweepRAMTmp()
+		}
+	}()
+}
+
+// TmpOutputDir returns where on-request transcode of spec is written
+func TmpOutputDir(spec config.VideoSpec) string {
+	ramTmp.mutex.Lock()
+	defer ramTmp.mutex.Unlock()
+
+	if ramTmp.enabled && spec.Duration <= ramTmp.config.MaxDuration {
+		return config.AppPaths.TmpRAM
+	}
+	return config.AppPaths.Tmp
+}
+
+// RecordTmpHit counts cache hit of path, files in RAM dir with enough hits are persisted to disk
+func RecordTmpHit(path string) {
+	ramTmp.mutex.Lock()
+	defer ramTmp.mutex.Unlock()
+
+	if ramTmp.enabled && filepath.Dir(path) == config.AppPaths.TmpRAM {
+		ramTmp.hits[path]++
+	}
+}
+
+// sweepRAMTmp moves popular expired files to disk tmp and deletes the rest
+func sweepRAMTmp() {
+	ramTmp.mutex.Lock()
+	ttl, persistHits := ramTmp.config.TTL, ramTmp.config.PersistHits
+	ramTmp.mutex.Unlock()
+
+	entries, err := os.ReadDir(config.AppPaths.TmpRAM)
+	if err != nil {
+		log.Printf("Warning: Failed to read RAM tmp dir: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || time.Since(info.ModTime()) < ttl {
+			continue // Recent, may still be written by FFmpeg
+		}
+
+		path := filepath.Join(config.AppPaths.TmpRAM, entry.Name())
+		ramTmp.mutex.Lock()
+		hits := ramTmp.hits[path]
+		delete(ramTmp.hits, path)
+		ramTmp.mutex.Unlock()
+
+		if _, degraded := DegradedFile(path); hits >= persistHits && !degraded {
+			diskPath := filepath.Join(config.AppPaths.Tmp, entry.Name())
+			if err := copyFile(path, diskPath, info.Mode()); err != nil {
+				log.Printf("Warning: Failed to persist %s: %v", entry.Name(), err)
+				continue
+			}
+			fileindex.Add(diskPath)
+		}
+
+		fileindex.Remove(path)
+		if err := os.Remove(path); err != nil {
+			log.Printf("Warning: Failed to remove %s from RAM tmp: %v", entry.Name(), err)
+		}
+	}
+}
+
+// copyFile copies via temporary file, rename can't move across filesystems (tmpfs -> disk)
+func copyFile(src, dst string, mode fs.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpPath := dst + ".partial"
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, dst)
+}
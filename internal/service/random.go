@@ -0,0 +1,81 @@
+package service
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sort"
+
+	"lorem.video/internal/config"
+)
+
+// Random spec values are kept to cheap, commonly used ones
+var (
+	randomDurations     = []int{5, 10, 20}
+	randomFPS           = []int{24, 25, 30, 60}
+	randomCRFs          = []string{"23crf", "25crf", "28crf"}
+	randomAudioBitrates = []int{64, 96, 128}
+)
+
+// RandomConstraints narrow RandomSpec, zero values allow anything
+type RandomConstraints struct {
+	Name      string
+	Container string
+	Codec     string
+	MaxHeight int
+}
+
+// RandomSpec picks random valid spec within constraints
+func RandomSpec(rng *rand.Rand, constraints RandomConstraints) (config.VideoSpec, error) {
+	spec := config.DefaultVideoSpec
+	if constraints.Name != "" {
+		spec.Name = constraints.Name
+	}
+
+	var containers []string
+	for _, container := range config.ValidContainers {
+		codecs := config.ContainerCodecs[container]
+		if constraints.Container != "" && container != constraints.Container {
+			continue
+		}
+		if constraints.Codec != "" && !slices.Contains(codecs.Video, constraints.Codec) {
+			continue
+		}
+		containers = append(containers, container)
+	}
+	if len(containers) == 0 {
+		return config.VideoSpec{}, fmt.Errorf("no container supports codec %q", constraints.Codec)
+	}
+	spec.Container = pick(rng, containers)
+
+	codecs := config.ContainerCodecs[spec.Container]
+	spec.Codec = constraints.Codec
+	if spec.Codec == "" {
+		spec.Codec = pick(rng, codecs.Video)
+	}
+	spec.AudioCodec = pick(rng, codecs.Audio)
+
+	var resolutions []config.Resolution
+	for _, res := range config.Resolutions {
+		if constraints.MaxHeight <= 0 || res.Height <= constraints.MaxHeight {
+			resolutions = append(resolutions, res)
+		}
+	}
+	if len(resolutions) == 0 {
+		return config.VideoSpec{}, fmt.Errorf("no resolution up to %dp", constraints.MaxHeight)
+	}
+	// Map order is random, sort so seeded rng is reproducible
+	sort.Slice(resolutions, func(i, j int) bool { return resolutions[i].Height < resolutions[j].Height })
+	res := pick(rng, resolutions)
+	spec.Width, spec.Height = res.Width, res.Height
+
+	spec.Duration = pick(rng, randomDurations)
+	spec.FPS = pick(rng, randomFPS)
+	spec.Bitrate = pick(rng, randomCRFs)
+	spec.AudioBitrate = pick(rng, randomAudioBitrates)
+	return spec, nil
+}
+
+func pick[T any](rng *rand.Rand, values []T) T {
+	return values[rng.IntN(len(values))]
+}
@@ -0,0 +1,36 @@
+package service
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"lorem.video/internal/config"
+)
+
+func TestRandomSpecConstraints(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for range 100 {
+		spec, err := RandomSpec(rng, RandomConstraints{Container: "webm", MaxHeight: 1080})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if spec.Container != "webm" || !slices.Contains([]string{"vp9", "av1"}, spec.Codec) || spec.Height > 1080 {
+			t.Fatalf("spec outside constraints: %+v", spec)
+		}
+	}
+
+	spec, err := RandomSpec(rng, RandomConstraints{Codec: "h264"})
+	if err != nil || spec.Container != "mp4" || spec.AudioCodec != "aac" {
+		t.Errorf("h264 should pick mp4 with aac: %+v %v", spec, err)
+	}
+	if _, err := RandomSpec(rng, RandomConstraints{Container: "webm", Codec: "h264"}); err == nil {
+		t.Error("expected error for h264 in webm")
+	}
+	if _, err := RandomSpec(rng, RandomConstraints{MaxHeight: 100}); err == nil {
+		t.Error("expected error when no resolution fits")
+	}
+	if spec.Name != config.DefaultVideoSpec.Name {
+		t.Errorf("expected default source, got %s", spec.Name)
+	}
+}
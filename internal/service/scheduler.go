@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/stats"
+)
+
+// ErrAlreadyQueued is returned when same output is already waiting for a transcode slot
+var ErrAlreadyQueued = errors.New("transcode already queued")
+
+// SchedulerConfig limits concurrent FFmpeg encodes, disabled (unlimited) when Workers is 0
+type SchedulerConfig struct {
+	Workers int
+	MaxWait time.Duration // Jobs waiting longer are started in arrival order regardless of cost
+}
+
+func SchedulerConfigFromEnv() SchedulerConfig {
+	return SchedulerConfig{
+		Workers: int(config.GetEnvInt64("TRANSCODE_WORKERS")),
+		MaxWait: config.GetEnvDuration("TRANSCODE_MAX_WAIT", 30*time.Second),
+	}
+}
+
+type queuedJob struct {
+	output   string
+	cost     float64
+	enqueued time.Time
+	start    chan struct{}
+}
+
+// scheduler starts cheapest waiting job first, so short/low resolution requests aren't stuck behind long 4K AV1 encodes
+type scheduler struct {
+	mutex   sync.Mutex
+	config  SchedulerConfig
+	running int
+	waiting []*queuedJob
+}
+
+var transcodeQueue = &scheduler{}
+
+func SetSchedulerConfig(schedulerConfig SchedulerConfig) {
+	transcodeQueue.mutex.Lock()
+	defer transcodeQueue.mutex.Unlock()
+	transcodeQueue.config = schedulerConfig
+}
+
+// acquire blocks until job may run, call returned func when FFmpeg exits
+func (s *scheduler) acquire(ctx context.Context, output string, cost float64) (func(), error) {
+	s.mutex.Lock()
+	if s.config.Workers <= 0 || (s.running < s.config.Workers && len(s.waiting) == 0) {
+		s.running++
+		s.mutex.Unlock()
+		return s.release, nil
+	}
+	for _, queued := range s.waiting {
+		if queued.output == output {
+			s.mutex.Unlock()
+			return nil, ErrAlreadyQueued
+		}
+	}
+
+	job := &queuedJob{output: output, cost: cost, enqueued: time.Now(), start: make(chan struct{})}
+	s.waiting = append(s.waiting, job)
+	s.mutex.Unlock()
+
+	dequeued := stats.TranscodeQueued()
+	defer dequeued()
+
+	select {
+	case <-job.start:
+		return s.release, nil
+	case <-ctx.Done():
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		select {
+		case <-job.start:
+			// Started concurrently with cancellation, give slot to next job
+			s.running--
+			s.startNext()
+		default:
+			s.remove(job)
+		}
+		return nil, ctx.Err()
+	}
+}
+
+func (s *scheduler) release() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.running--
+	s.startNext()
+}
+
+// startNext fills free slots, must be called with mutex held
+func (s *scheduler) startNext() {
+	for len(s.waiting) > 0 && (s.config.Workers <= 0 || s.running < s.config.Workers) {
+		next := s.pick(time.Now())
+		s.remove(next)
+		s.running++
+		close(next.start)
+	}
+}
+
+// pick returns oldest job that waited past MaxWait (starvation protection), otherwise cheapest
+func (s *scheduler) pick(now time.Time) *queuedJob {
+	oldest, cheapest := s.waiting[0], s.waiting[0]
+	for _, job := range s.waiting[1:] {
+		if job.enqueued.Before(oldest.enqueued) {
+			oldest = job
+		}
+		if job.cost < cheapest.cost {
+			cheapest = job
+		}
+	}
+	if s.config.MaxWait > 0 && now.Sub(oldest.enqueued) >= s.config.MaxWait {
+		return oldest
+	}
+	return cheapest
+}
+
+func (s *scheduler) remove(job *queuedJob) {
+	for i, queued := range s.waiting {
+		if queued == job {
+			s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+			return
+		}
+	}
+}
+
+// codecCostFactor is rough encode time relative to H.264
+var codecCostFactor = map[string]float64{
+	"libaom-av1": 8,
+	"libvpx-vp9": 4,
+	"libx265":    4,
+}
+
+// transcodeCost estimates relative encode time of spec
+func transcodeCost(spec config.VideoSpec) float64 {
+	factor := 1.0
+	if codecFactor, ok := codecCostFactor[config.VideoCodecNameMap[spec.Codec]]; ok {
+		factor = codecFactor
+	}
+	return float64(spec.Duration) * float64(spec.Width*spec.Height) * float64(max(spec.FPS, 1)) * factor
+}
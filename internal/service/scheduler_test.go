@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSchedulerPrioritizesCheapJobs(t *testing.T) {
+	s := &scheduler{config: SchedulerConfig{Workers: 1, MaxWait: time.Hour}}
+	ctx := context.Background()
+
+	release, err := s.acquire(ctx, "running.mp4", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	started := make(chan string, 2)
+	for _, job := range []struct {
+		output string
+		cost   float64
+	}{{"4k_av1.mp4", 1000}, {"360p_h264.mp4", 1}} {
+		go func() {
+			release, err := s.acquire(ctx, job.output, job.cost)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			started <- job.output
+			release()
+		}()
+		time.Sleep(10 * time.Millisecond) // Keep enqueue order
+	}
+
+	if _, err := s.acquire(ctx, "4k_av1.mp4", 1000); err != ErrAlreadyQueued {
+		t.Errorf("duplicate acquire err = %v, want ErrAlreadyQueued", err)
+	}
+
+	release()
+	if first := <-started; first != "360p_h264.mp4" {
+		t.Errorf("first started %s, want cheap job", first)
+	}
+	<-started
+}
+
+func TestSchedulerStarvationProtection(t *testing.T) {
+	now := time.Now()
+	s := &scheduler{
+		config: SchedulerConfig{Workers: 1, MaxWait: time.Minute},
+		waiting: []*queuedJob{
+			{output: "old.mp4", cost: 1000, enqueued: now.Add(-2 * time.Minute)},
+			{output: "new.mp4", cost: 1, enqueued: now},
+		},
+	}
+
+	if job := s.pick(now); job.output != "old.mp4" {
+		t.Errorf("picked %s, want job waiting past MaxWait", job.output)
+	}
+}
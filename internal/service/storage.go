@@ -0,0 +1,90 @@
+package service
+
+import (
+	"io/fs"
+	"path/filepath"
+	"syscall"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/stats"
+)
+
+type DirUsage struct {
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+	Files int    `json:"files"`
+}
+
+// StorageReport shows whether cleanup keeps up with generated files
+type StorageReport struct {
+	Directories   []DirUsage `json:"directories"`
+	FreeBytes     uint64     `json:"freeBytes"` // Available on filesystem holding data directory
+	TotalBytes    uint64     `json:"totalBytes"`
+	CacheHits     int64      `json:"cacheHits"` // Since server start
+	CacheMisses   int64      `json:"cacheMisses"`
+	CacheHitRatio *float64   `json:"cacheHitRatio"` // nil before first video request
+}
+
+// GetStorageReport walks data directories, cost grows with number of cached files
+func GetStorageReport() (*StorageReport, error) {
+	report := &StorageReport{}
+
+	dirs := []struct{ name, path string }{
+		{"tmp", config.AppPaths.Tmp},
+		{"video", config.AppPaths.Video},
+		{"stream", config.AppPaths.Stream},
+		{"hlsCache", config.AppPaths.HLSCache},
+		{"sourceVideo", config.AppPaths.SourceVideo},
+		{"logs", config.AppPaths.Logs},
+	}
+	if config.AppPaths.TmpRAM != "" {
+		dirs = append(dirs, struct{ name, path string }{"tmpRAM", config.AppPaths.TmpRAM})
+	}
+	for _, dir := range dirs {
+		usage, err := dirUsage(dir.name, dir.path)
+		if err != nil {
+			return nil, err
+		}
+		report.Directories = append(report.Directories, usage)
+	}
+
+	var fsStat syscall.Statfs_t
+	if err := syscall.Statfs(config.AppPaths.Data, &fsStat); err != nil {
+		return nil, err
+	}
+	report.FreeBytes = fsStat.Bavail * uint64(fsStat.Bsize)
+	report.TotalBytes = fsStat.Blocks * uint64(fsStat.Bsize)
+
+	runtimeStats := stats.GetRuntimeStats()
+	report.CacheHits, report.CacheMisses = runtimeStats.CacheHits, runtimeStats.CacheMisses
+	if total := report.CacheHits + report.CacheMisses; total > 0 {
+		ratio := float64(report.CacheHits) / float64(total)
+		report.CacheHitRatio = &ratio
+	}
+
+	return report, nil
+}
+
+func dirUsage(name, root string) (DirUsage, error) {
+	usage := DirUsage{Name: name, Path: root}
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Missing directory or files removed by cleanup during walk
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		usage.Files++
+		usage.Bytes += info.Size()
+		return nil
+	})
+
+	return usage, err
+}
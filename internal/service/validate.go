@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/errlog"
+)
+
+// SourcePolicy sets minimums a new source video must meet before it can be used in specs
+type SourcePolicy struct {
+	MinDuration time.Duration
+	MinSize     int  // Shorter side in pixels, 144 allows 256x144 and portrait 144x256
+	DecodeCheck bool // Decode whole file with ffmpeg -f null, slow for long mezzanine files
+	Normalize   bool // Re-encode accepted sources to mezzanine format, see NormalizeSource
+}
+
+// SourcePolicyFromEnv reads SOURCE_MIN_DURATION (default 1s), SOURCE_MIN_SIZE (default 144), SOURCE_DECODE_CHECK (default on)
+// and SOURCE_NORMALIZE (default off)
+func SourcePolicyFromEnv() SourcePolicy {
+	policy := SourcePolicy{
+		MinDuration: config.GetEnvDuration("SOURCE_MIN_DURATION", time.Second),
+		MinSize:     int(config.GetEnvInt64("SOURCE_MIN_SIZE")),
+		DecodeCheck: os.Getenv("SOURCE_DECODE_CHECK") != "false",
+		Normalize:   os.Getenv("SOURCE_NORMALIZE") == "true",
+	}
+	if policy.MinSize <= 0 {
+		policy.MinSize = 144
+	}
+	return policy
+}
+
+// SourceReport is validation result, files with Problems are rejected, Warnings are accepted but flagged
+type SourceReport struct {
+	Duration float64  `json:"duration,omitempty"`
+	Width    int      `json:"width,omitempty"`
+	Height   int      `json:"height,omitempty"`
+	Video    string   `json:"video,omitempty"` // Codec name
+	Audio    string   `json:"audio,omitempty"`
+	Problems []string `json:"problems,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (r SourceReport) Rejected() bool {
+	return len(r.Problems) > 0
+}
+
+// ValidateSource probes file and, when probe passes, decodes it end to end so broken files fail here instead of at request time
+func ValidateSource(ctx context.Context, path string, policy SourcePolicy) SourceReport {
+	cmd := exec.CommandContext(ctx, "ffprobe",
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		path,
+	)
+	output, err := cmd.Output()
+	if err != nil {
+		return SourceReport{Problems: []string{fmt.Sprintf("ffprobe failed: %v", err)}}
+	}
+
+	var probe config.FFProbeOutput
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return SourceReport{Problems: []string{fmt.Sprintf("failed to parse ffprobe output: %v", err)}}
+	}
+
+	report := checkProbe(probe, policy)
+	if report.Rejected() || !policy.DecodeCheck {
+		return report
+	}
+
+	// -xerror stops at first decode error, remaining stderr lines are non fatal decoder complaints
+	var stderr bytes.Buffer
+	decode := exec.CommandContext(ctx, "nice", "-n", "10", "ffmpeg", "-v", "error", "-xerror", "-i", path, "-f", "null", "-")
+	decode.Stderr = &stderr
+	if err := decode.Run(); err != nil {
+		report.Problems = append(report.Problems, fmt.Sprintf("decode failed: %v: %s", err, errlog.StderrExcerpt(strings.TrimSpace(stderr.String()))))
+	} else if stderr.Len() > 0 {
+		report.Warnings = append(report.Warnings, "decoder reported errors: "+errlog.StderrExcerpt(strings.TrimSpace(stderr.String())))
+	}
+	return report
+}
+
+func checkProbe(probe config.FFProbeOutput, policy SourcePolicy) SourceReport {
+	var report SourceReport
+	report.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+
+	for _, stream := range probe.Streams {
+		switch {
+		case stream.CodecType == "video" && report.Video == "":
+			report.Video = stream.CodecName
+			report.Width = stream.Width
+			report.Height = stream.Height
+		case stream.CodecType == "audio" && report.Audio == "":
+			report.Audio = stream.CodecName
+		}
+	}
+
+	if report.Video == "" {
+		report.Problems = append(report.Problems, "no video stream")
+		return report
+	}
+	if report.Duration < policy.MinDuration.Seconds() {
+		report.Problems = append(report.Problems, fmt.Sprintf("duration %.2fs is shorter than %s", report.Duration, policy.MinDuration))
+	}
+	if min(report.Width, report.Height) < policy.MinSize {
+		report.Problems = append(report.Problems, fmt.Sprintf("resolution %dx%d is below %dp", report.Width, report.Height, policy.MinSize))
+	}
+
+	if report.Audio == "" {
+		report.Warnings = append(report.Warnings, "no audio stream, specs with audio will be silent")
+	}
+	if report.Width%2 != 0 || report.Height%2 != 0 {
+		report.Warnings = append(report.Warnings, fmt.Sprintf("odd resolution %dx%d, encodes are scaled to even size", report.Width, report.Height))
+	}
+	return report
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"lorem.video/internal/config"
+)
+
+func TestCheckProbe(t *testing.T) {
+	policy := SourcePolicy{MinDuration: time.Second, MinSize: 144}
+
+	good := checkProbe(config.FFProbeOutput{
+		Streams: []config.FFprobeStream{{CodecType: "video", CodecName: "h264", Width: 1920, Height: 1080}},
+		Format:  config.FFprobeFormat{Duration: "60.0"},
+	}, policy)
+	if good.Rejected() || len(good.Warnings) != 1 {
+		t.Errorf("expected accepted source flagged for missing audio, got %+v", good)
+	}
+
+	tiny := checkProbe(config.FFProbeOutput{
+		Streams: []config.FFprobeStream{
+			{CodecType: "video", CodecName: "h264", Width: 120, Height: 90},
+			{CodecType: "audio", CodecName: "aac"},
+		},
+		Format: config.FFprobeFormat{Duration: "0.5"},
+	}, policy)
+	if len(tiny.Problems) != 2 {
+		t.Errorf("expected duration and resolution problems, got %+v", tiny)
+	}
+
+	audioOnly := checkProbe(config.FFProbeOutput{
+		Streams: []config.FFprobeStream{{CodecType: "audio", CodecName: "aac"}},
+		Format:  config.FFprobeFormat{Duration: "60.0"},
+	}, policy)
+	if !audioOnly.Rejected() {
+		t.Errorf("expected audio-only file rejected, got %+v", audioOnly)
+	}
+}
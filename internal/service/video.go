@@ -9,11 +9,17 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	"lorem.video/internal/config"
+	"lorem.video/internal/errlog"
+	"lorem.video/internal/fileindex"
 	"lorem.video/internal/parser"
+	"lorem.video/internal/stats"
+	"lorem.video/internal/tenant"
 )
 
 type VideoService struct {
@@ -24,11 +30,15 @@ func NewVideoService() *VideoService {
 }
 
 func (s *VideoService) GetInfo(name string) (*config.FFProbeOutput, error) {
-	// TODO convert name to spec and chek data/video first and then data/tmp
-	// HACK "bunny" is hardoded for now
-	videoPath := filepath.Join(config.AppPaths.Video, "bunny", name)
+	inputParams, err := parser.ParseFilename(name)
+	if err != nil {
+		return nil, err
+	}
+	spec := config.ApplyDefaultVideoSpec(inputParams)
 
-	if _, err := os.Stat(videoPath); os.IsNotExist(err) {
+	// Checks data/video/{spec.Name} first and then data/tmp, files are stored under canonical spec filename
+	videoPath := parser.FindExistingVideo(parser.GenerateFilename(&spec), &spec)
+	if videoPath == "" {
 		return nil, fmt.Errorf("video not found: %s", name)
 	}
 
@@ -49,6 +59,7 @@ func (s *VideoService) GetInfo(name string) (*config.FFProbeOutput, error) {
 	if err := json.Unmarshal(output, &info); err != nil {
 		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
 	}
+	info.Summary = summarizeProbe(&info)
 
 	return &info, nil
 }
@@ -65,8 +76,13 @@ func (s *VideoService) TranscodeFromParams(ctx context.Context, paramsStr string
 
 	spec := config.ApplyDefaultVideoSpec(inputParams)
 
-	// Operates only with default source video for now
-	inputPath := config.AppPaths.DefaultSourceVideo
+	inputPath, err := config.FindSourceVideo(spec.Name)
+	if err != nil {
+		errCh := make(chan error, 1)
+		errCh <- err
+		close(errCh)
+		return nil, errCh
+	}
 	outputPath := config.AppPaths.Video
 
 	return s.Transcode(ctx, spec, inputPath, outputPath)
@@ -82,7 +98,7 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 	fullOutputPath := filepath.Join(outputPath, filename)
 
 	// Check if file already exists
-	if _, err := os.Stat(fullOutputPath); err == nil {
+	if fileindex.Exists(fullOutputPath) {
 		go func() {
 			defer close(resultCh)
 			defer close(errCh)
@@ -95,23 +111,44 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 		defer close(resultCh)
 		defer close(errCh)
 
+		degradation := degradationFromContext(ctx)
+		width, height := degradedSize(spec.Width, spec.Height, degradation.MaxHeight)
+
+		inputArgs, synthetic, err := syntheticInputArgs(inputPath, spec)
+		if err != nil {
+			errCh <- err
+			return
+		}
+		if !synthetic {
+			inputArgs = []string{"-i", inputPath}
+		}
+
 		args := []string{
 			"-y",                   // overwrite output files
 			"-loglevel", "warning", // reduce log verbosity
 			"-threads", "2",
-			"-i", inputPath,
-			"-t", fmt.Sprintf("%d", spec.Duration),
-			"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d",
-				spec.Width, spec.Height, spec.Width, spec.Height),
 		}
+		args = append(args, inputArgs...)
+		args = append(args,
+			"-t", fmt.Sprintf("%d", spec.Duration),
+			"-vf", videoFilters(spec, width, height),
+		)
 
 		// minimal header for streaming/progressive playback (To not download whole file)
 		// not to confuse with live streaming HLS, it's chunked differently
 		switch spec.Container {
 		case "mp4":
-			args = append(args, "-movflags", "frag_keyframe+empty_moov")
+			if needsMetadataInjection(spec) {
+				// Fragment offsets relative to moof stay valid when boxes are added to moov afterwards
+				args = append(args, "-movflags", "frag_keyframe+empty_moov+default_base_moof")
+			} else {
+				args = append(args, "-movflags", "frag_keyframe+empty_moov")
+			}
 		case "webm":
 			args = append(args, "-f", "webm")
+			if stereoMode, ok := matroskaStereoModes[spec.Stereo3D]; ok {
+				args = append(args, "-metadata:s:v:0", "stereo_mode="+stereoMode)
+			}
 		}
 
 		videoCodec := config.VideoCodecNameMap[spec.Codec]
@@ -122,9 +159,16 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 				"-r", fmt.Sprintf("%d", spec.FPS),
 			)
 
-			if codecArgs, ok := config.VideoCodecArgs[videoCodec]; ok {
+			codecArgsMap := config.VideoCodecArgs
+			if degradation.FastPreset {
+				codecArgsMap = config.VideoCodecFastArgs
+			}
+			if codecArgs, ok := codecArgsMap[videoCodec]; ok {
 				args = append(args, codecArgs...)
 			}
+			if packing, ok := x264FramePacking[spec.Stereo3D]; ok && videoCodec == "libx264" {
+				args = append(args, "-x264-params", "frame-packing="+packing) // Frame packing arrangement SEI
+			}
 		} else {
 			args = append(args, "-vn") // no video
 		}
@@ -146,8 +190,12 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 			args = append(args,
 				"-c:a", audioCodec, // audio codec
 				"-b:a", fmt.Sprintf("%dk", spec.AudioBitrate), // audio bitrate
-				"-ac", "2", // force 2 channels (stereo)
 			)
+			if layout, ok := config.ChannelLayouts[spec.Channels]; ok {
+				args = append(args, "-ch_layout", layout)
+			} else {
+				args = append(args, "-ac", "2") // force 2 channels (stereo)
+			}
 		} else {
 			args = append(args, "-an") // no audio
 		}
@@ -155,7 +203,7 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 		args = append(args, fullOutputPath)
 
 		// Use nice to lower process priority for background video generation
-		niceArgs := append([]string{"-n", "10", "ffmpeg"}, args...)
+		niceArgs := append([]string{"-n", strconv.Itoa(transcodeNice), "ffmpeg"}, args...)
 		cmd := exec.CommandContext(ctx, "nice", niceArgs...)
 
 		// Add resource limits for VPS environments
@@ -163,12 +211,48 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 			Setpgid: true, // Create new process group for better cleanup
 		}
 
-		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
+		// Claimed while queued, so duplicate requests and WaitTranscoded see the job before FFmpeg starts
+		job := &inflight{done: make(chan struct{})}
+		if _, queued := transcoding.LoadOrStore(fullOutputPath, job); queued {
+			errCh <- ErrAlreadyQueued
+			return
+		}
+		defer job.finish(fullOutputPath)
 
-		if err := cmd.Run(); err != nil {
-			log.Printf("FFmpeg failed with error: %v", err)
-			log.Printf("FFmpeg stderr output: %s", stderr.String())
+		release, err := transcodeQueue.acquire(ctx, fullOutputPath, transcodeCost(spec))
+		if err != nil {
+			errCh <- err
+			return
+		}
+
+		// Opened after queue, duplicate request must not truncate running job's log
+		var stderr bytes.Buffer
+		jobID := JobID(ctx, filename)
+		jobLog := startJobLog(jobID)
+		jobLog.attach(cmd, &stderr)
+
+		// Indexed before FFmpeg finishes so concurrent requests stream partial file instead of starting another transcode
+		fileindex.Add(fullOutputPath)
+
+		done := stats.TranscodeStarted()
+		started := time.Now()
+		if err = cmd.Start(); err == nil {
+			job.pid.Store(int64(cmd.Process.Pid)) // nice execs FFmpeg, pid and process group stay the same
+			err = cmd.Wait()
+		}
+		done()
+		release()
+		jobLog.finish(err)
+		tenant.FromContext(ctx).RecordEncode(time.Since(started))
+
+		if err != nil {
+			fileindex.Remove(fullOutputPath)
+			errlog.Record(ctx, errlog.Entry{
+				Job:          jobID,
+				Spec:         filename,
+				Message:      fmt.Sprintf("ffmpeg failed: %v", err),
+				FFmpegStderr: errlog.StderrExcerpt(stderr.String()),
+			})
 
 			// Clean up partial file on failure
 			if _, statErr := os.Stat(fullOutputPath); statErr == nil {
@@ -181,7 +265,16 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 			return
 		}
 
+		if needsMetadataInjection(spec) {
+			if err := injectMetadata(spec, fullOutputPath); err != nil {
+				errlog.Record(ctx, errlog.Entry{Spec: filename, Message: fmt.Sprintf("metadata injection failed: %v", err)})
+			}
+		}
+
 		log.Printf("Transcode success: %s", filepath.Base(fullOutputPath))
+		if degradation.Degraded() {
+			trackDegradedFile(fullOutputPath, degradation)
+		}
 
 		resultCh <- fullOutputPath
 	}()
@@ -190,6 +283,31 @@ func (s *VideoService) Transcode(ctx context.Context, spec config.VideoSpec, inp
 
 }
 
+// videoFilters scales/crops to output size and applies requested degradations
+func videoFilters(spec config.VideoSpec, width, height int) string {
+	filters := []string{
+		fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=increase,crop=%d:%d", width, height, width, height),
+	}
+	if spec.Grain > 0 {
+		filters = append(filters, fmt.Sprintf("noise=alls=%d:allf=t+u", spec.Grain)) // Temporal uniform noise, like film grain
+	}
+	if spec.Blur > 0 {
+		filters = append(filters, fmt.Sprintf("gblur=sigma=%d", spec.Blur))
+	}
+	switch spec.Stereo3D {
+	case "sbs3d":
+		// Same picture for both eyes, each squeezed to half of the frame
+		filters = append(filters, "scale=iw/2:ih,split[left][right];[left][right]hstack")
+	case "tb3d":
+		filters = append(filters, "scale=iw:ih/2,split[top][bottom];[top][bottom]vstack")
+	}
+	if spec.Pixelate > 0 {
+		// Downscale and upscale back with nearest neighbour keeps hard block edges
+		filters = append(filters, fmt.Sprintf("scale=iw/%d:ih/%d:flags=neighbor,scale=%d:%d:flags=neighbor", spec.Pixelate, spec.Pixelate, width, height))
+	}
+	return strings.Join(filters, ",")
+}
+
 func (s *VideoService) TranscodeHLS(ctx context.Context, res config.Resolution, inputPath, outputPath string) (<-chan string, <-chan error) {
 	resultCh := make(chan string, 1)
 	errCh := make(chan error, 1)
@@ -227,13 +345,27 @@ func (s *VideoService) TranscodeHLS(ctx context.Context, res config.Resolution,
 
 		cmd := exec.CommandContext(ctx, "ffmpeg", args...)
 		var stderr bytes.Buffer
-		cmd.Stderr = &stderr
-
-		if err := cmd.Run(); err != nil {
+		jobID := JobID(ctx, fmt.Sprintf("hls_%s_%s", filepath.Base(filepath.Dir(outputPath)), filepath.Base(outputPath)))
+		jobLog := startJobLog(jobID)
+		jobLog.attach(cmd, &stderr)
+
+		done := stats.TranscodeStarted()
+		err := cmd.Run()
+		done()
+		jobLog.finish(err)
+
+		if err != nil {
+			errlog.Record(ctx, errlog.Entry{
+				Job:          jobID,
+				Spec:         fmt.Sprintf("hls %dx%d", res.Width, res.Height),
+				Message:      fmt.Sprintf("ffmpeg failed: %v", err),
+				FFmpegStderr: errlog.StderrExcerpt(stderr.String()),
+			})
 			errCh <- fmt.Errorf("ffmpeg failed: %w\nOutput: %s", err, stderr.String())
 			return
 		}
 
+		fileindex.AddTree(outputPath)
 		resultCh <- playlistPath
 	}()
 
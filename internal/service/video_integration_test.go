@@ -139,6 +139,24 @@ func TestTranscodeFromParamsIntegration(t *testing.T) {
 			t.Fatal("TranscodeFromParams timed out")
 		}
 	})
+
+	t.Run("GetInfo of shorthand spec", func(t *testing.T) {
+		inputParams, err := parser.ParseFilename("bunny_640x360_2s")
+		if err != nil {
+			t.Fatalf("Failed to parse params: %v", err)
+		}
+		spec := config.ApplyDefaultVideoSpec(inputParams)
+		createTestVideo(t, filepath.Join(config.AppPaths.Tmp, parser.GenerateFilename(&spec)), 2, 640, 360)
+
+		// Looked up by canonical filename, not by the name in URL
+		info, err := service.GetInfo("bunny_640x360_2s")
+		if err != nil {
+			t.Fatalf("GetInfo failed: %v", err)
+		}
+		if info.Summary == nil || info.Summary.Width != 640 {
+			t.Errorf("unexpected summary: %+v", info.Summary)
+		}
+	})
 }
 
 func TestFindExistingVideoIntegration(t *testing.T) {
@@ -570,3 +588,60 @@ func verifyVideoWithFFProbe(t *testing.T, videoPath, originalParams string) {
 		t.Logf("Container format: expected %s, got %s (this might be normal)", expectedFormat, actualFormat)
 	}
 }
+
+func TestLazyHLSIntegration(t *testing.T) {
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		t.Skip("FFmpeg not found, skipping integration test")
+	}
+
+	tempDir := t.TempDir()
+	oldAppPaths := config.AppPaths
+	defer func() { config.AppPaths = oldAppPaths }()
+	config.AppPaths = &config.Paths{
+		Data:       tempDir,
+		HLSCache:   filepath.Join(tempDir, "hlscache"),
+		LogsErrors: filepath.Join(tempDir, "logs", "errors"),
+	}
+
+	sourcePath := filepath.Join(tempDir, "clip.mp4")
+	generate := exec.Command("ffmpeg", "-v", "error", "-f", "lavfi", "-i", "testsrc2=size=640x360:rate=25:duration=3",
+		"-f", "lavfi", "-i", "sine=duration=3", "-c:v", "libx264", "-c:a", "aac", "-shortest", sourcePath)
+	if output, err := generate.CombinedOutput(); err != nil {
+		t.Fatalf("failed to generate source: %v\n%s", err, output)
+	}
+
+	stream, err := OpenLazyHLS("clip", sourcePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream.Segments != 3 {
+		t.Errorf("expected 3 segments, got %d", stream.Segments)
+	}
+	if _, err := os.Stat(stream.MasterPlaylist()); err != nil {
+		t.Errorf("master playlist not written: %v", err)
+	}
+
+	segmentPath, err := stream.Segment(context.Background(), "480p", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	initPath, err := stream.Segment(context.Background(), "480p", -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Segment is decodable after init and starts at its position in source
+	initData, _ := os.ReadFile(initPath)
+	segmentData, _ := os.ReadFile(segmentPath)
+	joined := filepath.Join(tempDir, "joined.mp4")
+	if err := os.WriteFile(joined, append(initData, segmentData...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	output, err := exec.Command("ffprobe", "-v", "error", "-show_entries", "format=start_time", "-of", "csv=p=0", joined).Output()
+	if err != nil {
+		t.Fatalf("ffprobe of init+segment failed: %v", err)
+	}
+	if start := strings.TrimSpace(string(output)); !strings.HasPrefix(start, "1.") && !strings.HasPrefix(start, "0.9") {
+		t.Errorf("segment 1 starts at %s, expected 1s", start)
+	}
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"math"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"lorem.video/internal/config"
+)
+
+// summarizeProbe converts ffprobe strings to numbers, first video stream gives resolution and fps
+func summarizeProbe(probe *config.FFProbeOutput) *config.VideoSummary {
+	summary := &config.VideoSummary{
+		Container: containerName(probe.Format),
+		Duration:  parseFloat(probe.Format.Duration),
+		Bitrate:   kbps(probe.Format.BitRate),
+		Streams:   []config.StreamSummary{},
+	}
+	summary.Size, _ = strconv.ParseInt(probe.Format.Size, 10, 64)
+
+	for _, stream := range probe.Streams {
+		streamSummary := config.StreamSummary{
+			Index:         stream.Index,
+			Type:          stream.CodecType,
+			Codec:         stream.CodecName,
+			Bitrate:       kbps(stream.BitRate),
+			Width:         stream.Width,
+			Height:        stream.Height,
+			Channels:      stream.Channels,
+			ChannelLayout: stream.ChannelLayout,
+		}
+		streamSummary.SampleRate, _ = strconv.Atoi(stream.SampleRate)
+		if stream.CodecType == "video" {
+			streamSummary.FPS = parseFrameRate(stream.AvgFrameRate)
+			if streamSummary.FPS == 0 {
+				streamSummary.FPS = parseFrameRate(stream.RFrameRate)
+			}
+
+			if summary.Resolution == "" {
+				summary.Width = stream.Width
+				summary.Height = stream.Height
+				summary.Resolution = strconv.Itoa(stream.Width) + "x" + strconv.Itoa(stream.Height)
+				summary.FPS = streamSummary.FPS
+			}
+		}
+		summary.Streams = append(summary.Streams, streamSummary)
+	}
+
+	return summary
+}
+
+// containerName picks format from ffprobe's demuxer list matching file extension, e.g. "mov,mp4,m4a,3gp,3g2,mj2" for .mp4
+func containerName(format config.FFprobeFormat) string {
+	names := strings.Split(format.FormatName, ",")
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(format.Filename)), ".")
+	if slices.Contains(names, ext) {
+		return ext
+	}
+	return names[0]
+}
+
+// parseFrameRate turns "30000/1001" into 29.97, rounded to 3 decimals
+func parseFrameRate(rate string) float64 {
+	numerator, denominator, found := strings.Cut(rate, "/")
+	value := parseFloat(numerator)
+	if found {
+		d := parseFloat(denominator)
+		if d == 0 {
+			return 0
+		}
+		value /= d
+	}
+	return math.Round(value*1000) / 1000
+}
+
+func parseFloat(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+// kbps converts bit/s string to rounded kbps
+func kbps(bitsPerSecond string) int {
+	return int(math.Round(parseFloat(bitsPerSecond) / 1000))
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"testing"
+
+	"lorem.video/internal/config"
+)
+
+func TestSummarizeProbe(t *testing.T) {
+	summary := summarizeProbe(&config.FFProbeOutput{
+		Streams: []config.FFprobeStream{
+			{Index: 0, CodecType: "video", CodecName: "h264", Width: 1280, Height: 720, AvgFrameRate: "30000/1001", BitRate: "2500400"},
+			{Index: 1, CodecType: "audio", CodecName: "aac", SampleRate: "48000", Channels: 2, ChannelLayout: "stereo", BitRate: "128000"},
+		},
+		Format: config.FFprobeFormat{
+			Filename:   "/data/video/bunny/bunny_720p.mp4",
+			FormatName: "mov,mp4,m4a,3gp,3g2,mj2",
+			Duration:   "20.020000",
+			Size:       "6600000",
+			BitRate:    "2637362",
+		},
+	})
+
+	if summary.Container != "mp4" || summary.Resolution != "1280x720" || summary.FPS != 29.97 || summary.Duration != 20.02 || summary.Bitrate != 2637 {
+		t.Errorf("unexpected summary: %+v", summary)
+	}
+	if summary.Streams[0].Bitrate != 2500 || summary.Streams[1].SampleRate != 48000 || summary.Streams[1].Bitrate != 128 {
+		t.Errorf("unexpected streams: %+v", summary.Streams)
+	}
+
+	webm := summarizeProbe(&config.FFProbeOutput{Format: config.FFprobeFormat{Filename: "clip.webm", FormatName: "matroska,webm"}})
+	if webm.Container != "webm" {
+		t.Errorf("expected webm container, got %s", webm.Container)
+	}
+}
@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+	"lorem.video/internal/stats"
+)
+
+// warmMaxActiveRequests treats server as idle below this many in-flight requests
+const warmMaxActiveRequests = 5
+
+// WarmConfig controls popularity based cache warming, disabled when TopN is 0
+type WarmConfig struct {
+	Interval time.Duration // How often stats are analyzed
+	Days     int           // Stats lookback window
+	TopN     int           // Most requested uncached specs generated per run
+	Paused   func() bool   // Optional, no encodes are started while it returns true (maintenance mode)
+}
+
+func WarmConfigFromEnv() WarmConfig {
+	warmConfig := WarmConfig{
+		Interval: config.GetEnvDuration("CACHE_WARM_INTERVAL", time.Hour),
+		Days:     int(config.GetEnvInt64("CACHE_WARM_DAYS")),
+		TopN:     int(config.GetEnvInt64("CACHE_WARM_TOP")),
+	}
+	if warmConfig.Days <= 0 {
+		warmConfig.Days = 7
+	}
+	return warmConfig
+}
+
+func (c WarmConfig) Enabled() bool {
+	return c.TopN > 0 && c.Interval > 0
+}
+
+func (c WarmConfig) paused() bool {
+	return c.Paused != nil && c.Paused()
+}
+
+// StartCacheWarming periodically pregenerates most requested video specs that aren't cached
+func StartCacheWarming(warmConfig WarmConfig) {
+	go func() {
+		ticker := time.NewTicker(warmConfig.Interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if !isIdle() || warmConfig.paused() {
+				continue
+			}
+			if _, err := WarmCache(context.Background(), warmConfig); err != nil {
+				log.Printf("❌ Failed to warm cache: %v", err)
+			}
+		}
+	}()
+}
+
+// WarmCache generates up to TopN popular uncached videos into tmp dir, stops early when server gets busy
+func WarmCache(ctx context.Context, warmConfig WarmConfig) ([]string, error) {
+	specs, err := popularUncachedSpecs(warmConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	var generated []string
+	videoService := NewVideoService()
+	for _, spec := range specs {
+		if !isIdle() {
+			log.Printf("Cache warming paused, server busy")
+			break
+		}
+		if warmConfig.paused() {
+			log.Printf("Cache warming paused, maintenance mode")
+			break
+		}
+
+		inputPath, err := config.FindSourceVideo(spec.Name)
+		if err != nil {
+			continue
+		}
+
+		resultCh, errCh := videoService.Transcode(ctx, spec, inputPath, config.AppPaths.Tmp)
+		select {
+		case result := <-resultCh:
+			generated = append(generated, result)
+		case err := <-errCh:
+			log.Printf("❌ Failed to warm %s: %v", parser.GenerateFilename(&spec), err)
+		case <-ctx.Done():
+			return generated, ctx.Err()
+		}
+	}
+
+	if len(generated) > 0 {
+		log.Printf("✅ Cache warming generated %d videos", len(generated))
+	}
+	return generated, nil
+}
+
+// popularUncachedSpecs returns most requested video specs without existing file, most popular first
+func popularUncachedSpecs(warmConfig WarmConfig) ([]config.VideoSpec, error) {
+	result, err := stats.AnalyzeStats(stats.AnalyzerConfig{
+		LogDir:             config.AppPaths.LogsStats,
+		ExcludeStaticPaths: true,
+		MinDate:            time.Now().AddDate(0, 0, -warmConfig.Days).Format("2006-01-02"),
+		UseSummaries:       true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var specs []config.VideoSpec
+	seen := make(map[string]bool)
+	for _, endpoint := range result.TopEndpoints {
+		if len(specs) >= warmConfig.TopN {
+			break
+		}
+		if stats.EndpointCategory(endpoint.Path) != stats.CategoryVideo {
+			continue
+		}
+
+		// Same resolution as ServeVideo, different URLs can map to one file
+		inputParams, err := parser.ParseFilename(strings.TrimPrefix(endpoint.Path, "/"))
+		if err != nil || *inputParams == (config.VideoSpec{}) {
+			continue
+		}
+		spec := config.ApplyDefaultVideoSpec(inputParams)
+		filename := parser.GenerateFilename(&spec)
+		if seen[filename] || parser.FindExistingVideo(filename, &spec) != "" {
+			continue
+		}
+		seen[filename] = true
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+func isIdle() bool {
+	runtimeStats := stats.GetRuntimeStats()
+	return runtimeStats.ActiveTranscodes+runtimeStats.QueuedTranscodes == 0 && runtimeStats.ActiveRequests < warmMaxActiveRequests
+}
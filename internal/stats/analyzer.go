@@ -1,14 +1,15 @@
 package stats
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
 	"net/url"
-	"os"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mileusna/useragent"
@@ -21,55 +22,116 @@ type AnalyzerConfig struct {
 	ExcludeReferer     string // Filter out referrers containing this domain
 	MinDate            string // YYYY-MM-DD format, empty for all
 	MaxDate            string // YYYY-MM-DD format, empty for all
+	GeoDB              *GeoDB // Optional, resolves country/ASN for records logged without it
+	UseSummaries       bool   // Read/write summary-YYYY-MM-DD.json for past days instead of rescanning raw logs
+	Workers            int    // Log files processed in parallel, 0 for number of CPUs
+
+	// Raw-line filters, summaries are bypassed when any of these is set
+	PathPrefix  string         // Only paths starting with prefix, e.g. /hls/
+	PathRegex   *regexp.Regexp // Only paths matching regex
+	UAContains  string         // Only user agents containing this string (case-insensitive)
+	ExcludeBots bool           // Filter out user agents detected as bots/crawlers
 }
 
 type EndpointStat struct {
-	Path  string
-	Count int
-	Bytes int64
+	Path  string `json:"path"`
+	Count int    `json:"count"`
+	Bytes int64  `json:"bytes"`
 }
 
 type VisitorStat struct {
-	IP        string
-	UserAgent string
-	Browser   string // Detected browser/bot name
-	Requests  int
-	Bytes     int64
-	FirstSeen time.Time
-	LastSeen  time.Time
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Browser   string    `json:"browser"` // Detected browser/bot name
+	Country   string    `json:"country,omitempty"`
+	ASN       string    `json:"asn,omitempty"`
+	Requests  int       `json:"requests"`
+	Bytes     int64     `json:"bytes"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
 }
 
 type ReferrerStat struct {
-	Domain   string
-	FullURL  string
-	Count    int
-	LastSeen time.Time
+	Domain   string    `json:"domain"`
+	FullURL  string    `json:"fullUrl"`
+	Count    int       `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
 }
 
 type UserAgentStat struct {
-	UserAgent string
-	Count     int
-	IsBot     bool
+	UserAgent string `json:"userAgent"`
+	Count     int    `json:"count"`
+	IsBot     bool   `json:"isBot"`
 }
 
+// LatencyStat aggregates ResponseTime for an endpoint category
+type LatencyStat struct {
+	Category string  `json:"category"`
+	Count    int     `json:"count"`
+	AvgMs    float64 `json:"avgMs"`
+	P95Ms    int64   `json:"p95Ms"`
+	MaxMs    int64   `json:"maxMs"`
+}
+
+// DailyVisitorStat counts unique visitors (IP+UA) per day
+type DailyVisitorStat struct {
+	Date           string `json:"date"` // YYYY-MM-DD
+	UniqueVisitors int    `json:"uniqueVisitors"`
+	Requests       int    `json:"requests"`
+}
+
+// CountryStat aggregates traffic per GeoIP country
+type CountryStat struct {
+	Country        string `json:"country"`
+	Requests       int    `json:"requests"`
+	Bytes          int64  `json:"bytes"`
+	UniqueVisitors int    `json:"uniqueVisitors"`
+}
+
+// ASNStat aggregates traffic per autonomous system (hosting providers, ISPs)
+type ASNStat struct {
+	ASN            string `json:"asn"`
+	Org            string `json:"org,omitempty"`
+	Requests       int    `json:"requests"`
+	Bytes          int64  `json:"bytes"`
+	UniqueVisitors int    `json:"uniqueVisitors"`
+}
+
+// Endpoint categories used for latency breakdown
+const (
+	CategoryVideo       = "video"
+	CategoryHLSPlaylist = "hls-playlist"
+	CategoryHLSChunk    = "hls-chunk"
+	CategoryStatic      = "static"
+	CategoryAPI         = "api"
+	CategoryPage        = "page"
+)
+
 type AnalysisResult struct {
-	TotalRequests  int
-	UniqueVisitors int
-	TotalBytes     int64
-	DateRange      string
-
-	TopEndpoints     []EndpointStat
-	TopVisitors      []VisitorStat
-	TopReferrers     []ReferrerStat
-	FullReferrerURLs []ReferrerStat
-	UserAgents       []UserAgentStat
-	Bots             []UserAgentStat
+	TotalRequests  int    `json:"totalRequests"`
+	UniqueVisitors int    `json:"uniqueVisitors"`
+	TotalBytes     int64  `json:"totalBytes"`
+	DateRange      string `json:"dateRange"`
+
+	TopEndpoints     []EndpointStat          `json:"topEndpoints"`
+	TopVisitors      []VisitorStat           `json:"topVisitors"`
+	TopReferrers     []ReferrerStat          `json:"topReferrers"`
+	FullReferrerURLs []ReferrerStat          `json:"fullReferrerUrls"`
+	UserAgents       []UserAgentStat         `json:"userAgents"`
+	Bots             []UserAgentStat         `json:"bots"`
+	EndpointLatency  []LatencyStat           `json:"endpointLatency"`
+	DailyVisitors    []DailyVisitorStat      `json:"dailyVisitors"`
+	Countries        []CountryStat           `json:"countries"`
+	ASNs             []ASNStat               `json:"asns"`
+	HLSSessions      HLSSessionStats         `json:"hlsSessions"`
+	CacheSources     map[string]int          `json:"cacheSources"` // Video requests by X-Cache source
+	Tenants          map[string]*TenantUsage `json:"tenants"`      // Usage per API key namespace
 
 	// Quick insights
-	VideoRequests   int
-	StaticRequests  int
-	PartialRequests int
-	ErrorRequests   int
+	VideoRequests   int `json:"videoRequests"`
+	StaticRequests  int `json:"staticRequests"`
+	PartialRequests int `json:"partialRequests"`
+	ErrorRequests   int `json:"errorRequests"`
 }
 
 func AnalyzeStats(analyzerConfig AnalyzerConfig) (*AnalysisResult, error) {
@@ -85,43 +147,76 @@ func AnalyzeStats(analyzerConfig AnalyzerConfig) (*AnalysisResult, error) {
 		return &AnalysisResult{}, nil
 	}
 
-	result := &AnalysisResult{
-		TopEndpoints:     make([]EndpointStat, 0),
-		TopVisitors:      make([]VisitorStat, 0),
-		TopReferrers:     make([]ReferrerStat, 0),
-		FullReferrerURLs: make([]ReferrerStat, 0),
-		UserAgents:       make([]UserAgentStat, 0),
-		Bots:             make([]UserAgentStat, 0),
-	}
+	total := newDailySummary("", analyzerConfig.summaryFilters())
+	daily := make([]DailyVisitorStat, 0, len(files))
 
-	// Maps for aggregation
-	endpoints := make(map[string]*EndpointStat)
-	visitors := make(map[string]*VisitorStat) // key: IP+UA
-	referrers := make(map[string]*ReferrerStat)
-	fullReferrers := make(map[string]*ReferrerStat)
-	userAgents := make(map[string]*UserAgentStat)
+	// Process log files in parallel, past days are read from precomputed summaries when enabled
+	summaries := make([]*DailySummary, len(files))
+	workers := analyzerConfig.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
 
-	var minDate, maxDate time.Time
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for i, file := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			summary, err := loadOrBuildSummary(file, analyzerConfig)
+			if err != nil {
+				fmt.Printf("Warning: Error processing %s: %v\n", file, err)
+				return
+			}
+			summaries[i] = summary
+		}()
+	}
+	wg.Wait()
 
-	// Process all log files
-	for _, file := range files {
-		err := processLogFile(file, analyzerConfig, result, endpoints, visitors, referrers, fullReferrers, userAgents, &minDate, &maxDate)
-		if err != nil {
-			fmt.Printf("Warning: Error processing %s: %v\n", file, err)
+	// Merge partial aggregates in file (date) order
+	for _, summary := range summaries {
+		if summary == nil {
 			continue
 		}
+
+		if summary.TotalRequests > 0 {
+			daily = append(daily, DailyVisitorStat{
+				Date:           summary.Date,
+				UniqueVisitors: summary.UniqueVisitors(),
+				Requests:       summary.TotalRequests,
+			})
+		}
+		total.merge(summary)
+	}
+
+	result := &AnalysisResult{
+		TotalRequests:   total.TotalRequests,
+		UniqueVisitors:  total.UniqueVisitors(),
+		TotalBytes:      total.TotalBytes,
+		VideoRequests:   total.VideoRequests,
+		StaticRequests:  total.StaticRequests,
+		PartialRequests: total.PartialRequests,
+		ErrorRequests:   total.ErrorRequests,
+		DailyVisitors:   daily,
+		CacheSources:    total.CacheSources,
+		Tenants:         total.Tenants,
 	}
 
 	// Convert maps to sorted slices
-	result.TopEndpoints = sortEndpoints(endpoints)
-	result.TopVisitors = sortVisitors(visitors)
-	result.TopReferrers = sortReferrers(referrers)
-	result.FullReferrerURLs = sortReferrers(fullReferrers)
-	result.UserAgents, result.Bots = sortUserAgents(userAgents)
-
-	result.UniqueVisitors = len(visitors)
-	if !minDate.IsZero() && !maxDate.IsZero() {
-		result.DateRange = fmt.Sprintf("%s to %s", minDate.Format("2006-01-02"), maxDate.Format("2006-01-02"))
+	result.TopEndpoints = sortEndpoints(total.Endpoints)
+	result.TopVisitors = sortVisitors(total.Visitors)
+	result.TopReferrers = sortReferrers(total.Referrers)
+	result.FullReferrerURLs = sortReferrers(total.FullReferrers)
+	result.UserAgents, result.Bots = sortUserAgents(total.UserAgents)
+	result.EndpointLatency = summarizeLatencies(total.Latencies)
+	result.Countries, result.ASNs = total.geoStats()
+	result.HLSSessions = summarizeSessions(total.HLSSessions)
+
+	if !total.FirstRequest.IsZero() && !total.LastRequest.IsZero() {
+		result.DateRange = fmt.Sprintf("%s to %s", total.FirstRequest.Format("2006-01-02"), total.LastRequest.Format("2006-01-02"))
 	}
 
 	return result, nil
@@ -157,146 +252,126 @@ func findLogFiles(logDir string, config AnalyzerConfig) ([]string, error) {
 	return filtered, nil
 }
 
-func processLogFile(filename string, config AnalyzerConfig, result *AnalysisResult,
-	endpoints map[string]*EndpointStat, visitors map[string]*VisitorStat,
-	referrers map[string]*ReferrerStat, fullReferrers map[string]*ReferrerStat,
-	userAgents map[string]*UserAgentStat, minDate *time.Time, maxDate *time.Time) error {
-
-	file, err := os.Open(filename)
-	if err != nil {
-		return err
+// includes applies record filters from config
+func (config AnalyzerConfig) includes(stat *RequestStats) bool {
+	if config.ExcludeStaticPaths && strings.HasPrefix(stat.Path, "/web/") {
+		return false
 	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		var stat RequestStats
-		if err := json.Unmarshal(scanner.Bytes(), &stat); err != nil {
-			continue // Skip malformed lines
-		}
-
-		// Apply filters
-		if config.ExcludeStaticPaths && strings.HasPrefix(stat.Path, "/web/") {
-			continue
-		}
-		if config.ExcludePartial && stat.Status == 206 {
-			continue
-		}
-		if config.ExcludeReferer != "" && stat.Referer != "" {
-			referrerDomain := extractDomain(stat.Referer)
-			if strings.Contains(referrerDomain, config.ExcludeReferer) {
-				continue
-			}
-		}
-
-		// Track date range
-		if minDate.IsZero() || stat.Timestamp.Before(*minDate) {
-			*minDate = stat.Timestamp
-		}
-		if maxDate.IsZero() || stat.Timestamp.After(*maxDate) {
-			*maxDate = stat.Timestamp
+	if config.ExcludePartial && stat.Status == 206 {
+		return false
+	}
+	if config.ExcludeReferer != "" && stat.Referer != "" {
+		referrerDomain := extractDomain(stat.Referer)
+		if strings.Contains(referrerDomain, config.ExcludeReferer) {
+			return false
 		}
+	}
+	if config.PathPrefix != "" && !strings.HasPrefix(stat.Path, config.PathPrefix) {
+		return false
+	}
+	if config.PathRegex != nil && !config.PathRegex.MatchString(stat.Path) {
+		return false
+	}
+	if config.UAContains != "" && !strings.Contains(strings.ToLower(stat.UserAgent), strings.ToLower(config.UAContains)) {
+		return false
+	}
+	if config.ExcludeBots && isBot(stat.UserAgent) {
+		return false
+	}
+	return true
+}
 
-		result.TotalRequests++
-		result.TotalBytes += stat.ResponseSize
-
-		// Categorize requests
-		categorizeRequest(&stat, result)
+// needsRawScan reports whether filters can't be answered from daily summaries
+func (config AnalyzerConfig) needsRawScan() bool {
+	return config.PathPrefix != "" || config.PathRegex != nil || config.UAContains != "" || config.ExcludeBots
+}
 
-		normalizedPath := stat.Path
-		if normalizedPath == "" {
-			normalizedPath = "/"
-		}
-		if ep, exists := endpoints[normalizedPath]; exists {
-			ep.Count++
-			ep.Bytes += stat.ResponseSize
-		} else {
-			endpoints[normalizedPath] = &EndpointStat{
-				Path:  normalizedPath,
-				Count: 1,
-				Bytes: stat.ResponseSize,
-			}
-		}
+// EndpointCategory groups request paths for latency breakdown
+func EndpointCategory(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/web/"), path == "/sitemap.xml", path == "/robots.txt":
+		return CategoryStatic
+	case strings.HasPrefix(path, "/hls/") && strings.HasSuffix(path, ".m3u8"):
+		return CategoryHLSPlaylist
+	case strings.HasPrefix(path, "/hls/"):
+		return CategoryHLSChunk
+	case strings.HasPrefix(path, "/api/"), strings.HasPrefix(path, "/getInfo/"), strings.HasPrefix(path, "/transcode/"), strings.HasPrefix(path, "/simulate/"),
+		path == "/batch", path == "/random", path == "/playlist.m3u", path == "/version":
+		return CategoryAPI
+	case path == "" || path == "/":
+		return CategoryPage
+	default:
+		return CategoryVideo
+	}
+}
 
-		// Track visitors (by IP + UA combination for better uniqueness)
-		visitorKey := stat.IP + "|" + stat.UserAgent
-		if visitor, exists := visitors[visitorKey]; exists {
-			visitor.Requests++
-			visitor.Bytes += stat.ResponseSize
-			visitor.LastSeen = stat.Timestamp
-		} else {
-			visitors[visitorKey] = &VisitorStat{
-				IP:        stat.IP,
-				UserAgent: stat.UserAgent,
-				Browser:   ExtractBrowserName(stat.UserAgent),
-				Requests:  1,
-				Bytes:     stat.ResponseSize,
-				FirstSeen: stat.Timestamp,
-				LastSeen:  stat.Timestamp,
-			}
-		}
+var hlsChunkSequence = regexp.MustCompile(`media\.(\d+)\.mp4$`)
 
-		// Track referrers
-		if stat.Referer != "" {
-			// Full URL tracking
-			if ref, exists := fullReferrers[stat.Referer]; exists {
-				ref.Count++
-				ref.LastSeen = stat.Timestamp
-			} else {
-				fullReferrers[stat.Referer] = &ReferrerStat{
-					Domain:   extractDomain(stat.Referer),
-					FullURL:  stat.Referer,
-					Count:    1,
-					LastSeen: stat.Timestamp,
-				}
-			}
+// EndpointPath collapses HLS chunk sequence numbers, so looping live chunks count as one endpoint
+func EndpointPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	if EndpointCategory(path) == CategoryHLSChunk {
+		return hlsChunkSequence.ReplaceAllString(path, "media.N.mp4")
+	}
+	return path
+}
 
-			// Domain aggregation
-			domain := extractDomain(stat.Referer)
-			if domain != "" {
-				if ref, exists := referrers[domain]; exists {
-					ref.Count++
-					ref.LastSeen = stat.Timestamp
-				} else {
-					referrers[domain] = &ReferrerStat{
-						Domain:   domain,
-						FullURL:  domain,
-						Count:    1,
-						LastSeen: stat.Timestamp,
-					}
-				}
-			}
+func summarizeLatencies(latencies map[string]map[int64]int) []LatencyStat {
+	var result []LatencyStat
+	for category, histogram := range latencies {
+		values := make([]int64, 0, len(histogram))
+		count := 0
+		var total int64
+		for ms, n := range histogram {
+			values = append(values, ms)
+			count += n
+			total += ms * int64(n)
 		}
-
-		// Track user agents
-		if ua, exists := userAgents[stat.UserAgent]; exists {
-			ua.Count++
-		} else {
-			userAgents[stat.UserAgent] = &UserAgentStat{
-				UserAgent: stat.UserAgent,
-				Count:     1,
-				IsBot:     isBot(stat.UserAgent),
-			}
+		if count == 0 {
+			continue
 		}
+		slices.Sort(values)
+
+		result = append(result, LatencyStat{
+			Category: category,
+			Count:    count,
+			AvgMs:    float64(total) / float64(count),
+			P95Ms:    histogramPercentile(histogram, values, count, 95),
+			MaxMs:    values[len(values)-1],
+		})
 	}
-
-	return scanner.Err()
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+	return result
 }
 
-func categorizeRequest(stat *RequestStats, result *AnalysisResult) {
-	if strings.HasPrefix(stat.Path, "/web/") {
-		result.StaticRequests++
-	} else if strings.HasPrefix(stat.Path, "/") && !strings.HasPrefix(stat.Path, "/info/") {
-		result.VideoRequests++
+// percentile uses nearest-rank method, sorted must be ascending and not empty
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
 	}
+	return sorted[rank-1]
+}
 
-	if stat.Status == 206 {
-		result.PartialRequests++
+// histogramPercentile is nearest-rank percentile over ms -> count histogram, values are sorted histogram keys
+func histogramPercentile(histogram map[int64]int, values []int64, count int, p int) int64 {
+	rank := (p*count + 99) / 100
+	if rank < 1 {
+		rank = 1
 	}
 
-	if stat.Status >= 400 {
-		result.ErrorRequests++
+	seen := 0
+	for _, ms := range values {
+		seen += histogram[ms]
+		if seen >= rank {
+			return ms
+		}
 	}
+	return values[len(values)-1]
 }
 
 func extractDomain(referrer string) string {
@@ -0,0 +1,267 @@
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEndpointCategory(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"/", CategoryPage},
+		{"/web/styles.css", CategoryStatic},
+		{"/robots.txt", CategoryStatic},
+		{"/hls/bunny/playlist.m3u8", CategoryHLSPlaylist},
+		{"/hls/bunny/720p/media.m3u8", CategoryHLSPlaylist},
+		{"/hls/bunny/720p/media.1679654321.mp4", CategoryHLSChunk},
+		{"/hls/bunny/720p/init.mp4", CategoryHLSChunk},
+		{"/getInfo/bunny.mp4", CategoryAPI},
+		{"/api/stats/runtime", CategoryAPI},
+		{"/720p_h264_10s", CategoryVideo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := EndpointCategory(tt.path); got != tt.want {
+				t.Errorf("EndpointCategory(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEndpointPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"":                                     "/",
+		"/hls/bunny/720p/media.1679654321.mp4": "/hls/bunny/720p/media.N.mp4",
+		"/hls/bunny/720p/init.mp4":             "/hls/bunny/720p/init.mp4",
+		"/media.5.mp4":                         "/media.5.mp4",
+	} {
+		if got := EndpointPath(path); got != want {
+			t.Errorf("EndpointPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestSummarizeLatencies(t *testing.T) {
+	// 1..100ms once each, 50ms twice more
+	samples := make(map[int64]int)
+	for i := int64(100); i >= 1; i-- {
+		samples[i]++
+	}
+	samples[50] += 2
+
+	result := summarizeLatencies(map[string]map[int64]int{
+		CategoryVideo:  samples,
+		CategoryStatic: {5: 1},
+	})
+
+	if len(result) != 2 {
+		t.Fatalf("expected 2 categories, got %d", len(result))
+	}
+
+	video := result[0]
+	if video.Category != CategoryVideo || video.Count != 102 {
+		t.Fatalf("expected video category with 102 samples first, got %+v", video)
+	}
+	if video.AvgMs != 5150.0/102 {
+		t.Errorf("AvgMs = %v, want %v", video.AvgMs, 5150.0/102)
+	}
+	// rank ceil(0.95*102) = 97, two extra 50ms samples shift it to 95ms
+	if video.P95Ms != 95 {
+		t.Errorf("P95Ms = %v, want 95", video.P95Ms)
+	}
+	if video.MaxMs != 100 {
+		t.Errorf("MaxMs = %v, want 100", video.MaxMs)
+	}
+
+	if result[1].P95Ms != 5 {
+		t.Errorf("single sample P95Ms = %v, want 5", result[1].P95Ms)
+	}
+}
+
+func writeLogFile(t *testing.T, dir, date string, records []RequestStats) {
+	t.Helper()
+
+	var lines []byte
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, append(data, '\n')...)
+	}
+
+	path := filepath.Join(dir, "stats-"+date+".jsonl")
+	if err := os.WriteFile(path, lines, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAnalyzeStatsDailyVisitors(t *testing.T) {
+	dir := t.TempDir()
+	day1 := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	writeLogFile(t, dir, "2025-12-01", []RequestStats{
+		{Timestamp: day1, Path: "/720p", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: day1, Path: "/480p", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: day1, Path: "/480p", IP: "2.2.2.2", UserAgent: "b", Status: 200},
+	})
+	writeLogFile(t, dir, "2025-12-02", []RequestStats{
+		{Timestamp: day2, Path: "/720p", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+	})
+
+	result, err := AnalyzeStats(AnalyzerConfig{LogDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.UniqueVisitors != 2 {
+		t.Errorf("UniqueVisitors = %d, want 2", result.UniqueVisitors)
+	}
+
+	want := []DailyVisitorStat{
+		{Date: "2025-12-01", UniqueVisitors: 2, Requests: 3},
+		{Date: "2025-12-02", UniqueVisitors: 1, Requests: 1},
+	}
+	if !reflect.DeepEqual(result.DailyVisitors, want) {
+		t.Errorf("DailyVisitors = %+v, want %+v", result.DailyVisitors, want)
+	}
+}
+
+func TestExportInflux(t *testing.T) {
+	dir := t.TempDir()
+	ts := time.Date(2025, 12, 1, 10, 15, 0, 0, time.UTC)
+
+	writeLogFile(t, dir, "2025-12-01", []RequestStats{
+		{Timestamp: ts, Path: "/720p", IP: "1.1.1.1", UserAgent: "a", Status: 200, ResponseTime: 10, ResponseSize: 100},
+		{Timestamp: ts.Add(time.Minute), Path: "/480p", IP: "1.1.1.1", UserAgent: "a", Status: 404, ResponseTime: 30, ResponseSize: 0},
+		{Timestamp: ts, Path: "/hls/bunny/playlist.m3u8", IP: "2.2.2.2", UserAgent: "b", Status: 200, ResponseTime: 5, ResponseSize: 50},
+	})
+
+	var out strings.Builder
+	if err := ExportInflux(&out, AnalyzerConfig{LogDir: dir}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	hour := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC).UnixNano()
+	want := fmt.Sprintf("lorem_video,category=hls-playlist requests=1i,bytes=50i,errors=0i,visitors=1i,latency_avg_ms=5.00,latency_p95_ms=5i,latency_max_ms=5i %d\n", hour) +
+		fmt.Sprintf("lorem_video,category=video requests=2i,bytes=100i,errors=1i,visitors=1i,latency_avg_ms=20.00,latency_p95_ms=30i,latency_max_ms=30i %d\n", hour)
+
+	if out.String() != want {
+		t.Errorf("ExportInflux output:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+func TestAnalyzeStatsSummaries(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	writeLogFile(t, dir, "2025-12-01", []RequestStats{
+		{Timestamp: day, Path: "/720p", IP: "1.1.1.1", UserAgent: "a", Status: 200, ResponseTime: 10, ResponseSize: 100, Country: "LV"},
+		{Timestamp: day, Path: "/720p", IP: "1.1.1.1", UserAgent: "a", Status: 200, ResponseTime: 12, ResponseSize: 100, Country: "LV"},
+		{Timestamp: day, Path: "/web/styles.css", IP: "1.1.1.1", UserAgent: "a", Status: 200, ResponseSize: 10, Country: "LV"},
+		{Timestamp: day, Path: "/480p", IP: "2.2.2.2", UserAgent: "b", Status: 206, ResponseTime: 20, ResponseSize: 50},
+	})
+
+	analyzerConfig := AnalyzerConfig{LogDir: dir, ExcludeStaticPaths: true, UseSummaries: true}
+
+	raw, err := AnalyzeStats(analyzerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summaryPath := filepath.Join(dir, "summary-2025-12-01.json")
+	if _, err := os.Stat(summaryPath); err != nil {
+		t.Fatalf("expected summary file to be written: %v", err)
+	}
+
+	// Remove raw log content, second run must come from summary
+	logPath := filepath.Join(dir, "stats-2025-12-01.jsonl")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(logPath, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	cached, err := AnalyzeStats(analyzerConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(normalizeTimes(raw), normalizeTimes(cached)) {
+		t.Errorf("summary result differs from raw:\nraw:    %+v\ncached: %+v", raw, cached)
+	}
+	if cached.TotalRequests != 3 || cached.PartialRequests != 1 {
+		t.Errorf("unexpected totals: %+v", cached)
+	}
+	if len(cached.Countries) != 2 {
+		t.Errorf("expected LV and unknown countries, got %+v", cached.Countries)
+	}
+
+	// Different filters must not reuse summary
+	other, err := AnalyzeStats(AnalyzerConfig{LogDir: dir, UseSummaries: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.TotalRequests != 0 {
+		t.Errorf("expected raw rescan with different filters, got %d requests", other.TotalRequests)
+	}
+}
+
+// normalizeTimes drops monotonic clock readings so JSON-decoded results compare equal
+func normalizeTimes(result *AnalysisResult) *AnalysisResult {
+	data, _ := json.Marshal(result)
+	var normalized AnalysisResult
+	json.Unmarshal(data, &normalized)
+	return &normalized
+}
+
+func TestAnalyzeStatsRawFilters(t *testing.T) {
+	dir := t.TempDir()
+	day := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+
+	writeLogFile(t, dir, "2025-12-01", []RequestStats{
+		{Timestamp: day, Path: "/720p_av1", IP: "1.1.1.1", Status: 200, UserAgent: "Googlebot/2.1 (+http://www.google.com/bot.html)"},
+		{Timestamp: day, Path: "/720p_h264", IP: "1.1.1.1", Status: 200, UserAgent: "ExoPlayerLib/2.19.1"},
+		{Timestamp: day, Path: "/hls/bunny/720p/media.1.mp4", IP: "1.1.1.1", Status: 200},
+		{Timestamp: day, Path: "/hls/bunny/playlist.m3u8", IP: "1.1.1.1", Status: 200},
+	})
+
+	tests := []struct {
+		name   string
+		config AnalyzerConfig
+		want   int
+	}{
+		{"no filter", AnalyzerConfig{}, 4},
+		{"prefix", AnalyzerConfig{PathPrefix: "/hls/"}, 2},
+		{"regex", AnalyzerConfig{PathRegex: regexp.MustCompile(`_av1`)}, 1},
+		{"prefix and regex", AnalyzerConfig{PathPrefix: "/hls/", PathRegex: regexp.MustCompile(`/media\.\d+\.mp4$`)}, 1},
+		{"ua contains", AnalyzerConfig{UAContains: "exoplayer"}, 1},
+		{"exclude bots", AnalyzerConfig{ExcludeBots: true}, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.LogDir = dir
+			tt.config.UseSummaries = true
+			result, err := AnalyzeStats(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.TotalRequests != tt.want {
+				t.Errorf("TotalRequests = %d, want %d", result.TotalRequests, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package stats
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// defaultTrustedProxies covers reverse proxy on same host or docker network
+var defaultTrustedProxies = []netip.Prefix{
+	netip.MustParsePrefix("127.0.0.0/8"),
+	netip.MustParsePrefix("::1/128"),
+	netip.MustParsePrefix("10.0.0.0/8"),
+	netip.MustParsePrefix("172.16.0.0/12"),
+	netip.MustParsePrefix("192.168.0.0/16"),
+	netip.MustParsePrefix("fc00::/7"),
+}
+
+var trustedProxies = defaultTrustedProxies
+
+// TrustedProxiesFromEnv parses TRUSTED_PROXIES, comma separated IPs/CIDRs or "none". Loopback and private ranges when unset
+func TrustedProxiesFromEnv() []netip.Prefix {
+	value := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	if value == "" {
+		return defaultTrustedProxies
+	}
+	if value == "none" {
+		return nil
+	}
+
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil {
+			prefixes = append(prefixes, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(entry); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+		} else {
+			log.Printf("Warning: invalid TRUSTED_PROXIES entry %q, ignoring", entry)
+		}
+	}
+	return prefixes
+}
+
+func SetTrustedProxies(prefixes []netip.Prefix) {
+	trustedProxies = prefixes
+}
+
+// GetRealIP returns client IP. X-Forwarded-For/X-Real-IP are honored only from trusted proxies and XFF is read
+// from the right, first entry is whatever client sent and can't be used to identify it
+func GetRealIP(r *http.Request) string {
+	remote, ok := parseIP(r.RemoteAddr)
+	if !ok {
+		return strings.TrimSpace(r.RemoteAddr)
+	}
+	if !trustedProxy(remote) {
+		return remote.String()
+	}
+
+	if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		client := remote
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop, ok := parseIP(hops[i])
+			if !ok {
+				break
+			}
+			client = hop
+			if !trustedProxy(hop) {
+				break
+			}
+		}
+		return client.String()
+	}
+	if realIP, ok := parseIP(r.Header.Get("X-Real-IP")); ok {
+		return realIP.String()
+	}
+	return remote.String()
+}
+
+// parseIP accepts address with or without port
+func parseIP(value string) (netip.Addr, bool) {
+	value = strings.TrimSpace(value)
+	if host, _, err := net.SplitHostPort(value); err == nil {
+		value = host
+	}
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+func trustedProxy(addr netip.Addr) bool {
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetRealIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		remote    string
+		forwarded string
+		realIP    string
+		want      string
+	}{
+		{"direct client", "203.0.113.5:4000", "", "", "203.0.113.5"},
+		{"untrusted peer can't spoof", "203.0.113.5:4000", "198.51.100.1", "198.51.100.1", "203.0.113.5"},
+		{"proxy appended hop", "172.18.0.3:4000", "198.51.100.1, 203.0.113.5", "", "203.0.113.5"},
+		{"proxy chain", "127.0.0.1:4000", "203.0.113.5, 10.0.0.2", "", "203.0.113.5"},
+		{"garbage hop", "127.0.0.1:4000", "203.0.113.5, nonsense", "", "127.0.0.1"},
+		{"real ip from proxy", "127.0.0.1:4000", "", "203.0.113.5", "203.0.113.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remote
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+			if got := GetRealIP(r); got != tt.want {
+				t.Errorf("GetRealIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrustedProxiesFromEnv(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.1.0.0/16, 192.0.2.7, bogus")
+	prefixes := TrustedProxiesFromEnv()
+	if len(prefixes) != 2 || prefixes[1].Bits() != 32 {
+		t.Errorf("unexpected prefixes: %v", prefixes)
+	}
+
+	t.Setenv("TRUSTED_PROXIES", "none")
+	if prefixes := TrustedProxiesFromEnv(); len(prefixes) != 0 {
+		t.Errorf("none should trust nothing, got %v", prefixes)
+	}
+}
@@ -0,0 +1,130 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+type MetricDelta struct {
+	Name      string   `json:"name"`
+	Current   int64    `json:"current"`
+	Previous  int64    `json:"previous"`
+	Change    int64    `json:"change"`
+	ChangePct *float64 `json:"changePct"` // nil when previous is 0
+}
+
+type EndpointDelta struct {
+	Path     string `json:"path"`
+	Current  int    `json:"current"`
+	Previous int    `json:"previous"`
+	Change   int    `json:"change"`
+}
+
+// Comparison holds side-by-side deltas between two analysis ranges
+type Comparison struct {
+	CurrentRange  string          `json:"currentRange"`
+	PreviousRange string          `json:"previousRange"`
+	Metrics       []MetricDelta   `json:"metrics"`
+	TopEndpoints  []EndpointDelta `json:"topEndpoints"`
+}
+
+// PreviousRange returns range of equal length right before minDate..maxDate (YYYY-MM-DD, inclusive)
+func PreviousRange(minDate, maxDate string) (string, string, error) {
+	minTime, err := time.Parse("2006-01-02", minDate)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid min date: %w", err)
+	}
+
+	maxTime := time.Now()
+	if maxDate != "" {
+		maxTime, err = time.Parse("2006-01-02", maxDate)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid max date: %w", err)
+		}
+	}
+	maxTime = time.Date(maxTime.Year(), maxTime.Month(), maxTime.Day(), 0, 0, 0, 0, time.UTC)
+
+	if maxTime.Before(minTime) {
+		return "", "", fmt.Errorf("max date %s is before min date %s", maxDate, minDate)
+	}
+
+	days := int(maxTime.Sub(minTime).Hours()/24) + 1
+	prevMax := minTime.AddDate(0, 0, -1)
+	prevMin := minTime.AddDate(0, 0, -days)
+
+	return prevMin.Format("2006-01-02"), prevMax.Format("2006-01-02"), nil
+}
+
+// Compare builds deltas between current and previous results, endpoints are the union of both top N
+func Compare(current, previous *AnalysisResult, topN int) Comparison {
+	comparison := Comparison{
+		CurrentRange:  current.DateRange,
+		PreviousRange: previous.DateRange,
+		Metrics: []MetricDelta{
+			newMetricDelta("Total Requests", int64(current.TotalRequests), int64(previous.TotalRequests)),
+			newMetricDelta("Unique Visitors", int64(current.UniqueVisitors), int64(previous.UniqueVisitors)),
+			newMetricDelta("Total Bytes", current.TotalBytes, previous.TotalBytes),
+			newMetricDelta("Video Requests", int64(current.VideoRequests), int64(previous.VideoRequests)),
+			newMetricDelta("Error Requests", int64(current.ErrorRequests), int64(previous.ErrorRequests)),
+		},
+	}
+
+	currentCounts := make(map[string]int)
+	for _, ep := range current.TopEndpoints {
+		currentCounts[ep.Path] = ep.Count
+	}
+	previousCounts := make(map[string]int)
+	for _, ep := range previous.TopEndpoints {
+		previousCounts[ep.Path] = ep.Count
+	}
+
+	paths := make(map[string]struct{})
+	for i, ep := range current.TopEndpoints {
+		if i >= topN {
+			break
+		}
+		paths[ep.Path] = struct{}{}
+	}
+	for i, ep := range previous.TopEndpoints {
+		if i >= topN {
+			break
+		}
+		paths[ep.Path] = struct{}{}
+	}
+
+	for path := range paths {
+		comparison.TopEndpoints = append(comparison.TopEndpoints, EndpointDelta{
+			Path:     path,
+			Current:  currentCounts[path],
+			Previous: previousCounts[path],
+			Change:   currentCounts[path] - previousCounts[path],
+		})
+	}
+	sort.Slice(comparison.TopEndpoints, func(i, j int) bool {
+		a, b := comparison.TopEndpoints[i], comparison.TopEndpoints[j]
+		if a.Current != b.Current {
+			return a.Current > b.Current
+		}
+		if a.Previous != b.Previous {
+			return a.Previous > b.Previous
+		}
+		return a.Path < b.Path
+	})
+
+	return comparison
+}
+
+func newMetricDelta(name string, current, previous int64) MetricDelta {
+	delta := MetricDelta{
+		Name:     name,
+		Current:  current,
+		Previous: previous,
+		Change:   current - previous,
+	}
+	if previous != 0 {
+		pct := float64(current-previous) / float64(previous) * 100
+		delta.ChangePct = &pct
+	}
+	return delta
+}
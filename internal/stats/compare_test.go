@@ -0,0 +1,63 @@
+package stats
+
+import "testing"
+
+func TestPreviousRange(t *testing.T) {
+	tests := []struct {
+		minDate, maxDate string
+		wantMin, wantMax string
+	}{
+		{"2025-12-08", "2025-12-14", "2025-12-01", "2025-12-07"},
+		{"2025-12-01", "2025-12-01", "2025-11-30", "2025-11-30"},
+		{"2025-03-01", "2025-03-31", "2025-01-29", "2025-02-28"},
+	}
+
+	for _, tt := range tests {
+		gotMin, gotMax, err := PreviousRange(tt.minDate, tt.maxDate)
+		if err != nil {
+			t.Fatalf("PreviousRange(%s, %s) error: %v", tt.minDate, tt.maxDate, err)
+		}
+		if gotMin != tt.wantMin || gotMax != tt.wantMax {
+			t.Errorf("PreviousRange(%s, %s) = %s..%s, want %s..%s", tt.minDate, tt.maxDate, gotMin, gotMax, tt.wantMin, tt.wantMax)
+		}
+	}
+
+	if _, _, err := PreviousRange("2025-12-14", "2025-12-08"); err == nil {
+		t.Error("expected error for inverted range")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	current := &AnalysisResult{
+		TotalRequests: 150,
+		TopEndpoints:  []EndpointStat{{Path: "/720p", Count: 100}, {Path: "/new", Count: 50}},
+	}
+	previous := &AnalysisResult{
+		TotalRequests: 100,
+		TopEndpoints:  []EndpointStat{{Path: "/720p", Count: 80}, {Path: "/gone", Count: 20}},
+	}
+
+	comparison := Compare(current, previous, 10)
+
+	total := comparison.Metrics[0]
+	if total.Change != 50 || total.ChangePct == nil || *total.ChangePct != 50 {
+		t.Errorf("unexpected total requests delta: %+v", total)
+	}
+	if visitors := comparison.Metrics[1]; visitors.ChangePct != nil {
+		t.Errorf("expected nil percentage for zero previous value, got %v", *visitors.ChangePct)
+	}
+
+	want := []EndpointDelta{
+		{Path: "/720p", Current: 100, Previous: 80, Change: 20},
+		{Path: "/new", Current: 50, Previous: 0, Change: 50},
+		{Path: "/gone", Current: 0, Previous: 20, Change: -20},
+	}
+	if len(comparison.TopEndpoints) != len(want) {
+		t.Fatalf("got %d endpoints, want %d", len(comparison.TopEndpoints), len(want))
+	}
+	for i := range want {
+		if comparison.TopEndpoints[i] != want[i] {
+			t.Errorf("endpoint %d = %+v, want %+v", i, comparison.TopEndpoints[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package stats
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GeoIP databases are optional CSV range files (db-ip.com lite format) placed in the geoip data dir:
+// country.csv - start_ip,end_ip,country_code
+// asn.csv     - start_ip,end_ip,asn,org
+const (
+	GeoIPCountryFile = "country.csv"
+	GeoIPASNFile     = "asn.csv"
+)
+
+type GeoInfo struct {
+	Country string
+	ASN     string
+	Org     string
+}
+
+type GeoDB struct {
+	countries []geoRange
+	asns      []geoRange
+}
+
+type geoRange struct {
+	start  netip.Addr
+	end    netip.Addr
+	values []string
+}
+
+// LoadGeoDB reads country/ASN range files from dir, missing files are skipped
+func LoadGeoDB(dir string) (*GeoDB, error) {
+	db := &GeoDB{}
+
+	countries, err := loadGeoRanges(filepath.Join(dir, GeoIPCountryFile), 1)
+	if err != nil {
+		return nil, err
+	}
+	db.countries = countries
+
+	asns, err := loadGeoRanges(filepath.Join(dir, GeoIPASNFile), 2)
+	if err != nil {
+		return nil, err
+	}
+	db.asns = asns
+
+	return db, nil
+}
+
+// Empty reports whether no GeoIP data was loaded
+func (db *GeoDB) Empty() bool {
+	return db == nil || (len(db.countries) == 0 && len(db.asns) == 0)
+}
+
+func (db *GeoDB) Lookup(ip string) GeoInfo {
+	var info GeoInfo
+	if db.Empty() {
+		return info
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return info
+	}
+	addr = addr.Unmap()
+
+	if values := findGeoRange(db.countries, addr); values != nil {
+		info.Country = strings.ToUpper(values[0])
+	}
+	if values := findGeoRange(db.asns, addr); values != nil {
+		info.ASN = values[0]
+		if len(values) > 1 {
+			info.Org = values[1]
+		}
+	}
+
+	return info
+}
+
+func loadGeoRanges(path string, valueColumns int) ([]geoRange, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open geoip file: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	var ranges []geoRange
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		if len(record) < 2+valueColumns {
+			continue // Skip header or malformed lines
+		}
+
+		start, err1 := netip.ParseAddr(record[0])
+		end, err2 := netip.ParseAddr(record[1])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+
+		ranges = append(ranges, geoRange{
+			start:  start.Unmap(),
+			end:    end.Unmap(),
+			values: record[2 : 2+valueColumns],
+		})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].start.Less(ranges[j].start)
+	})
+
+	return ranges, nil
+}
+
+func findGeoRange(ranges []geoRange, addr netip.Addr) []string {
+	// First range starting after addr, candidate is the one before it
+	i := sort.Search(len(ranges), func(i int) bool {
+		return addr.Less(ranges[i].start)
+	})
+	if i == 0 {
+		return nil
+	}
+
+	candidate := ranges[i-1]
+	if candidate.end.Less(addr) {
+		return nil
+	}
+	return candidate.values
+}
@@ -0,0 +1,85 @@
+package stats
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGeoDBLookup(t *testing.T) {
+	dir := t.TempDir()
+
+	countryCSV := "start_ip,end_ip,country\n" +
+		"1.0.0.0,1.0.0.255,au\n" +
+		"8.8.8.0,8.8.8.255,US\n" +
+		"2001:db8::,2001:db8::ffff,LV\n"
+	asnCSV := "8.8.8.0,8.8.8.255,15169,Google LLC\n"
+
+	if err := os.WriteFile(filepath.Join(dir, GeoIPCountryFile), []byte(countryCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, GeoIPASNFile), []byte(asnCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := LoadGeoDB(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ip   string
+		want GeoInfo
+	}{
+		{"1.0.0.1", GeoInfo{Country: "AU"}},
+		{"8.8.8.8", GeoInfo{Country: "US", ASN: "15169", Org: "Google LLC"}},
+		{"::ffff:8.8.8.8", GeoInfo{Country: "US", ASN: "15169", Org: "Google LLC"}},
+		{"2001:db8::1", GeoInfo{Country: "LV"}},
+		{"9.9.9.9", GeoInfo{}},
+		{"0.0.0.1", GeoInfo{}},
+		{"not-an-ip", GeoInfo{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			if got := db.Lookup(tt.ip); got != tt.want {
+				t.Errorf("Lookup(%q) = %+v, want %+v", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadGeoDBMissingFiles(t *testing.T) {
+	db, err := LoadGeoDB(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !db.Empty() {
+		t.Error("expected empty GeoDB when no files present")
+	}
+}
+
+func TestAnalyzeStatsResolvesASNOrg(t *testing.T) {
+	geoDir, logDir := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(geoDir, GeoIPASNFile), []byte("8.8.8.0,8.8.8.255,15169,Google LLC\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadGeoDB(geoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tagged at log time, only ASN number is stored in record
+	writeLogFile(t, logDir, "2025-12-01", []RequestStats{
+		{Timestamp: time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC), Path: "/720p", IP: "8.8.8.8", Status: 200, Country: "US", ASN: "15169"},
+	})
+
+	result, err := AnalyzeStats(AnalyzerConfig{LogDir: logDir, GeoDB: db})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.ASNs) != 1 || result.ASNs[0].Org != "Google LLC" {
+		t.Errorf("expected ASN org resolved from GeoDB, got %+v", result.ASNs)
+	}
+}
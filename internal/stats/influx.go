@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// InfluxMeasurement is the measurement name used for exported points
+const InfluxMeasurement = "lorem_video"
+
+type influxBucket struct {
+	requests  int
+	bytes     int64
+	errors    int
+	latencies []int64
+	visitors  map[string]struct{} // key: IP+UA
+}
+
+// ExportInflux writes InfluxDB line protocol points aggregated per interval and endpoint category:
+// lorem_video,category=video requests=10i,bytes=1024i,errors=0i,latency_avg_ms=12.5,latency_p95_ms=40i,latency_max_ms=52i,visitors=3i 1733050800000000000
+func ExportInflux(w io.Writer, analyzerConfig AnalyzerConfig, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid interval: %s", interval)
+	}
+
+	files, err := findLogFiles(analyzerConfig.LogDir, analyzerConfig)
+	if err != nil {
+		return err
+	}
+
+	buckets := make(map[time.Time]map[string]*influxBucket)
+
+	for _, filename := range files {
+		if err := collectInfluxBuckets(filename, analyzerConfig, interval, buckets); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Error processing %s: %v\n", filename, err)
+		}
+	}
+
+	times := make([]time.Time, 0, len(buckets))
+	for ts := range buckets {
+		times = append(times, ts)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		return times[i].Before(times[j])
+	})
+
+	writer := bufio.NewWriter(w)
+	for _, ts := range times {
+		categories := make([]string, 0, len(buckets[ts]))
+		for category := range buckets[ts] {
+			categories = append(categories, category)
+		}
+		sort.Strings(categories)
+
+		for _, category := range categories {
+			writer.WriteString(formatInfluxLine(category, buckets[ts][category], ts))
+		}
+	}
+
+	return writer.Flush()
+}
+
+func collectInfluxBuckets(filename string, analyzerConfig AnalyzerConfig, interval time.Duration, buckets map[time.Time]map[string]*influxBucket) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var stat RequestStats
+		if err := json.Unmarshal(scanner.Bytes(), &stat); err != nil {
+			continue // Skip malformed lines
+		}
+
+		if !analyzerConfig.includes(&stat) {
+			continue
+		}
+
+		ts := stat.Timestamp.Truncate(interval)
+		categories, exists := buckets[ts]
+		if !exists {
+			categories = make(map[string]*influxBucket)
+			buckets[ts] = categories
+		}
+
+		category := EndpointCategory(stat.Path)
+		bucket, exists := categories[category]
+		if !exists {
+			bucket = &influxBucket{visitors: make(map[string]struct{})}
+			categories[category] = bucket
+		}
+
+		bucket.requests += stat.Weight()
+		bucket.bytes += stat.ResponseSize * int64(stat.Weight())
+		if stat.Status >= 400 {
+			bucket.errors++
+		}
+		bucket.latencies = append(bucket.latencies, stat.ResponseTime)
+		bucket.visitors[stat.IP+"|"+stat.UserAgent] = struct{}{}
+	}
+
+	return scanner.Err()
+}
+
+func formatInfluxLine(category string, bucket *influxBucket, ts time.Time) string {
+	sorted := slices.Clone(bucket.latencies)
+	slices.Sort(sorted)
+
+	var total int64
+	for _, ms := range sorted {
+		total += ms
+	}
+
+	var line strings.Builder
+	line.WriteString(InfluxMeasurement)
+	line.WriteString(",category=" + escapeInfluxTag(category))
+	line.WriteString(fmt.Sprintf(" requests=%di,bytes=%di,errors=%di,visitors=%di",
+		bucket.requests, bucket.bytes, bucket.errors, len(bucket.visitors)))
+	if len(sorted) > 0 {
+		line.WriteString(fmt.Sprintf(",latency_avg_ms=%.2f,latency_p95_ms=%di,latency_max_ms=%di",
+			float64(total)/float64(len(sorted)), percentile(sorted, 95), sorted[len(sorted)-1]))
+	}
+	line.WriteString(fmt.Sprintf(" %d\n", ts.UnixNano()))
+
+	return line.String()
+}
+
+func escapeInfluxTag(value string) string {
+	return strings.NewReplacer(",", `\,`, "=", `\=`, " ", `\ `).Replace(value)
+}
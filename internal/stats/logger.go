@@ -5,14 +5,16 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
-	"net"
+	"hash/fnv"
 	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"lorem.video/internal/tenant"
 )
 
 type RequestStats struct {
@@ -26,26 +28,82 @@ type RequestStats struct {
 	ResponseTime int64     `json:"responseTime"` // ms
 	ResponseSize int64     `json:"responseSize"` // bytes
 	ContentType  string    `json:"content_type,omitempty"`
+	Country      string    `json:"country,omitempty"` // ISO code from GeoIP, if configured
+	ASN          string    `json:"asn,omitempty"`
+	SampleRate   int       `json:"sampleRate,omitempty"` // Record stands for this many requests, empty means 1
+	Cache        string    `json:"cache,omitempty"`      // Video source: pregen, tmp or generate
+	Tenant       string    `json:"tenant,omitempty"`     // API key namespace, empty for public
+	Throughput   int64     `json:"throughput,omitempty"` // Client consumption rate in kbit/s, see RecordThroughput
+}
+
+// Weight is number of requests record stands for
+func (stats RequestStats) Weight() int {
+	if stats.SampleRate > 1 {
+		return stats.SampleRate
+	}
+	return 1
+}
+
+// LogOptions tune logging cost under heavy load, zero value logs every request
+type LogOptions struct {
+	SampleChunks int // Log 1-in-N successful HLS chunk requests, errors are always logged
 }
 
-type StatsLogger struct {
-	logFile     *os.File
-	writer      *bufio.Writer
-	currentDate string // Track current date for file rotation
-	logPath     string // Directory path for log files
-	mutex       sync.Mutex
+// FileSink writes requests to daily rotated stats-YYYY-MM-DD.jsonl files
+type FileSink struct {
+	logFile       *os.File
+	writer        *bufio.Writer
+	currentDate   string // Track current date for file rotation
+	logPath       string // Directory path for log files
+	flushInterval time.Duration
+	done          chan struct{} // Closed by Close to stop flusher
+	stopped       chan struct{} // Closed when flusher exited
+	closeOnce     sync.Once
+	mutex         sync.Mutex
 }
 
-func NewStatsLogger(logPath string) (*StatsLogger, error) {
-	return &StatsLogger{
+// NewFileSink flushes every write when flushInterval is 0, otherwise keeps writes in memory
+// and flushes every flushInterval from background goroutine
+func NewFileSink(logPath string, flushInterval time.Duration) (*FileSink, error) {
+	sl := &FileSink{
 		logFile:     nil,
 		writer:      nil,
 		currentDate: "", // Empty means no file opened yet
 		logPath:     logPath,
-	}, nil
+	}
+	if flushInterval <= 0 {
+		return sl, nil
+	}
+
+	sl.flushInterval = flushInterval
+	sl.done = make(chan struct{})
+	sl.stopped = make(chan struct{})
+	go sl.flushPeriodically(sl.done)
+	return sl, nil
+}
+
+func (sl *FileSink) flushPeriodically(done <-chan struct{}) {
+	defer close(sl.stopped)
+	ticker := time.NewTicker(sl.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sl.mutex.Lock()
+			if sl.writer != nil {
+				if err := sl.writer.Flush(); err != nil {
+					fmt.Printf("Warning: Failed to flush stats log: %v\n", err)
+				}
+			}
+			sl.mutex.Unlock()
+		case <-done:
+			return
+		}
+	}
 }
 
-func (sl *StatsLogger) Log(stats RequestStats) error {
+func (sl *FileSink) Log(stats RequestStats) error {
 	sl.mutex.Lock()
 	defer sl.mutex.Unlock()
 
@@ -82,10 +140,19 @@ func (sl *StatsLogger) Log(stats RequestStats) error {
 		return fmt.Errorf("failed to write to log: %w", err)
 	}
 
+	if sl.flushInterval > 0 {
+		return nil // Flushed by flushPeriodically
+	}
 	return sl.writer.Flush()
 }
 
-func (sl *StatsLogger) Close() error {
+// Close stops flusher, then writes out buffered records and closes file
+func (sl *FileSink) Close() error {
+	if sl.done != nil {
+		sl.closeOnce.Do(func() { close(sl.done) })
+		<-sl.stopped
+	}
+
 	sl.mutex.Lock()
 	defer sl.mutex.Unlock()
 
@@ -115,17 +182,38 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return n, err
 }
 
-func StatsMiddleware(logPath string) func(http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		logger, err := NewStatsLogger(logPath)
-		if err != nil {
-			fmt.Printf("Warning: Failed to create stats logger: %v\n", err)
+// Unwrap lets http.ResponseController flush streamed responses
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// sampleChunk picks 1-in-n chunks per client (IP+UA). Consecutive live sequence numbers of one viewer are logged
+// at regular interval n, so HLS sessions see even gaps and clients in lock-step are sampled like everyone else
+func sampleChunk(stats RequestStats, n int) bool {
+	hash := fnv.New64a()
+	hash.Write([]byte(stats.IP + "|" + stats.UserAgent))
+	if match := hlsChunkSequence.FindStringSubmatch(stats.Path); match != nil {
+		if sequence, err := strconv.ParseUint(match[1], 10, 64); err == nil {
+			return (hash.Sum64()%uint64(n)+sequence%uint64(n))%uint64(n) == 0
 		}
+	}
+	hash.Write([]byte(stats.Path))
+	return hash.Sum64()%uint64(n) == 0
+}
+
+// Observer receives every logged request, e.g. for live alerting
+type Observer func(RequestStats)
 
+// StatsMiddleware logs every request to sink, geoDB is optional and used to tag requests with country/ASN.
+// Observers see every request, including ones skipped by chunk sampling
+func StatsMiddleware(sink Sink, geoDB *GeoDB, options LogOptions, observers ...Observer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
 		gcClient := &http.Client{Timeout: 3 * time.Second}
 
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			done := RequestStarted()
+			defer done()
 
 			rw := &responseWriter{
 				ResponseWriter: w,
@@ -133,6 +221,9 @@ func StatsMiddleware(logPath string) func(http.Handler) http.Handler {
 				bytesWritten:   0,
 			}
 
+			ctx, throughput := withThroughput(r.Context())
+			r = r.WithContext(ctx)
+
 			next.ServeHTTP(rw, r)
 
 			if shouldSkipPath(r.URL.Path) {
@@ -141,7 +232,7 @@ func StatsMiddleware(logPath string) func(http.Handler) http.Handler {
 
 			responseTime := time.Since(start).Milliseconds()
 
-			ipAddress := getRealIP(r)
+			ipAddress := GetRealIP(r)
 
 			stats := RequestStats{
 				Timestamp:    start,
@@ -154,15 +245,37 @@ func StatsMiddleware(logPath string) func(http.Handler) http.Handler {
 				ResponseTime: responseTime,
 				ResponseSize: rw.bytesWritten,
 				ContentType:  rw.Header().Get("Content-Type"),
+				Cache:        rw.Header().Get(CacheHeader),
+				Tenant:       tenant.NameFromContext(r.Context()),
+				Throughput:   throughput.Load(),
+			}
+
+			if !geoDB.Empty() {
+				geo := geoDB.Lookup(ipAddress)
+				stats.Country = geo.Country
+				stats.ASN = geo.ASN
+			}
+
+			logged, sampled := stats, true
+			if options.SampleChunks > 1 && stats.Status < 400 && EndpointCategory(stats.Path) == CategoryHLSChunk {
+				sampled = sampleChunk(stats, options.SampleChunks)
+				logged.SampleRate = options.SampleChunks
 			}
 
-			if logger != nil {
-				if err := logger.Log(stats); err != nil {
+			if sink != nil && sampled {
+				if err := sink.Log(logged); err != nil {
 					fmt.Printf("Warning: Failed to log request stats: %v\n", err)
 				}
 			}
 
-			sendToGoatCounter(gcClient, r, ipAddress)
+			for _, observe := range observers {
+				observe(stats)
+			}
+
+			// Playlist and chunk requests repeat every few seconds per viewer, page view analytics only need the rest
+			if category := EndpointCategory(stats.Path); category != CategoryHLSChunk && category != CategoryHLSPlaylist {
+				sendToGoatCounter(gcClient, r, ipAddress)
+			}
 		})
 	}
 }
@@ -236,23 +349,11 @@ func sendToGoatCounter(client *http.Client, r *http.Request, ip string) {
 	}
 }
 
-func getRealIP(r *http.Request) string {
-	ipAddress := r.RemoteAddr
-	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		ipAddress = strings.Split(forwarded, ",")[0]
-	} else if realIP := r.Header.Get("X-Real-IP"); realIP != "" {
-		ipAddress = realIP
-	}
-	if host, _, err := net.SplitHostPort(ipAddress); err == nil {
-		ipAddress = host
-	}
-	return strings.TrimSpace(ipAddress)
-}
-
 func shouldSkipPath(path string) bool {
 	skipExtensions := []string{
 		// IMPORTANT not to add .mp4 .webm (both are valid inputs from user)
-		".ico", ".css", ".svg", ".js", ".webp", ".gif", ".m3u8", ".json",
+		// .m3u8 and HLS chunks are kept for per-endpoint latency stats
+		".ico", ".css", ".svg", ".js", ".webp", ".gif", ".json",
 	}
 
 	if lastDot := strings.LastIndex(path, "."); lastDot != -1 {
@@ -264,11 +365,5 @@ func shouldSkipPath(path string) bool {
 		}
 	}
 
-	// don't log HLS media chunks
-	hlsPattern := regexp.MustCompile(`/media\.\d+\.mp4$`)
-	if hlsPattern.MatchString(path) {
-		return true
-	}
-
 	return false
 }
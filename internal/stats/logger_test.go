@@ -0,0 +1,142 @@
+package stats
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStatsMiddlewareSampling(t *testing.T) {
+	dir := t.TempDir()
+	observed := 0
+	sink, err := NewFileSink(dir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+	middleware := StatsMiddleware(sink, nil, LogOptions{SampleChunks: 3}, func(RequestStats) { observed++ })
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/hls/missing/720p/media.1.mp4" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("chunk"))
+	}))
+
+	paths := []string{"/hls/missing/720p/media.1.mp4", "/720p"}
+	for i := 0; i < 6; i++ {
+		paths = append(paths, fmt.Sprintf("/hls/bunny/720p/media.%d.mp4", 100+i))
+	}
+	for _, path := range paths {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if observed != len(paths) {
+		t.Errorf("observers saw %d requests, want %d", observed, len(paths))
+	}
+
+	result, err := AnalyzeStats(AnalyzerConfig{LogDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 sampled chunk records weighted by 3, error and non-chunk requests always logged
+	if result.TotalRequests != 8 || result.ErrorRequests != 1 {
+		t.Errorf("TotalRequests = %d, ErrorRequests = %d, want 8 and 1", result.TotalRequests, result.ErrorRequests)
+	}
+	if want := int64(7 * len("chunk")); result.TotalBytes != want {
+		t.Errorf("TotalBytes = %d, want %d", result.TotalBytes, want)
+	}
+}
+
+func TestSampleChunkPerClient(t *testing.T) {
+	for _, ip := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+		var sampled []int
+		for sequence := 1000; sequence < 1020; sequence++ {
+			stats := RequestStats{IP: ip, UserAgent: "player", Path: fmt.Sprintf("/hls/bunny/720p/media.%d.mp4", sequence)}
+			if sampleChunk(stats, 4) {
+				sampled = append(sampled, sequence)
+			}
+		}
+		if len(sampled) != 5 {
+			t.Fatalf("%s: sampled %v, want every 4th chunk", ip, sampled)
+		}
+		for i := 1; i < len(sampled); i++ {
+			if sampled[i]-sampled[i-1] != 4 {
+				t.Errorf("%s: irregular sampling %v", ip, sampled)
+			}
+		}
+	}
+}
+
+func TestStatsMiddlewareCacheSource(t *testing.T) {
+	var logged []RequestStats
+	middleware := StatsMiddleware(nil, nil, LogOptions{}, func(stats RequestStats) { logged = append(logged, stats) })
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/720p" {
+			w.Header().Set(CacheHeader, CachePregen)
+		}
+	}))
+	for _, path := range []string{"/720p", "/api/stats/runtime"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if len(logged) != 2 || logged[0].Cache != CachePregen || logged[1].Cache != "" {
+		t.Errorf("unexpected cache sources: %+v", logged)
+	}
+}
+
+func TestStatsMiddlewareThroughput(t *testing.T) {
+	var logged []RequestStats
+	middleware := StatsMiddleware(nil, nil, LogOptions{}, func(stats RequestStats) { logged = append(logged, stats) })
+
+	handler := middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/720p":
+			RecordThroughput(r.Context(), 4<<20, 2*time.Second)
+		case "/360p":
+			RecordThroughput(r.Context(), 1024, time.Second) // Too small to tell client speed
+		}
+	}))
+	for _, path := range []string{"/720p", "/360p"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	if len(logged) != 2 || logged[0].Throughput != 16777 || logged[1].Throughput != 0 {
+		t.Errorf("unexpected throughput: %+v", logged)
+	}
+}
+
+func TestBufferedFileSink(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewFileSink(dir, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := logger.Log(RequestStats{Timestamp: time.Now(), Path: "/720p"}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := AnalyzeStats(AnalyzerConfig{LogDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalRequests != 0 {
+		t.Fatalf("expected buffered write not flushed yet, got %d requests", result.TotalRequests)
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	result, err = AnalyzeStats(AnalyzerConfig{LogDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalRequests != 1 {
+		t.Errorf("TotalRequests = %d after Close, want 1", result.TotalRequests)
+	}
+}
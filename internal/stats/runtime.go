@@ -0,0 +1,101 @@
+package stats
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Live gauges for capacity monitoring, updated by StatsMiddleware and the video service
+var (
+	startedAt        = time.Now()
+	activeRequests   atomic.Int64
+	peakRequests     atomic.Int64
+	activeTranscodes atomic.Int64
+	peakTranscodes   atomic.Int64
+	queuedTranscodes atomic.Int64
+	cachePregenHits  atomic.Int64
+	cacheTmpHits     atomic.Int64
+	cacheMisses      atomic.Int64
+)
+
+// Video cache sources, sent in CacheHeader and logged as RequestStats.Cache
+const (
+	CacheHeader   = "X-Cache"
+	CachePregen   = "pregen"   // data/video, generated at startup
+	CacheTmp      = "tmp"      // data/tmp, generated by earlier request or cache warming
+	CacheGenerate = "generate" // Transcode started by this request
+)
+
+type RuntimeStats struct {
+	StartedAt        time.Time `json:"startedAt"`
+	UptimeSeconds    int64     `json:"uptimeSeconds"`
+	ActiveRequests   int64     `json:"activeRequests"`
+	PeakRequests     int64     `json:"peakRequests"`
+	ActiveTranscodes int64     `json:"activeTranscodes"` // running FFmpeg processes
+	PeakTranscodes   int64     `json:"peakTranscodes"`
+	QueuedTranscodes int64     `json:"queuedTranscodes"` // waiting for free worker
+	CacheHits        int64     `json:"cacheHits"`        // video requests served from existing file
+	CachePregenHits  int64     `json:"cachePregenHits"`
+	CacheTmpHits     int64     `json:"cacheTmpHits"`
+	CacheMisses      int64     `json:"cacheMisses"` // video requests that started a transcode
+}
+
+// RequestStarted increments open request gauge, call returned func when request is done
+func RequestStarted() func() {
+	return trackGauge(&activeRequests, &peakRequests)
+}
+
+// TranscodeStarted increments running FFmpeg gauge, call returned func when process exits
+func TranscodeStarted() func() {
+	return trackGauge(&activeTranscodes, &peakTranscodes)
+}
+
+// TranscodeQueued increments waiting transcode gauge, call returned func when job starts or is cancelled
+func TranscodeQueued() func() {
+	queuedTranscodes.Add(1)
+	return func() {
+		queuedTranscodes.Add(-1)
+	}
+}
+
+// RecordCache counts video request by where response came from, one of Cache* sources
+func RecordCache(source string) {
+	switch source {
+	case CachePregen:
+		cachePregenHits.Add(1)
+	case CacheTmp:
+		cacheTmpHits.Add(1)
+	case CacheGenerate:
+		cacheMisses.Add(1)
+	}
+}
+
+func GetRuntimeStats() RuntimeStats {
+	return RuntimeStats{
+		StartedAt:        startedAt,
+		UptimeSeconds:    int64(time.Since(startedAt).Seconds()),
+		ActiveRequests:   activeRequests.Load(),
+		PeakRequests:     peakRequests.Load(),
+		ActiveTranscodes: activeTranscodes.Load(),
+		PeakTranscodes:   peakTranscodes.Load(),
+		QueuedTranscodes: queuedTranscodes.Load(),
+		CacheHits:        cachePregenHits.Load() + cacheTmpHits.Load(),
+		CachePregenHits:  cachePregenHits.Load(),
+		CacheTmpHits:     cacheTmpHits.Load(),
+		CacheMisses:      cacheMisses.Load(),
+	}
+}
+
+func trackGauge(active, peak *atomic.Int64) func() {
+	current := active.Add(1)
+	for {
+		prev := peak.Load()
+		if current <= prev || peak.CompareAndSwap(prev, current) {
+			break
+		}
+	}
+
+	return func() {
+		active.Add(-1)
+	}
+}
@@ -0,0 +1,172 @@
+package stats
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// HLSSessionIdle closes a playback session when no playlist/chunk request arrives for this long
+	HLSSessionIdle = time.Minute
+	// HLSRebufferGap between consecutive chunk requests suggests player stalled (chunks are 1s long)
+	HLSRebufferGap = 4 * time.Second
+)
+
+// HLSSessionStats summarizes playback sessions reconstructed from playlist+chunk requests
+type HLSSessionStats struct {
+	Sessions             int                   `json:"sessions"`
+	ChunkRequests        int                   `json:"chunkRequests"`
+	AvgWatchSeconds      float64               `json:"avgWatchSeconds"`
+	RebufferGaps         int                   `json:"rebufferGaps"`
+	SessionsWithRebuffer int                   `json:"sessionsWithRebuffer"`
+	Videos               []HLSVideoSessionStat `json:"videos"`
+}
+
+type HLSVideoSessionStat struct {
+	Video           string  `json:"video"`
+	Sessions        int     `json:"sessions"`
+	AvgWatchSeconds float64 `json:"avgWatchSeconds"`
+	RebufferGaps    int     `json:"rebufferGaps"`
+}
+
+// HLSSessionTotals is the mergeable per-video aggregate stored in daily summaries
+type HLSSessionTotals struct {
+	Sessions             int   `json:"sessions"`
+	ChunkRequests        int   `json:"chunkRequests"`
+	WatchMs              int64 `json:"watchMs"`
+	RebufferGaps         int   `json:"rebufferGaps"`
+	SessionsWithRebuffer int   `json:"sessionsWithRebuffer"`
+}
+
+type openSession struct {
+	video     string
+	start     time.Time
+	last      time.Time
+	lastChunk time.Time
+	chunks    int
+	gaps      int
+}
+
+// sessionTracker groups HLS requests from same IP+UA and video into sessions.
+// Records are expected in log (time) order, sessions crossing midnight are split per file
+type sessionTracker struct {
+	open map[string]*openSession
+}
+
+func newSessionTracker() *sessionTracker {
+	return &sessionTracker{open: make(map[string]*openSession)}
+}
+
+func (t *sessionTracker) observe(stat *RequestStats, summary *DailySummary) {
+	category := EndpointCategory(stat.Path)
+	if (category != CategoryHLSPlaylist && category != CategoryHLSChunk) || stat.Status >= 400 {
+		return
+	}
+
+	video := hlsVideoName(stat.Path)
+	key := stat.IP + "|" + stat.UserAgent + "|" + video
+
+	session, exists := t.open[key]
+	if exists && stat.Timestamp.Sub(session.last) > HLSSessionIdle {
+		summary.addSession(session)
+		exists = false
+	}
+	if !exists {
+		session = &openSession{video: video, start: stat.Timestamp}
+		t.open[key] = session
+	}
+	session.last = stat.Timestamp
+
+	if category == CategoryHLSChunk {
+		// With 1-in-N sampling logged chunks are N times further apart
+		weight := stat.Weight()
+		if !session.lastChunk.IsZero() && stat.Timestamp.Sub(session.lastChunk) > HLSRebufferGap*time.Duration(weight) {
+			session.gaps++
+		}
+		session.lastChunk = stat.Timestamp
+		session.chunks += weight
+	}
+}
+
+// flush closes all sessions still open at end of log file
+func (t *sessionTracker) flush(summary *DailySummary) {
+	for key, session := range t.open {
+		summary.addSession(session)
+		delete(t.open, key)
+	}
+}
+
+// addSession records a finished session, playlist-only sessions never started playback and are ignored
+func (s *DailySummary) addSession(session *openSession) {
+	if session.chunks == 0 {
+		return
+	}
+
+	totals, exists := s.HLSSessions[session.video]
+	if !exists {
+		totals = &HLSSessionTotals{}
+		s.HLSSessions[session.video] = totals
+	}
+	totals.Sessions++
+	totals.ChunkRequests += session.chunks
+	totals.WatchMs += session.last.Sub(session.start).Milliseconds()
+	totals.RebufferGaps += session.gaps
+	if session.gaps > 0 {
+		totals.SessionsWithRebuffer++
+	}
+}
+
+func mergeSessionTotals(dst, src map[string]*HLSSessionTotals) {
+	for video, totals := range src {
+		entry, exists := dst[video]
+		if !exists {
+			entry = &HLSSessionTotals{}
+			dst[video] = entry
+		}
+		entry.Sessions += totals.Sessions
+		entry.ChunkRequests += totals.ChunkRequests
+		entry.WatchMs += totals.WatchMs
+		entry.RebufferGaps += totals.RebufferGaps
+		entry.SessionsWithRebuffer += totals.SessionsWithRebuffer
+	}
+}
+
+func summarizeSessions(sessions map[string]*HLSSessionTotals) HLSSessionStats {
+	var result HLSSessionStats
+	var watchMs int64
+	for video, totals := range sessions {
+		result.Sessions += totals.Sessions
+		result.ChunkRequests += totals.ChunkRequests
+		result.RebufferGaps += totals.RebufferGaps
+		result.SessionsWithRebuffer += totals.SessionsWithRebuffer
+		watchMs += totals.WatchMs
+
+		result.Videos = append(result.Videos, HLSVideoSessionStat{
+			Video:           video,
+			Sessions:        totals.Sessions,
+			AvgWatchSeconds: float64(totals.WatchMs) / 1000 / float64(totals.Sessions),
+			RebufferGaps:    totals.RebufferGaps,
+		})
+	}
+	if result.Sessions > 0 {
+		result.AvgWatchSeconds = float64(watchMs) / 1000 / float64(result.Sessions)
+	}
+
+	sort.Slice(result.Videos, func(i, j int) bool {
+		if result.Videos[i].Sessions != result.Videos[j].Sessions {
+			return result.Videos[i].Sessions > result.Videos[j].Sessions
+		}
+		return result.Videos[i].Video < result.Videos[j].Video
+	})
+	return result
+}
+
+// hlsVideoName extracts {videoName} from /hls/{videoName}/...
+func hlsVideoName(path string) string {
+	rest := strings.TrimPrefix(path, "/hls/")
+	if i := strings.Index(rest, "/"); i >= 0 {
+		return rest[:i]
+	}
+	return rest
+}
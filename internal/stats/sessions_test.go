@@ -0,0 +1,64 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHLSSessions(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	writeLogFile(t, dir, "2025-12-01", []RequestStats{
+		// Session 1: 10s of playback with one 6s stall
+		{Timestamp: at(0), Path: "/hls/bunny/playlist.m3u8", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: at(1), Path: "/hls/bunny/720p/media.1.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: at(2), Path: "/hls/bunny/720p/media.2.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: at(8), Path: "/hls/bunny/720p/media.3.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: at(10), Path: "/hls/bunny/720p/media.4.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		// Session 2: same client returns after idle timeout
+		{Timestamp: at(300), Path: "/hls/bunny/720p/media.1.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: at(304), Path: "/hls/bunny/720p/media.2.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		// Other video, concurrent with session 1
+		{Timestamp: at(5), Path: "/hls/sintel/480p/media.1.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		{Timestamp: at(7), Path: "/hls/sintel/480p/media.2.mp4", IP: "1.1.1.1", UserAgent: "a", Status: 200},
+		// Playlist only, never played
+		{Timestamp: at(20), Path: "/hls/bunny/playlist.m3u8", IP: "2.2.2.2", UserAgent: "b", Status: 200},
+		// Non HLS and failed requests are ignored
+		{Timestamp: at(21), Path: "/720p", IP: "2.2.2.2", UserAgent: "b", Status: 200},
+		{Timestamp: at(22), Path: "/hls/missing/720p/media.1.mp4", IP: "2.2.2.2", UserAgent: "b", Status: 404},
+	})
+
+	result, err := AnalyzeStats(AnalyzerConfig{LogDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sessions := result.HLSSessions
+	if sessions.Sessions != 3 {
+		t.Fatalf("Sessions = %d, want 3", sessions.Sessions)
+	}
+	if sessions.ChunkRequests != 8 {
+		t.Errorf("ChunkRequests = %d, want 8", sessions.ChunkRequests)
+	}
+	if sessions.AvgWatchSeconds != 16.0/3 {
+		t.Errorf("AvgWatchSeconds = %v, want %v", sessions.AvgWatchSeconds, 16.0/3)
+	}
+	if sessions.RebufferGaps != 1 || sessions.SessionsWithRebuffer != 1 {
+		t.Errorf("RebufferGaps = %d, SessionsWithRebuffer = %d, want 1 and 1", sessions.RebufferGaps, sessions.SessionsWithRebuffer)
+	}
+
+	want := []HLSVideoSessionStat{
+		{Video: "bunny", Sessions: 2, AvgWatchSeconds: 7, RebufferGaps: 1},
+		{Video: "sintel", Sessions: 1, AvgWatchSeconds: 2},
+	}
+	if len(sessions.Videos) != len(want) {
+		t.Fatalf("Videos = %+v, want %+v", sessions.Videos, want)
+	}
+	for i := range want {
+		if sessions.Videos[i] != want[i] {
+			t.Errorf("Videos[%d] = %+v, want %+v", i, sessions.Videos[i], want[i])
+		}
+	}
+}
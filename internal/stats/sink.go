@@ -0,0 +1,151 @@
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Sink receives logged requests, implemented by FileSink and RemoteSink
+type Sink interface {
+	Log(stats RequestStats) error
+	Close() error
+}
+
+type multiSink []Sink
+
+// MultiSink logs every request to all sinks, nil sinks are skipped
+func MultiSink(sinks ...Sink) Sink {
+	var result multiSink
+	for _, sink := range sinks {
+		if sink != nil {
+			result = append(result, sink)
+		}
+	}
+	return result
+}
+
+func (m multiSink) Log(stats RequestStats) error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Log(stats))
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// remoteSinkMaxPending bounds memory when remote endpoint is down, oldest records are dropped
+const remoteSinkMaxPending = 10000
+
+// RemoteSink POSTs batches of requests as JSONL (application/x-ndjson) to URL, e.g. Vector/Loki/Logstash http input
+type RemoteSink struct {
+	url    string
+	client *http.Client
+	done   chan struct{}
+
+	mutex   sync.Mutex
+	pending []RequestStats
+	dropped int
+}
+
+func NewRemoteSink(url string, flushInterval time.Duration) *RemoteSink {
+	if flushInterval <= 0 {
+		flushInterval = 5 * time.Second
+	}
+
+	rs := &RemoteSink{
+		url:    url,
+		client: &http.Client{Timeout: 10 * time.Second},
+		done:   make(chan struct{}),
+	}
+	go rs.flushPeriodically(flushInterval)
+	return rs
+}
+
+func (rs *RemoteSink) Log(stats RequestStats) error {
+	rs.mutex.Lock()
+	defer rs.mutex.Unlock()
+
+	if len(rs.pending) >= remoteSinkMaxPending {
+		rs.pending = rs.pending[1:]
+		rs.dropped++
+	}
+	rs.pending = append(rs.pending, stats)
+	return nil
+}
+
+func (rs *RemoteSink) flushPeriodically(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := rs.flush(); err != nil {
+				fmt.Printf("Warning: Failed to send stats to remote sink: %v\n", err)
+			}
+		case <-rs.done:
+			return
+		}
+	}
+}
+
+// flush sends pending batch, failed batch is put back to be retried with next flush
+func (rs *RemoteSink) flush() error {
+	rs.mutex.Lock()
+	batch, dropped := rs.pending, rs.dropped
+	rs.pending, rs.dropped = nil, 0
+	rs.mutex.Unlock()
+
+	if dropped > 0 {
+		fmt.Printf("Warning: Remote stats sink dropped %d records\n", dropped)
+	}
+	if len(batch) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, stats := range batch {
+		if err := encoder.Encode(stats); err != nil {
+			return fmt.Errorf("failed to marshal stats: %w", err)
+		}
+	}
+
+	resp, err := rs.client.Post(rs.url, "application/x-ndjson", &body)
+	if err == nil {
+		resp.Body.Close()
+		if resp.StatusCode >= 400 {
+			err = fmt.Errorf("remote responded %s", resp.Status)
+		}
+	}
+	if err != nil {
+		rs.mutex.Lock()
+		rs.pending = append(batch, rs.pending...)
+		if overflow := len(rs.pending) - remoteSinkMaxPending; overflow > 0 {
+			rs.pending = rs.pending[overflow:]
+			rs.dropped += overflow
+		}
+		rs.mutex.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Close stops periodic flushing and sends remaining records
+func (rs *RemoteSink) Close() error {
+	close(rs.done)
+	return rs.flush()
+}
@@ -0,0 +1,54 @@
+package stats
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRemoteSink(t *testing.T) {
+	received := make(chan int, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/x-ndjson" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		lines := 0
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			lines++
+		}
+		received <- lines
+	}))
+	defer server.Close()
+
+	sink := NewRemoteSink(server.URL, time.Hour)
+	sink.Log(RequestStats{Path: "/720p"})
+	sink.Log(RequestStats{Path: "/480p"})
+
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := <-received; lines != 2 {
+		t.Errorf("remote received %d records, want 2", lines)
+	}
+}
+
+func TestRemoteSinkRetry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sink := NewRemoteSink(server.URL, time.Hour)
+	defer close(sink.done)
+	sink.Log(RequestStats{Path: "/720p"})
+
+	if err := sink.flush(); err == nil {
+		t.Fatal("expected error from failing remote")
+	}
+	if len(sink.pending) != 1 {
+		t.Errorf("failed batch should be kept for retry, pending %d", len(sink.pending))
+	}
+}
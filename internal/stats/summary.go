@@ -0,0 +1,557 @@
+package stats
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// summaryVersion is bumped whenever DailySummary fields change, summaries of other version are rebuilt from raw logs
+const summaryVersion = 2
+
+// summaryTopN caps visitors, referrers and user agents stored in summary file, unique visitors are kept as hashes
+const summaryTopN = 500
+
+// DailySummary is the partial aggregate of one daily log file. Summaries of past days
+// are written next to raw logs as summary-YYYY-MM-DD.json and reused by AnalyzeStats
+type DailySummary struct {
+	Version int            `json:"version"`
+	Date    string         `json:"date"`
+	Filters SummaryFilters `json:"filters"`
+
+	TotalRequests   int       `json:"totalRequests"`
+	TotalBytes      int64     `json:"totalBytes"`
+	VideoRequests   int       `json:"videoRequests"`
+	StaticRequests  int       `json:"staticRequests"`
+	PartialRequests int       `json:"partialRequests"`
+	ErrorRequests   int       `json:"errorRequests"`
+	FirstRequest    time.Time `json:"firstRequest"`
+	LastRequest     time.Time `json:"lastRequest"`
+
+	Endpoints     map[string]*EndpointStat     `json:"endpoints"`
+	Visitors      map[string]*VisitorStat      `json:"visitors"` // key: IP+UA
+	Referrers     map[string]*ReferrerStat     `json:"referrers"`
+	FullReferrers map[string]*ReferrerStat     `json:"fullReferrers"`
+	UserAgents    map[string]*UserAgentStat    `json:"userAgents"`
+	Latencies     map[string]map[int64]int     `json:"latencies"` // category -> ms -> count
+	Countries     map[string]*GeoTraffic       `json:"countries"`
+	ASNs          map[string]*GeoTraffic       `json:"asns"`
+	HLSSessions   map[string]*HLSSessionTotals `json:"hlsSessions"`             // video -> totals
+	CacheSources  map[string]int               `json:"cacheSources"`            // pregen/tmp/generate -> video requests
+	Tenants       map[string]*TenantUsage      `json:"tenants"`                 // API key namespace -> usage
+	VisitorHashes []uint64                     `json:"visitorHashes,omitempty"` // All visitors of compacted summary
+
+	visitors map[uint64]struct{} // Hashed IP+UA of every visitor, Visitors map may be capped
+}
+
+type TenantUsage struct {
+	Requests int   `json:"requests"`
+	Bytes    int64 `json:"bytes"`
+}
+
+// SummaryFilters records analyzer filters a summary was built with, summary is reused only on exact match
+type SummaryFilters struct {
+	ExcludeStaticPaths bool   `json:"excludeStaticPaths"`
+	ExcludePartial     bool   `json:"excludePartial"`
+	ExcludeReferer     string `json:"excludeReferer"`
+	GeoIP              bool   `json:"geoip"`
+}
+
+type GeoTraffic struct {
+	Org           string   `json:"org,omitempty"`
+	Requests      int      `json:"requests"`
+	Bytes         int64    `json:"bytes"`
+	VisitorHashes []uint64 `json:"visitorHashes,omitempty"` // Visitors of compacted summary
+
+	visitors map[uint64]struct{} // Hashed IP+UA, counted here as Visitors map may be capped
+}
+
+func newDailySummary(date string, filters SummaryFilters) *DailySummary {
+	return &DailySummary{
+		Version:       summaryVersion,
+		Date:          date,
+		Filters:       filters,
+		Endpoints:     make(map[string]*EndpointStat),
+		Visitors:      make(map[string]*VisitorStat),
+		Referrers:     make(map[string]*ReferrerStat),
+		FullReferrers: make(map[string]*ReferrerStat),
+		UserAgents:    make(map[string]*UserAgentStat),
+		Latencies:     make(map[string]map[int64]int),
+		Countries:     make(map[string]*GeoTraffic),
+		ASNs:          make(map[string]*GeoTraffic),
+		HLSSessions:   make(map[string]*HLSSessionTotals),
+		CacheSources:  make(map[string]int),
+		Tenants:       make(map[string]*TenantUsage),
+		visitors:      make(map[uint64]struct{}),
+	}
+}
+
+// UniqueVisitors counts distinct IP+UA, also those dropped from capped Visitors map
+func (s *DailySummary) UniqueVisitors() int {
+	return len(s.visitors)
+}
+
+func hashSet(hashes []uint64) map[uint64]struct{} {
+	set := make(map[uint64]struct{}, len(hashes))
+	for _, hash := range hashes {
+		set[hash] = struct{}{}
+	}
+	return set
+}
+
+func visitorHash(visitorKey string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(visitorKey))
+	return hash.Sum64()
+}
+
+// compact keeps summaryTopN largest visitors, referrers and user agents, so summary file size doesn't grow with traffic
+func (s *DailySummary) compact() {
+	s.VisitorHashes = slices.Sorted(maps.Keys(s.visitors))
+	for _, traffic := range s.Countries {
+		traffic.VisitorHashes = slices.Sorted(maps.Keys(traffic.visitors))
+	}
+	for _, traffic := range s.ASNs {
+		traffic.VisitorHashes = slices.Sorted(maps.Keys(traffic.visitors))
+	}
+	keepTop(s.Visitors, func(v *VisitorStat) int { return v.Requests })
+	keepTop(s.Referrers, func(r *ReferrerStat) int { return r.Count })
+	keepTop(s.FullReferrers, func(r *ReferrerStat) int { return r.Count })
+	keepTop(s.UserAgents, func(ua *UserAgentStat) int { return ua.Count })
+}
+
+func keepTop[V any](entries map[string]V, count func(V) int) {
+	if len(entries) <= summaryTopN {
+		return
+	}
+	keys := slices.Collect(maps.Keys(entries))
+	sort.Slice(keys, func(i, j int) bool {
+		return count(entries[keys[i]]) > count(entries[keys[j]])
+	})
+	for _, key := range keys[summaryTopN:] {
+		delete(entries, key)
+	}
+}
+
+func (config AnalyzerConfig) summaryFilters() SummaryFilters {
+	return SummaryFilters{
+		ExcludeStaticPaths: config.ExcludeStaticPaths,
+		ExcludePartial:     config.ExcludePartial,
+		ExcludeReferer:     config.ExcludeReferer,
+		GeoIP:              !config.GeoDB.Empty(),
+	}
+}
+
+// loadOrBuildSummary reads cached summary for past days when allowed, otherwise scans raw log file
+func loadOrBuildSummary(logFile string, config AnalyzerConfig) (*DailySummary, error) {
+	date := logFileDate(logFile)
+	summaryPath := filepath.Join(filepath.Dir(logFile), fmt.Sprintf("summary-%s.json", date))
+	cacheable := config.UseSummaries && !config.needsRawScan() && date < time.Now().Format("2006-01-02")
+
+	if cacheable {
+		if summary, ok := readSummary(summaryPath, logFile, config.summaryFilters()); ok {
+			return summary, nil
+		}
+	}
+
+	summary, err := buildSummary(logFile, date, config)
+	if err != nil {
+		return nil, err
+	}
+
+	if cacheable {
+		summary.compact()
+		if err := writeSummary(summaryPath, summary); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to write summary %s: %v\n", filepath.Base(summaryPath), err)
+		}
+	}
+
+	return summary, nil
+}
+
+func readSummary(summaryPath, logFile string, filters SummaryFilters) (*DailySummary, bool) {
+	summaryInfo, err := os.Stat(summaryPath)
+	if err != nil {
+		return nil, false
+	}
+	logInfo, err := os.Stat(logFile)
+	if err != nil || logInfo.ModTime().After(summaryInfo.ModTime()) {
+		return nil, false // Raw log changed after summary was written
+	}
+
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		return nil, false
+	}
+
+	var summary DailySummary
+	if err := json.Unmarshal(data, &summary); err != nil || summary.Version != summaryVersion || summary.Filters != filters {
+		return nil, false
+	}
+	summary.visitors = hashSet(summary.VisitorHashes)
+	for _, traffic := range summary.Countries {
+		traffic.visitors = hashSet(traffic.VisitorHashes)
+	}
+	for _, traffic := range summary.ASNs {
+		traffic.visitors = hashSet(traffic.VisitorHashes)
+	}
+
+	return &summary, true
+}
+
+func writeSummary(summaryPath string, summary *DailySummary) error {
+	data, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := summaryPath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, summaryPath)
+}
+
+func buildSummary(filename, date string, config AnalyzerConfig) (*DailySummary, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	summary := newDailySummary(date, config.summaryFilters())
+	sessions := newSessionTracker()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var stat RequestStats
+		if err := json.Unmarshal(scanner.Bytes(), &stat); err != nil {
+			continue // Skip malformed lines
+		}
+
+		if !config.includes(&stat) {
+			continue
+		}
+
+		summary.add(&stat, config.GeoDB)
+		sessions.observe(&stat, summary)
+	}
+	sessions.flush(summary)
+
+	return summary, scanner.Err()
+}
+
+func (s *DailySummary) add(stat *RequestStats, geoDB *GeoDB) {
+	// Track date range
+	if s.FirstRequest.IsZero() || stat.Timestamp.Before(s.FirstRequest) {
+		s.FirstRequest = stat.Timestamp
+	}
+	if s.LastRequest.IsZero() || stat.Timestamp.After(s.LastRequest) {
+		s.LastRequest = stat.Timestamp
+	}
+
+	// Sampled records stand for SampleRate requests
+	weight := stat.Weight()
+	bytes := stat.ResponseSize * int64(weight)
+
+	s.TotalRequests += weight
+	s.TotalBytes += bytes
+
+	// Categorize requests
+	category := EndpointCategory(stat.Path)
+	if category == CategoryVideo {
+		s.VideoRequests += weight
+	} else if strings.HasPrefix(stat.Path, "/web/") {
+		s.StaticRequests += weight
+	}
+	if stat.Status == 206 {
+		s.PartialRequests += weight
+	}
+	if stat.Status >= 400 {
+		s.ErrorRequests += weight
+	}
+	if stat.Cache != "" {
+		s.CacheSources[stat.Cache] += weight
+	}
+	if stat.Tenant != "" {
+		usage := s.Tenants[stat.Tenant]
+		if usage == nil {
+			usage = &TenantUsage{}
+			s.Tenants[stat.Tenant] = usage
+		}
+		usage.Requests += weight
+		usage.Bytes += bytes
+	}
+
+	if s.Latencies[category] == nil {
+		s.Latencies[category] = make(map[int64]int)
+	}
+	s.Latencies[category][stat.ResponseTime] += weight
+
+	normalizedPath := EndpointPath(stat.Path)
+	if ep, exists := s.Endpoints[normalizedPath]; exists {
+		ep.Count += weight
+		ep.Bytes += bytes
+	} else {
+		s.Endpoints[normalizedPath] = &EndpointStat{
+			Path:  normalizedPath,
+			Count: weight,
+			Bytes: bytes,
+		}
+	}
+
+	// Resolve country/ASN for records logged without GeoIP data
+	country, asn, org := stat.Country, stat.ASN, ""
+	if country == "" && asn == "" && !geoDB.Empty() {
+		info := geoDB.Lookup(stat.IP)
+		country, asn, org = info.Country, info.ASN, info.Org
+	}
+	// Records tagged at log time carry only ASN number, org name is resolved once per ASN
+	if asn != "" && org == "" && !geoDB.Empty() {
+		if entry := s.ASNs[asn]; entry == nil || entry.Org == "" {
+			if info := geoDB.Lookup(stat.IP); info.ASN == asn {
+				org = info.Org
+			}
+		}
+	}
+	if country == "" {
+		country = "unknown"
+	}
+
+	// Track visitors (by IP + UA combination for better uniqueness)
+	visitorKey := stat.IP + "|" + stat.UserAgent
+	hash := visitorHash(visitorKey)
+	s.visitors[hash] = struct{}{}
+
+	addGeoTraffic(s.Countries, country, "", hash, weight, bytes)
+	if asn != "" {
+		addGeoTraffic(s.ASNs, asn, org, hash, weight, bytes)
+	}
+	if visitor, exists := s.Visitors[visitorKey]; exists {
+		visitor.Requests += weight
+		visitor.Bytes += bytes
+		visitor.LastSeen = stat.Timestamp
+	} else {
+		s.Visitors[visitorKey] = &VisitorStat{
+			IP:        stat.IP,
+			UserAgent: stat.UserAgent,
+			Browser:   ExtractBrowserName(stat.UserAgent),
+			Country:   country,
+			ASN:       asn,
+			Requests:  weight,
+			Bytes:     bytes,
+			FirstSeen: stat.Timestamp,
+			LastSeen:  stat.Timestamp,
+		}
+	}
+
+	// Track referrers
+	if stat.Referer != "" {
+		domain := extractDomain(stat.Referer)
+
+		// Full URL tracking
+		addReferrer(s.FullReferrers, stat.Referer, domain, stat.Referer, weight, stat.Timestamp)
+
+		// Domain aggregation
+		if domain != "" {
+			addReferrer(s.Referrers, domain, domain, domain, weight, stat.Timestamp)
+		}
+	}
+
+	// Track user agents
+	if ua, exists := s.UserAgents[stat.UserAgent]; exists {
+		ua.Count += weight
+	} else {
+		s.UserAgents[stat.UserAgent] = &UserAgentStat{
+			UserAgent: stat.UserAgent,
+			Count:     weight,
+			IsBot:     isBot(stat.UserAgent),
+		}
+	}
+}
+
+// merge adds other summary into s, used to combine daily summaries into a range
+func (s *DailySummary) merge(other *DailySummary) {
+	if other.TotalRequests == 0 {
+		return
+	}
+
+	if s.FirstRequest.IsZero() || other.FirstRequest.Before(s.FirstRequest) {
+		s.FirstRequest = other.FirstRequest
+	}
+	if s.LastRequest.IsZero() || other.LastRequest.After(s.LastRequest) {
+		s.LastRequest = other.LastRequest
+	}
+
+	s.TotalRequests += other.TotalRequests
+	s.TotalBytes += other.TotalBytes
+	s.VideoRequests += other.VideoRequests
+	s.StaticRequests += other.StaticRequests
+	s.PartialRequests += other.PartialRequests
+	s.ErrorRequests += other.ErrorRequests
+	for source, count := range other.CacheSources {
+		s.CacheSources[source] += count
+	}
+	for name, usage := range other.Tenants {
+		if existing, exists := s.Tenants[name]; exists {
+			existing.Requests += usage.Requests
+			existing.Bytes += usage.Bytes
+		} else {
+			s.Tenants[name] = &TenantUsage{Requests: usage.Requests, Bytes: usage.Bytes}
+		}
+	}
+
+	for path, ep := range other.Endpoints {
+		if existing, exists := s.Endpoints[path]; exists {
+			existing.Count += ep.Count
+			existing.Bytes += ep.Bytes
+		} else {
+			copied := *ep
+			s.Endpoints[path] = &copied
+		}
+	}
+
+	for hash := range other.visitors {
+		s.visitors[hash] = struct{}{}
+	}
+	for key, visitor := range other.Visitors {
+		if existing, exists := s.Visitors[key]; exists {
+			existing.Requests += visitor.Requests
+			existing.Bytes += visitor.Bytes
+			if visitor.FirstSeen.Before(existing.FirstSeen) {
+				existing.FirstSeen = visitor.FirstSeen
+			}
+			if visitor.LastSeen.After(existing.LastSeen) {
+				existing.LastSeen = visitor.LastSeen
+			}
+		} else {
+			copied := *visitor
+			s.Visitors[key] = &copied
+		}
+	}
+
+	for key, ref := range other.Referrers {
+		addReferrer(s.Referrers, key, ref.Domain, ref.FullURL, ref.Count, ref.LastSeen)
+	}
+	for key, ref := range other.FullReferrers {
+		addReferrer(s.FullReferrers, key, ref.Domain, ref.FullURL, ref.Count, ref.LastSeen)
+	}
+
+	for key, ua := range other.UserAgents {
+		if existing, exists := s.UserAgents[key]; exists {
+			existing.Count += ua.Count
+		} else {
+			copied := *ua
+			s.UserAgents[key] = &copied
+		}
+	}
+
+	for category, histogram := range other.Latencies {
+		if s.Latencies[category] == nil {
+			s.Latencies[category] = make(map[int64]int)
+		}
+		for ms, count := range histogram {
+			s.Latencies[category][ms] += count
+		}
+	}
+
+	mergeGeoTraffic(s.Countries, other.Countries)
+	mergeGeoTraffic(s.ASNs, other.ASNs)
+	mergeSessionTotals(s.HLSSessions, other.HLSSessions)
+}
+
+func addReferrer(referrers map[string]*ReferrerStat, key, domain, fullURL string, count int, lastSeen time.Time) {
+	if ref, exists := referrers[key]; exists {
+		ref.Count += count
+		if lastSeen.After(ref.LastSeen) {
+			ref.LastSeen = lastSeen
+		}
+		return
+	}
+
+	referrers[key] = &ReferrerStat{
+		Domain:   domain,
+		FullURL:  fullURL,
+		Count:    count,
+		LastSeen: lastSeen,
+	}
+}
+
+// addGeoTraffic records a single (possibly sampled) request
+func addGeoTraffic(traffic map[string]*GeoTraffic, key, org string, visitor uint64, requests int, bytes int64) {
+	entry, exists := traffic[key]
+	if !exists {
+		entry = &GeoTraffic{visitors: make(map[uint64]struct{})}
+		traffic[key] = entry
+	}
+	entry.visitors[visitor] = struct{}{}
+	if org != "" {
+		entry.Org = org
+	}
+	entry.Requests += requests
+	entry.Bytes += bytes
+}
+
+func mergeGeoTraffic(dst, src map[string]*GeoTraffic) {
+	for key, traffic := range src {
+		entry, exists := dst[key]
+		if !exists {
+			entry = &GeoTraffic{visitors: make(map[uint64]struct{})}
+			dst[key] = entry
+		}
+		for hash := range traffic.visitors {
+			entry.visitors[hash] = struct{}{}
+		}
+		if traffic.Org != "" {
+			entry.Org = traffic.Org
+		}
+		entry.Requests += traffic.Requests
+		entry.Bytes += traffic.Bytes
+	}
+}
+
+// geoStats converts merged traffic into sorted slices
+func (s *DailySummary) geoStats() ([]CountryStat, []ASNStat) {
+	countries := make([]CountryStat, 0, len(s.Countries))
+	for country, traffic := range s.Countries {
+		countries = append(countries, CountryStat{
+			Country:        country,
+			Requests:       traffic.Requests,
+			Bytes:          traffic.Bytes,
+			UniqueVisitors: len(traffic.visitors),
+		})
+	}
+	sort.Slice(countries, func(i, j int) bool {
+		return countries[i].Requests > countries[j].Requests
+	})
+
+	asns := make([]ASNStat, 0, len(s.ASNs))
+	for asn, traffic := range s.ASNs {
+		asns = append(asns, ASNStat{
+			ASN:            asn,
+			Org:            traffic.Org,
+			Requests:       traffic.Requests,
+			Bytes:          traffic.Bytes,
+			UniqueVisitors: len(traffic.visitors),
+		})
+	}
+	sort.Slice(asns, func(i, j int) bool {
+		return asns[i].Requests > asns[j].Requests
+	})
+
+	return countries, asns
+}
+
+// logFileDate extracts YYYY-MM-DD from stats-YYYY-MM-DD.jsonl
+func logFileDate(filename string) string {
+	base := filepath.Base(filename)
+	return strings.TrimSuffix(strings.TrimPrefix(base, "stats-"), ".jsonl")
+}
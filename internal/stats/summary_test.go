@@ -0,0 +1,69 @@
+package stats
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSummaryCompactKeepsUniqueVisitors(t *testing.T) {
+	summary := newDailySummary("2025-12-01", SummaryFilters{})
+	for i := range summaryTopN + 100 {
+		summary.add(&RequestStats{
+			Timestamp: time.Date(2025, 12, 1, 10, 0, 0, 0, time.UTC),
+			Path:      "/720p",
+			IP:        fmt.Sprintf("10.0.%d.%d", i/256, i%256),
+			UserAgent: fmt.Sprintf("agent-%d", i),
+			Referer:   fmt.Sprintf("https://site%d.example/", i),
+			Status:    200,
+			Country:   "LV",
+			ASN:       "64500",
+		}, nil)
+	}
+	summary.compact()
+
+	dir := t.TempDir()
+	logPath := filepath.Join(dir, "stats-2025-12-01.jsonl")
+	summaryPath := filepath.Join(dir, "summary-2025-12-01.json")
+	if err := os.WriteFile(logPath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(logPath, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSummary(summaryPath, summary); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, ok := readSummary(summaryPath, logPath, SummaryFilters{})
+	if !ok {
+		t.Fatal("expected summary to be reused")
+	}
+	if len(loaded.Visitors) != summaryTopN || len(loaded.UserAgents) > summaryTopN || len(loaded.FullReferrers) > summaryTopN {
+		t.Errorf("expected maps capped to %d, got %d visitors, %d agents, %d referrers",
+			summaryTopN, len(loaded.Visitors), len(loaded.UserAgents), len(loaded.FullReferrers))
+	}
+	if loaded.UniqueVisitors() != summaryTopN+100 {
+		t.Errorf("expected %d unique visitors, got %d", summaryTopN+100, loaded.UniqueVisitors())
+	}
+
+	countries, asns := loaded.geoStats()
+	if len(countries) != 1 || countries[0].UniqueVisitors != summaryTopN+100 {
+		t.Errorf("expected %d unique visitors from LV, got %+v", summaryTopN+100, countries)
+	}
+	if len(asns) != 1 || asns[0].UniqueVisitors != summaryTopN+100 {
+		t.Errorf("expected %d unique visitors from AS64500, got %+v", summaryTopN+100, asns)
+	}
+
+	// Summary of older version is rebuilt from raw log
+	summary.Version = summaryVersion - 1
+	if err := writeSummary(summaryPath, summary); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readSummary(summaryPath, logPath, SummaryFilters{}); ok {
+		t.Error("expected summary with other version to be rejected")
+	}
+}
@@ -0,0 +1,27 @@
+package stats
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// minThroughputBytes is smallest transfer measured, smaller responses fit into socket buffers and never block
+const minThroughputBytes = 1 << 20
+
+type throughputKey struct{}
+
+// RecordThroughput reports client consumption rate for request logged by StatsMiddleware. Handler passes bytes
+// sent and time spent blocked writing them, so waiting for data to send doesn't count against client
+func RecordThroughput(ctx context.Context, bytes int64, blocked time.Duration) {
+	holder, ok := ctx.Value(throughputKey{}).(*atomic.Int64)
+	if !ok || bytes < minThroughputBytes || blocked <= 0 {
+		return
+	}
+	holder.Store(int64(float64(bytes*8) / 1000 / blocked.Seconds()))
+}
+
+func withThroughput(ctx context.Context) (context.Context, *atomic.Int64) {
+	holder := &atomic.Int64{}
+	return context.WithValue(ctx, throughputKey{}, holder), holder
+}
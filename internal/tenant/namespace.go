@@ -0,0 +1,103 @@
+package tenant
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/fileindex"
+	"lorem.video/internal/parser"
+)
+
+// Spec resolution methods accept nil tenant, which is public namespace
+
+// sourceNames lists tenant's own source names
+func (t *Tenant) sourceNames() []string {
+	files, err := config.GetSourceVideoFilesIn(t.SourceDir())
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, file := range files {
+		names = append(names, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)))
+	}
+	return names
+}
+
+// ParseFilename recognizes tenant's own sources in addition to public ones
+func (t *Tenant) ParseFilename(filename string) (*config.VideoSpec, error) {
+	if t == nil {
+		return parser.ParseFilename(filename)
+	}
+	return parser.ParseFilenameWithSources(filename, append(t.sourceNames(), parser.SourceNames()...))
+}
+
+// FindSourceVideo prefers tenant's own source over public one with same name
+func (t *Tenant) FindSourceVideo(name string) (string, error) {
+	if t != nil {
+		if path, err := config.FindSourceVideoIn(t.SourceDir(), name); err == nil {
+			return path, nil
+		}
+	}
+	return config.FindSourceVideo(name)
+}
+
+// FindExistingVideo checks tenant cache, public cache is shared only when name isn't shadowed by tenant source
+func (t *Tenant) FindExistingVideo(filename string, spec *config.VideoSpec) string {
+	if t == nil {
+		return parser.FindExistingVideo(filename, spec)
+	}
+	if path := filepath.Join(t.CacheDir(), filename); fileindex.Exists(path) {
+		return path
+	}
+	if slices.Contains(t.sourceNames(), spec.Name) {
+		return ""
+	}
+	return parser.FindExistingVideo(filename, spec)
+}
+
+// EnforceQuota evicts least recently written videos from tenant cache until it fits QuotaBytes,
+// inUse files (still transcoding) are kept
+func (t *Tenant) EnforceQuota(inUse func(path string) bool) {
+	if t == nil || t.QuotaBytes <= 0 {
+		return
+	}
+
+	type cached struct {
+		path    string
+		size    int64
+		modTime int64
+	}
+	var files []cached
+	var total int64
+	filepath.WalkDir(t.CacheDir(), func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil
+		}
+		files = append(files, cached{path, info.Size(), info.ModTime().UnixNano()})
+		total += info.Size()
+		return nil
+	})
+
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime < files[j].modTime })
+	for _, file := range files {
+		if total <= t.QuotaBytes {
+			return
+		}
+		if inUse(file.path) {
+			continue
+		}
+		fileindex.Remove(file.path)
+		if err := os.Remove(file.path); err == nil {
+			total -= file.size
+		}
+	}
+}
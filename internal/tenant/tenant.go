@@ -0,0 +1,138 @@
+package tenant
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+
+	"lorem.video/internal/config"
+)
+
+// KeyHeader carries API key, KeyQueryParam is alternative for <video src> and players that can't set headers
+const (
+	KeyHeader     = "X-API-Key"
+	KeyQueryParam = "key"
+)
+
+// nameRegex keeps tenant name safe as directory name
+var nameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// Tenant is team with own source videos, cache dir and stats bucket, selected by API key
+type Tenant struct {
+	Name       string `json:"name"`
+	Key        string `json:"key"`
+	QuotaBytes int64  `json:"quotaBytes"` // Cache dir size limit, oldest videos are evicted, 0 is unlimited
+
+	MonthlyEncodeSeconds int64 `json:"monthlyEncodeSeconds"` // FFmpeg wall time cap, new transcodes get 402 above it, 0 is unlimited
+	MonthlyEgressBytes   int64 `json:"monthlyEgressBytes"`   // Transfer cap, all requests get 429 above it, 0 is unlimited
+
+	usageOnce sync.Once
+	usage     *usageCounter
+}
+
+// SourceDir holds tenant's source videos, they shadow public sources with same name
+func (t *Tenant) SourceDir() string {
+	return filepath.Join(config.AppPaths.Tenants, t.Name, "sourceVideo")
+}
+
+// CacheDir holds videos generated for tenant
+func (t *Tenant) CacheDir() string {
+	return filepath.Join(config.AppPaths.Tenants, t.Name, "tmp")
+}
+
+// Registry maps API keys to tenants
+type Registry struct {
+	byKey  map[string]*Tenant
+	byName map[string]*Tenant
+}
+
+// Load reads JSON array of tenants and creates their directories
+func Load(path string) (*Registry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tenants []*Tenant
+	if err := json.Unmarshal(data, &tenants); err != nil {
+		return nil, fmt.Errorf("invalid tenants file %s: %w", path, err)
+	}
+
+	registry := &Registry{byKey: make(map[string]*Tenant), byName: make(map[string]*Tenant)}
+	for _, tenant := range tenants {
+		if !nameRegex.MatchString(tenant.Name) || registry.byName[tenant.Name] != nil {
+			return nil, fmt.Errorf("invalid or duplicate tenant name: %q", tenant.Name)
+		}
+		if tenant.Key == "" || registry.byKey[tenant.Key] != nil {
+			return nil, fmt.Errorf("missing or duplicate key for tenant %s", tenant.Name)
+		}
+		for _, dir := range []string{tenant.SourceDir(), tenant.CacheDir()} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return nil, err
+			}
+		}
+		tenant.loadUsage()
+		registry.byName[tenant.Name] = tenant
+		registry.byKey[tenant.Key] = tenant
+	}
+	return registry, nil
+}
+
+func (r *Registry) Lookup(key string) (*Tenant, bool) {
+	tenant, ok := r.byKey[key]
+	return tenant, ok
+}
+
+// Tenants returns all tenants sorted by name
+func (r *Registry) Tenants() []*Tenant {
+	tenants := make([]*Tenant, 0, len(r.byKey))
+	for _, tenant := range r.byKey {
+		tenants = append(tenants, tenant)
+	}
+	sort.Slice(tenants, func(i, j int) bool { return tenants[i].Name < tenants[j].Name })
+	return tenants
+}
+
+var ErrUnknownKey = errors.New("unknown API key")
+
+// FromRequest returns tenant of request API key, nil without key (public namespace)
+func (r *Registry) FromRequest(req *http.Request) (*Tenant, error) {
+	key := req.Header.Get(KeyHeader)
+	if key == "" {
+		key = req.URL.Query().Get(KeyQueryParam)
+	}
+	if key == "" {
+		return nil, nil
+	}
+	tenant, ok := r.Lookup(key)
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return tenant, nil
+}
+
+type tenantKey struct{}
+
+func WithTenant(ctx context.Context, tenant *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// FromContext returns request tenant, nil for public namespace
+func FromContext(ctx context.Context) *Tenant {
+	tenant, _ := ctx.Value(tenantKey{}).(*Tenant)
+	return tenant
+}
+
+// NameFromContext returns tenant name for stats, empty for public namespace
+func NameFromContext(ctx context.Context) string {
+	if tenant := FromContext(ctx); tenant != nil {
+		return tenant.Name
+	}
+	return ""
+}
@@ -0,0 +1,59 @@
+package tenant
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lorem.video/internal/config"
+)
+
+func TestRegistryAndNamespace(t *testing.T) {
+	original := config.AppPaths.Tenants
+	config.AppPaths.Tenants = t.TempDir()
+	defer func() { config.AppPaths.Tenants = original }()
+
+	tenantsFile := filepath.Join(t.TempDir(), "tenants.json")
+	os.WriteFile(tenantsFile, []byte(`[{"name": "tv-team", "key": "secret", "quotaBytes": 25}]`), 0644)
+	registry, err := Load(tenantsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest("GET", "/intro_720p?key=secret", nil)
+	team, err := registry.FromRequest(req)
+	if err != nil || team == nil || team.Name != "tv-team" {
+		t.Fatalf("tenant not resolved from query key: %v %v", team, err)
+	}
+	req = httptest.NewRequest("GET", "/bunny", nil)
+	req.Header.Set(KeyHeader, "wrong")
+	if _, err := registry.FromRequest(req); err != ErrUnknownKey {
+		t.Errorf("expected unknown key error, got %v", err)
+	}
+
+	os.WriteFile(filepath.Join(team.SourceDir(), "intro.mp4"), []byte("video"), 0644)
+	spec, err := team.ParseFilename("intro_720p")
+	if err != nil || spec.Name != "intro" {
+		t.Errorf("tenant source not recognized: %+v %v", spec, err)
+	}
+	if path, err := team.FindSourceVideo("intro"); err != nil || filepath.Dir(path) != team.SourceDir() {
+		t.Errorf("tenant source not found: %s %v", path, err)
+	}
+
+	// 3 files of 10 bytes over 25 byte quota, oldest is evicted
+	for i, name := range []string{"old.mp4", "mid.mp4", "new.mp4"} {
+		path := filepath.Join(team.CacheDir(), name)
+		os.WriteFile(path, make([]byte, 10), 0644)
+		modTime := time.Now().Add(time.Duration(i-3) * time.Minute)
+		os.Chtimes(path, modTime, modTime)
+	}
+	team.EnforceQuota(func(string) bool { return false })
+	if _, err := os.Stat(filepath.Join(team.CacheDir(), "old.mp4")); !os.IsNotExist(err) {
+		t.Error("oldest cached file not evicted")
+	}
+	if _, err := os.Stat(filepath.Join(team.CacheDir(), "mid.mp4")); err != nil {
+		t.Error("file within quota evicted")
+	}
+}
@@ -0,0 +1,162 @@
+package tenant
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var (
+	ErrEncodeQuota = errors.New("monthly encode quota exceeded")
+	ErrEgressQuota = errors.New("monthly egress quota exceeded")
+)
+
+// Usage is tenant's consumption in calendar month (UTC), reset when month changes
+type Usage struct {
+	Month         string  `json:"month"` // YYYY-MM
+	EncodeSeconds float64 `json:"encodeSeconds"`
+	EgressBytes   int64   `json:"egressBytes"`
+}
+
+// usageCounter is shared by all requests of tenant
+type usageCounter struct {
+	mutex sync.Mutex
+	usage Usage
+	now   func() time.Time
+}
+
+func (c *usageCounter) current() *Usage {
+	month := c.now().UTC().Format("2006-01")
+	if c.usage.Month != month {
+		c.usage = Usage{Month: month}
+	}
+	return &c.usage
+}
+
+func (t *Tenant) counter() *usageCounter {
+	t.usageOnce.Do(func() {
+		if t.usage == nil {
+			t.usage = &usageCounter{now: time.Now}
+		}
+	})
+	return t.usage
+}
+
+func (t *Tenant) usagePath() string {
+	return filepath.Join(filepath.Dir(t.CacheDir()), "usage.json")
+}
+
+// RecordEncode adds FFmpeg wall time of tenant's transcode, nil tenant is no-op
+func (t *Tenant) RecordEncode(duration time.Duration) {
+	if t == nil {
+		return
+	}
+	counter := t.counter()
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	counter.current().EncodeSeconds += duration.Seconds()
+}
+
+// RecordEgress adds response bytes sent to tenant
+func (t *Tenant) RecordEgress(bytes int64) {
+	if t == nil {
+		return
+	}
+	counter := t.counter()
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	counter.current().EgressBytes += bytes
+}
+
+// Usage returns consumption in current month
+func (t *Tenant) Usage() Usage {
+	counter := t.counter()
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	return *counter.current()
+}
+
+// CheckEncodeQuota returns ErrEncodeQuota when new transcodes aren't allowed this month
+func (t *Tenant) CheckEncodeQuota() error {
+	if t == nil || t.MonthlyEncodeSeconds <= 0 {
+		return nil
+	}
+	if t.Usage().EncodeSeconds >= float64(t.MonthlyEncodeSeconds) {
+		return ErrEncodeQuota
+	}
+	return nil
+}
+
+// CheckEgressQuota returns ErrEgressQuota when tenant used up monthly transfer
+func (t *Tenant) CheckEgressQuota() error {
+	if t == nil || t.MonthlyEgressBytes <= 0 {
+		return nil
+	}
+	if t.Usage().EgressBytes >= t.MonthlyEgressBytes {
+		return ErrEgressQuota
+	}
+	return nil
+}
+
+// QuotaReset is when current month's usage resets
+func (t *Tenant) QuotaReset() time.Time {
+	now := t.counter().now().UTC()
+	return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// loadUsage restores persisted usage, older month is dropped on first access
+func (t *Tenant) loadUsage() {
+	data, err := os.ReadFile(t.usagePath())
+	if err != nil {
+		return
+	}
+	counter := t.counter()
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+	if err := json.Unmarshal(data, &counter.usage); err != nil {
+		log.Printf("Warning: Invalid usage file of tenant %s: %v", t.Name, err)
+	}
+}
+
+func (t *Tenant) saveUsage() error {
+	data, err := json.Marshal(t.Usage())
+	if err != nil {
+		return err
+	}
+	tmpPath := t.usagePath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, t.usagePath())
+}
+
+// StartUsagePersistence periodically writes usage of all tenants so counters survive restarts
+func (r *Registry) StartUsagePersistence(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			r.SaveUsage()
+		}
+	}()
+}
+
+// SaveUsage writes usage of all tenants
+func (r *Registry) SaveUsage() {
+	for _, tenant := range r.Tenants() {
+		if err := tenant.saveUsage(); err != nil {
+			log.Printf("Warning: Failed to save usage of tenant %s: %v", tenant.Name, err)
+		}
+	}
+}
+
+// RecordEgress adds bytes to tenant by name, for stats observer which only knows tenant name
+func (r *Registry) RecordEgress(name string, bytes int64) {
+	if tenant, ok := r.byName[name]; ok {
+		tenant.RecordEgress(bytes)
+	}
+}
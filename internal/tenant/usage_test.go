@@ -0,0 +1,37 @@
+package tenant
+
+import (
+	"testing"
+	"time"
+)
+
+func TestUsageQuotas(t *testing.T) {
+	now := time.Date(2026, 3, 31, 23, 0, 0, 0, time.UTC)
+	team := &Tenant{Name: "tv-team", MonthlyEncodeSeconds: 60, MonthlyEgressBytes: 1000}
+	team.usage = &usageCounter{now: func() time.Time { return now }}
+
+	team.RecordEncode(59 * time.Second)
+	team.RecordEgress(999)
+	if team.CheckEncodeQuota() != nil || team.CheckEgressQuota() != nil {
+		t.Fatal("quota exceeded too early")
+	}
+	team.RecordEncode(time.Second)
+	team.RecordEgress(1)
+	if team.CheckEncodeQuota() != ErrEncodeQuota || team.CheckEgressQuota() != ErrEgressQuota {
+		t.Error("expected both quotas exceeded")
+	}
+	if reset := team.QuotaReset(); !reset.Equal(time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("reset = %v", reset)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if usage := team.Usage(); usage.Month != "2026-04" || usage.EncodeSeconds != 0 || team.CheckEgressQuota() != nil {
+		t.Errorf("usage not reset in new month: %+v", usage)
+	}
+
+	var public *Tenant
+	public.RecordEncode(time.Hour)
+	if public.CheckEncodeQuota() != nil {
+		t.Error("public namespace has no quota")
+	}
+}
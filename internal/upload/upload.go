@@ -0,0 +1,282 @@
+package upload
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"lorem.video/internal/config"
+	"lorem.video/internal/parser"
+)
+
+// filenameRegex keeps source names parseable in specs, "_" separates spec parts
+var filenameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9-]{0,63}\.[a-z0-9]+$`)
+
+var (
+	ErrNotFound       = errors.New("upload not found")
+	ErrOffsetMismatch = errors.New("upload offset does not match")
+	ErrBusy           = errors.New("upload is already receiving data")
+	ErrTooLarge       = errors.New("upload exceeds maximum size")
+	ErrInvalidName    = errors.New("filename must be letters, digits or dashes with mp4 or webm extension, not a spec parameter like 720p")
+	ErrIncomplete     = errors.New("upload is not complete")
+)
+
+// Config from UPLOAD_* environment variables
+type Config struct {
+	MaxBytes int64         // Largest accepted Upload-Length
+	Expiry   time.Duration // Unfinished uploads are removed after this long
+}
+
+// ConfigFromEnv reads UPLOAD_MAX_BYTES (default 50GB) and UPLOAD_EXPIRY (default 24h)
+func ConfigFromEnv() Config {
+	uploadConfig := Config{
+		MaxBytes: config.GetEnvInt64("UPLOAD_MAX_BYTES"),
+		Expiry:   config.GetEnvDuration("UPLOAD_EXPIRY", 24*time.Hour),
+	}
+	if uploadConfig.MaxBytes <= 0 {
+		uploadConfig.MaxBytes = 50 << 30
+	}
+	return uploadConfig
+}
+
+// Upload statuses, accepted uploads are moved to source dir and no longer tracked
+const (
+	StatusUploading   = "uploading"
+	StatusValidating  = "validating"
+	StatusNormalizing = "normalizing"
+	StatusRejected    = "rejected" // Kept until expiry for inspection, see Problems
+)
+
+// Upload is state of one resumable upload, offset is size of received data on disk
+type Upload struct {
+	ID        string    `json:"id"`
+	Filename  string    `json:"filename"`
+	Length    int64     `json:"length"`
+	Offset    int64     `json:"offset"`
+	Status    string    `json:"status"`
+	Problems  []string  `json:"problems,omitempty"` // Validation failures of rejected upload
+	Warnings  []string  `json:"warnings,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (u Upload) Complete() bool {
+	return u.Offset == u.Length
+}
+
+// Store keeps partial uploads as {id}.part data with {id}.json info, so uploads survive restarts
+type Store struct {
+	mutex  sync.Mutex
+	dir    string
+	config Config
+	busy   map[string]bool // Uploads with PATCH in progress
+}
+
+func NewStore(dir string, uploadConfig Config) *Store {
+	return &Store{dir: dir, config: uploadConfig, busy: make(map[string]bool)}
+}
+
+func (s *Store) MaxBytes() int64 {
+	return s.config.MaxBytes
+}
+
+// Create registers new upload of length bytes, removing expired ones first
+func (s *Store) Create(filename string, length int64) (Upload, error) {
+	if !ValidFilename(filename) {
+		return Upload{}, ErrInvalidName
+	}
+	if length < 0 || length > s.config.MaxBytes {
+		return Upload{}, ErrTooLarge
+	}
+	s.RemoveExpired()
+
+	now := time.Now()
+	upload := Upload{
+		ID:        newID(),
+		Filename:  filename,
+		Length:    length,
+		Status:    StatusUploading,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.config.Expiry),
+	}
+
+	if err := os.WriteFile(s.partPath(upload.ID), nil, 0644); err != nil {
+		return Upload{}, err
+	}
+	if err := s.saveInfo(upload); err != nil {
+		os.Remove(s.partPath(upload.ID))
+		return Upload{}, err
+	}
+	return upload, nil
+}
+
+// SetStatus records validation progress or result of complete upload
+func (s *Store) SetStatus(id, status string, problems, warnings []string) (Upload, error) {
+	upload, err := s.Get(id)
+	if err != nil {
+		return Upload{}, err
+	}
+	upload.Status = status
+	upload.Problems = problems
+	upload.Warnings = warnings
+	return upload, s.saveInfo(upload)
+}
+
+func (s *Store) saveInfo(upload Upload) error {
+	data, err := json.Marshal(upload)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.infoPath(upload.ID), data, 0644)
+}
+
+// Get returns upload with offset read from part file size
+func (s *Store) Get(id string) (Upload, error) {
+	if !validID(id) {
+		return Upload{}, ErrNotFound
+	}
+	data, err := os.ReadFile(s.infoPath(id))
+	if err != nil {
+		return Upload{}, ErrNotFound
+	}
+	var upload Upload
+	if err := json.Unmarshal(data, &upload); err != nil {
+		return Upload{}, fmt.Errorf("invalid upload info %s: %w", id, err)
+	}
+
+	info, err := os.Stat(s.partPath(id))
+	if err != nil {
+		return Upload{}, ErrNotFound
+	}
+	upload.Offset = info.Size()
+	return upload, nil
+}
+
+// Append writes data at offset, which must equal received size. Data read before a broken connection is kept,
+// returned upload carries the new offset to resume from
+func (s *Store) Append(id string, offset int64, data io.Reader) (Upload, error) {
+	s.mutex.Lock()
+	if s.busy[id] {
+		s.mutex.Unlock()
+		return Upload{}, ErrBusy
+	}
+	s.busy[id] = true
+	s.mutex.Unlock()
+	defer func() {
+		s.mutex.Lock()
+		delete(s.busy, id)
+		s.mutex.Unlock()
+	}()
+
+	upload, err := s.Get(id)
+	if err != nil {
+		return Upload{}, err
+	}
+	if offset != upload.Offset {
+		return upload, ErrOffsetMismatch
+	}
+
+	file, err := os.OpenFile(s.partPath(id), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return upload, err
+	}
+	defer file.Close()
+
+	written, copyErr := io.Copy(file, io.LimitReader(data, upload.Length-upload.Offset))
+	upload.Offset += written
+	if err := file.Sync(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	return upload, copyErr
+}
+
+// Finish moves complete upload to dir under its filename
+func (s *Store) Finish(id, dir string) (string, error) {
+	upload, err := s.Get(id)
+	if err != nil {
+		return "", err
+	}
+	if !upload.Complete() {
+		return "", ErrIncomplete
+	}
+
+	destination := filepath.Join(dir, upload.Filename)
+	if err := os.Rename(s.partPath(id), destination); err != nil {
+		return "", err
+	}
+	os.Remove(s.infoPath(id))
+	return destination, nil
+}
+
+// Delete removes upload and its data
+func (s *Store) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	os.Remove(s.partPath(id))
+	return os.Remove(s.infoPath(id))
+}
+
+// RemoveExpired deletes unfinished and rejected uploads past their expiry
+func (s *Store) RemoveExpired() {
+	infos, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, info := range infos {
+		id := strings.TrimSuffix(filepath.Base(info), ".json")
+		if upload, err := s.Get(id); err == nil && upload.Status != StatusValidating && upload.Status != StatusNormalizing && now.After(upload.ExpiresAt) {
+			s.Delete(id)
+		}
+	}
+}
+
+// ValidFilename accepts names usable as spec source name with valid container extension,
+// names taken by spec parameters (720p, av1, 60s, mono, ...) would never be parsed as source
+func ValidFilename(filename string) bool {
+	if !filenameRegex.MatchString(filename) {
+		return false
+	}
+	ext := strings.TrimPrefix(filepath.Ext(filename), ".")
+	name := strings.TrimSuffix(filename, filepath.Ext(filename))
+	return slices.Contains(config.ValidContainers, ext) && !slices.Contains(config.SyntheticSources, name) && !parser.IsSpecToken(name)
+}
+
+// DataPath is received data of upload, for validation before Finish
+func (s *Store) DataPath(id string) string {
+	return s.partPath(id)
+}
+
+func (s *Store) partPath(id string) string {
+	return filepath.Join(s.dir, id+".part")
+}
+
+func (s *Store) infoPath(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// validID keeps ids from path values out of other directories
+func validID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
@@ -0,0 +1,78 @@
+package upload
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumeAfterPartialAppend(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, Config{MaxBytes: 100, Expiry: time.Hour})
+
+	upload, err := store.Create("mezzanine.mp4", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload, err = store.Append(upload.ID, 0, strings.NewReader("hello"))
+	if err != nil || upload.Offset != 5 {
+		t.Fatalf("expected offset 5, got %d (%v)", upload.Offset, err)
+	}
+
+	if _, err := store.Append(upload.ID, 0, strings.NewReader("again")); !errors.Is(err, ErrOffsetMismatch) {
+		t.Errorf("expected offset mismatch, got %v", err)
+	}
+	if _, err := store.Finish(upload.ID, dir); !errors.Is(err, ErrIncomplete) {
+		t.Errorf("expected incomplete, got %v", err)
+	}
+
+	// Bytes past Upload-Length are ignored
+	upload, err = store.Append(upload.ID, 5, strings.NewReader("worldEXTRA"))
+	if err != nil || !upload.Complete() {
+		t.Fatalf("expected complete upload, got %+v (%v)", upload, err)
+	}
+
+	sourceDir := t.TempDir()
+	path, err := store.Finish(upload.ID, sourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != "helloworld" || path != filepath.Join(sourceDir, "mezzanine.mp4") {
+		t.Errorf("unexpected result %s: %q", path, data)
+	}
+	if _, err := store.Get(upload.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("finished upload should be gone, got %v", err)
+	}
+}
+
+func TestValidFilename(t *testing.T) {
+	for filename, want := range map[string]bool{
+		"mezzanine.mp4":     true,
+		"clip-2.webm":       true,
+		"my_clip.mp4":       false,
+		"../escape.mp4":     false,
+		"clip.mov":          false,
+		"clock.mp4":         false,
+		"-leading-dash.mp4": false,
+		"720p.mp4":          false,
+		"1280x720.mp4":      false,
+		"av1.mp4":           false,
+		"aac.mp4":           false,
+		"60s.mp4":           false,
+		"30fps.mp4":         false,
+		"23crf.mp4":         false,
+		"128kbps.mp4":       false,
+		"mono.mp4":          false,
+		"vr360.mp4":         false,
+		"intro-60s.mp4":     true,
+	} {
+		if got := ValidFilename(filename); got != want {
+			t.Errorf("ValidFilename(%q) = %v, want %v", filename, got, want)
+		}
+	}
+}